@@ -0,0 +1,180 @@
+// Package bias measures the bias and the serial correlation of the bits of a raw source,
+// to select the bit planes worth debiasing and to predict the yield of Von Neumann debiasing.
+//
+// Bit positions are numbered by significance, position 0 is the least significant bit.
+// 16 bit samples are read little endian, as stored by WAV files and SDR captures.
+// The autocorrelation is computed over the bit stream taken most significant bit first,
+// the order in which the extractors consume their input.
+package bias
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrNoData is returned by Analyze if the reader did not provide any data.
+	ErrNoData = errors.New("bias: no data to analyze")
+
+	// ErrInvalidLags is returned for a number of lags outside of 1 to MaxLags.
+	ErrInvalidLags = errors.New("bias: invalid number of lags")
+)
+
+// MaxLags is the maximum number of autocorrelation lags.
+const MaxLags = 64
+
+// Config holds the parameters of the analysis.
+type Config struct {
+
+	// Lags is the number of autocorrelation lags, from 1 to MaxLags.
+	Lags int
+}
+
+// DefaultConfig computes the autocorrelation at lags 1 to 16 bits.
+var DefaultConfig = Config{
+	Lags: 16,
+}
+
+// Report holds the bias of the analyzed data.
+type Report struct {
+
+	// Bits is the number of analyzed bits, Ones the number of bits set.
+	Bits int64 `json:"bits"`
+	Ones int64 `json:"ones"`
+
+	// OnesRatio is the probability of a bit being set, 0.5 for unbiased data.
+	OnesRatio float64 `json:"onesRatio"`
+
+	// BytePositions holds the probability of each bit of a byte being set.
+	BytePositions [8]float64 `json:"bytePositions"`
+
+	// SamplePositions holds the probability of each bit of a 16 bit sample being set.
+	// A trailing byte that does not fill a sample is not counted.
+	SamplePositions [16]float64 `json:"samplePositions"`
+
+	// Autocorrelation holds the correlation coefficient of the bit stream with itself shifted by 1 to Lags bits,
+	// 0 for independent bits. It is undefined for a constant bit stream and reported as 1 in this case.
+	Autocorrelation []float64 `json:"autocorrelation"`
+
+	// PredictedYield is the expected number of Von Neumann output bits per input bit, p(1-p) for a ones ratio p,
+	// assuming independent bits. It can be compared to the Efficiency in the Stats of a run.
+	PredictedYield float64 `json:"predictedYield"`
+}
+
+// Yield returns the expected number of Von Neumann output bits per input bit for independent bits set with probability p.
+func Yield(p float64) float64 {
+	return p * (1 - p)
+}
+
+// Analyzer accumulates the bias of the data written to it.
+type Analyzer struct {
+	lags int
+
+	bytes      int64
+	byteOnes   [8]int64
+	samples    int64
+	sampleOnes [16]int64
+	low        byte
+	hasLow     bool
+
+	// the previous bits of the stream, the most recent in the lowest bit
+	history uint64
+	bits    int64
+
+	// coincident ones at each lag
+	pairs [MaxLags]int64
+}
+
+// NewAnalyzer returns an Analyzer for cfg, a zero number of lags uses DefaultConfig.
+func NewAnalyzer(cfg Config) (*Analyzer, error) {
+	if cfg.Lags == 0 {
+		cfg.Lags = DefaultConfig.Lags
+	}
+	if cfg.Lags < 1 || cfg.Lags > MaxLags {
+		return nil, ErrInvalidLags
+	}
+	return &Analyzer{lags: cfg.Lags}, nil
+}
+
+// Write adds p to the analyzed data, it never fails.
+func (a *Analyzer) Write(p []byte) (int, error) {
+	for _, b := range p {
+		a.bytes++
+		for i := 0; i < 8; i++ {
+			a.byteOnes[i] += int64(b >> uint(i) & 0x01)
+		}
+
+		if a.hasLow {
+			v := uint16(b)<<8 | uint16(a.low)
+			for i := 0; i < 16; i++ {
+				a.sampleOnes[i] += int64(v >> uint(i) & 0x01)
+			}
+			a.samples++
+			a.hasLow = false
+		} else {
+			a.low, a.hasLow = b, true
+		}
+
+		for i := 7; i >= 0; i-- {
+			bit := uint64(b >> uint(i) & 0x01)
+			if bit == 1 {
+				for k := 0; k < a.lags; k++ {
+					a.pairs[k] += int64(a.history >> uint(k) & 0x01)
+				}
+			}
+			a.history = a.history<<1 | bit
+			a.bits++
+		}
+	}
+	return len(p), nil
+}
+
+// Report returns the bias of the data written so far.
+func (a *Analyzer) Report() *Report {
+	r := &Report{
+		Bits:            a.bits,
+		Autocorrelation: make([]float64, a.lags),
+	}
+	if a.bytes == 0 {
+		return r
+	}
+
+	for i, c := range a.byteOnes {
+		r.Ones += c
+		r.BytePositions[i] = float64(c) / float64(a.bytes)
+	}
+	if a.samples > 0 {
+		for i, c := range a.sampleOnes {
+			r.SamplePositions[i] = float64(c) / float64(a.samples)
+		}
+	}
+
+	p := float64(r.Ones) / float64(r.Bits)
+	r.OnesRatio = p
+	r.PredictedYield = Yield(p)
+
+	for k := range r.Autocorrelation {
+		n := a.bits - int64(k+1)
+		if n <= 0 || p == 0 || p == 1 {
+			r.Autocorrelation[k] = 1
+			continue
+		}
+		r.Autocorrelation[k] = (float64(a.pairs[k])/float64(n) - p*p) / (p * (1 - p))
+	}
+	return r
+}
+
+// Analyze returns the bias of the data read from r.
+func Analyze(r io.Reader, cfg Config) (*Report, error) {
+	a, err := NewAnalyzer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(a, r); err != nil {
+		return nil, err
+	}
+	if a.bytes == 0 {
+		return nil, ErrNoData
+	}
+	return a.Report(), nil
+}
@@ -0,0 +1,143 @@
+package bias_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias/bias"
+)
+
+func TestBytePositions(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		data = make([]byte, 100000)
+	)
+	for i := range data {
+		// bit 0 is stuck at 1, bit 7 is set with p = 0.9, the rest is random
+		b := byte(rng.Intn(256))&0x7e | 0x01
+		if rng.Float64() < 0.9 {
+			b |= 0x80
+		}
+		data[i] = b
+	}
+
+	r, err := bias.Analyze(bytes.NewReader(data), bias.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Bits != 800000 {
+		t.Fatal("unexpected number of bits: ", r.Bits)
+	}
+	if r.BytePositions[0] != 1 {
+		t.Fatal("unexpected bias of bit 0: ", r.BytePositions[0])
+	}
+	if math.Abs(r.BytePositions[7]-0.9) > 0.01 {
+		t.Fatal("unexpected bias of bit 7: ", r.BytePositions[7])
+	}
+	for i := 1; i < 7; i++ {
+		if math.Abs(r.BytePositions[i]-0.5) > 0.01 {
+			t.Fatalf("unexpected bias of bit %d: %v", i, r.BytePositions[i])
+		}
+	}
+
+	want := (1 + 0.9 + 6*0.5) / 8
+	if math.Abs(r.OnesRatio-want) > 0.005 || r.PredictedYield != bias.Yield(r.OnesRatio) {
+		t.Fatalf("unexpected ones ratio %v or yield %v", r.OnesRatio, r.PredictedYield)
+	}
+
+	// the stuck bits repeat every 8 bits
+	if r.Autocorrelation[7] < 0.1 || r.Autocorrelation[7] < r.Autocorrelation[2]+0.1 {
+		t.Fatalf("unexpected autocorrelation: %v", r.Autocorrelation)
+	}
+}
+
+func TestSamplePositions(t *testing.T) {
+	var (
+		rng = rand.New(rand.NewSource(2))
+		buf bytes.Buffer
+	)
+
+	// 12 bit samples in 16 bits, the upper 4 bits are never set
+	for i := 0; i < 20000; i++ {
+		binary.Write(&buf, binary.LittleEndian, uint16(rng.Intn(1<<12)))
+	}
+	buf.WriteByte(0xff)
+
+	r, err := bias.Analyze(&buf, bias.Config{Lags: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Autocorrelation) != 4 {
+		t.Fatal("unexpected number of lags: ", len(r.Autocorrelation))
+	}
+	for i := 0; i < 16; i++ {
+		if i >= 12 && r.SamplePositions[i] != 0 {
+			t.Fatalf("unexpected bias of sample bit %d: %v", i, r.SamplePositions[i])
+		}
+		if i < 12 && math.Abs(r.SamplePositions[i]-0.5) > 0.02 {
+			t.Fatalf("unexpected bias of sample bit %d: %v", i, r.SamplePositions[i])
+		}
+	}
+}
+
+func TestAutocorrelation(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(3))
+		data = make([]byte, 50000)
+	)
+
+	// each bit repeats the previous one with p = 0.8
+	var prev byte
+	for i := range data {
+		for j := 7; j >= 0; j-- {
+			bit := prev
+			if rng.Float64() < 0.2 {
+				bit ^= 1
+			}
+			data[i] |= bit << uint(j)
+			prev = bit
+		}
+	}
+
+	a, err := bias.NewAnalyzer(bias.Config{Lags: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the history carries over between writes
+	for i := 0; i < len(data); i += 999 {
+		end := i + 999
+		if end > len(data) {
+			end = len(data)
+		}
+		a.Write(data[i:end])
+	}
+	r := a.Report()
+
+	// the correlation of a symmetric Markov chain is (1-2q)^k
+	for k, want := range []float64{0.6, 0.36, 0.216} {
+		if math.Abs(r.Autocorrelation[k]-want) > 0.02 {
+			t.Fatalf("lag %d: got %v, want %v", k+1, r.Autocorrelation[k], want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := bias.Analyze(bytes.NewReader(nil), bias.DefaultConfig); !errors.Is(err, bias.ErrNoData) {
+		t.Fatal("expected ErrNoData, got ", err)
+	}
+	if _, err := bias.NewAnalyzer(bias.Config{Lags: bias.MaxLags + 1}); !errors.Is(err, bias.ErrInvalidLags) {
+		t.Fatal("expected ErrInvalidLags, got ", err)
+	}
+
+	r, err := bias.Analyze(bytes.NewReader([]byte{0, 0, 0}), bias.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OnesRatio != 0 || r.PredictedYield != 0 || r.Autocorrelation[0] != 1 || len(r.Autocorrelation) != bias.DefaultConfig.Lags {
+		t.Fatalf("unexpected report for constant data: %+v", r)
+	}
+}
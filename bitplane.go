@@ -0,0 +1,136 @@
+package debias
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// BitPlaneConfig selects the bits of each sample passed on to the extractor.
+type BitPlaneConfig struct {
+
+	// SampleBits is the width of a sample, 8, 16, 24 or 32 bits, zero means 8.
+	SampleBits int
+
+	// Positions lists the bit positions taken from each sample in output order,
+	// position 0 is the least significant bit. Nil selects the least significant bit only.
+	Positions []int
+
+	// BigEndian reads samples of more than 8 bits big endian,
+	// by default they are read little endian as stored in WAV files and IQ captures.
+	BigEndian bool
+}
+
+// LowestBits returns the positions of the k least significant bits, the most significant of them first.
+func LowestBits(k int) []int {
+	positions := make([]int, k)
+	for i := range positions {
+		positions[i] = k - 1 - i
+	}
+	return positions
+}
+
+// BitPlaneReader passes on selected bits of the samples read from a source,
+// packed most significant bit first. Bits that do not fill a complete byte at the end of the input are dropped.
+type BitPlaneReader struct {
+	src       io.Reader
+	positions []int
+	width     int
+	bigEndian bool
+
+	buf     []byte
+	partial []byte
+	out     bytes.Buffer
+	bw      bitWriter
+	err     error
+
+	samples int64
+}
+
+// NewBitPlaneReader returns a BitPlaneReader selecting the bits configured in cfg from the samples read from src.
+func NewBitPlaneReader(src io.Reader, cfg BitPlaneConfig) (*BitPlaneReader, error) {
+	if cfg.SampleBits == 0 {
+		cfg.SampleBits = 8
+	}
+	if cfg.SampleBits%8 != 0 || cfg.SampleBits < 8 || cfg.SampleBits > 32 {
+		return nil, fmt.Errorf("%w: sample width %d", ErrInvalidSize, cfg.SampleBits)
+	}
+	positions := cfg.Positions
+	if positions == nil {
+		positions = []int{0}
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("%w: no bit positions selected", ErrInvalidSize)
+	}
+	for _, p := range positions {
+		if p < 0 || p >= cfg.SampleBits {
+			return nil, fmt.Errorf("%w: bit position %d of a %d bit sample", ErrInvalidSize, p, cfg.SampleBits)
+		}
+	}
+
+	return &BitPlaneReader{
+		src:       src,
+		positions: append([]int(nil), positions...),
+		width:     cfg.SampleBits / 8,
+		bigEndian: cfg.BigEndian,
+		buf:       make([]byte, MaxChunkSize),
+	}, nil
+}
+
+// Read reads the selected bits into p.
+func (r *BitPlaneReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 && r.err == nil {
+		r.fill()
+	}
+	if r.out.Len() > 0 {
+		return r.out.Read(p)
+	}
+	return 0, r.err
+}
+
+// fill reads the next chunk from the source and packs the selected bits of its complete samples.
+func (r *BitPlaneReader) fill() {
+	n, err := r.src.Read(r.buf)
+	data := r.buf[:n]
+	if len(r.partial) > 0 {
+		data = append(r.partial, data...)
+		r.partial = r.partial[:0]
+	}
+
+	complete := len(data) - len(data)%r.width
+	for i := 0; i < complete; i += r.width {
+		v := r.sample(data[i : i+r.width])
+		for _, pos := range r.positions {
+			r.bw.writeBit(byte(v>>uint(pos)&0x01), &r.out)
+		}
+		r.samples++
+	}
+	r.partial = append(r.partial, data[complete:]...)
+
+	if err != nil {
+		r.bw.flush(&r.out, DropPartial)
+		r.err = err
+	}
+}
+
+func (r *BitPlaneReader) sample(b []byte) uint32 {
+	var v uint32
+	for i := range b {
+		if r.bigEndian {
+			v = v<<8 | uint32(b[i])
+		} else {
+			v = v<<8 | uint32(b[len(b)-1-i])
+		}
+	}
+	return v
+}
+
+// BitsOut returns the number of selected bits passed on so far.
+func (r *BitPlaneReader) BitsOut() int64 {
+	return r.bw.bits
+}
+
+// ReportStats sets the number of bits passed on to the extractor.
+func (r *BitPlaneReader) ReportStats(s *Stats) {
+	s.BitsUsed = r.bw.bits
+}
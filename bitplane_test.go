@@ -0,0 +1,84 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func TestBitPlaneReader(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  debias.BitPlaneConfig
+		in   []byte
+		out  bitString
+	}{
+		{
+			name: "lsb of bytes",
+			cfg:  debias.BitPlaneConfig{},
+			in:   []byte{1, 0, 3, 2, 5, 4, 7, 6, 9},
+			out:  "10101010",
+		},
+		{
+			name: "lowest two bits",
+			cfg:  debias.BitPlaneConfig{Positions: debias.LowestBits(2)},
+			in:   []byte{1, 2, 3, 0},
+			out:  "01101100",
+		},
+		{
+			name: "16 bit little endian",
+			cfg:  debias.BitPlaneConfig{SampleBits: 16, Positions: []int{15, 8, 0}},
+			in:   []byte{0x01, 0x80, 0x00, 0x01, 0x01, 0x01},
+			out:  "101010011",
+		},
+		{
+			name: "16 bit big endian",
+			cfg:  debias.BitPlaneConfig{SampleBits: 16, Positions: []int{15, 8, 0}, BigEndian: true},
+			in:   []byte{0x80, 0x01, 0x01, 0x00, 0x01, 0x01},
+			out:  "101010011",
+		},
+		{
+			name: "24 bit",
+			cfg:  debias.BitPlaneConfig{SampleBits: 24, Positions: []int{23, 22, 21, 20, 3, 2, 1, 0}},
+			in:   []byte{0x05, 0x00, 0xa0},
+			out:  "10100101",
+		},
+	}
+	for _, tt := range tests {
+		r, err := debias.NewBitPlaneReader(iotest.OneByteReader(bytes.NewReader(tt.in)), tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		// bits that do not fill a byte are dropped
+		want := tt.out[:len(tt.out)/8*8].bytes()
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %08b, want %08b", tt.name, got, want)
+		}
+		if r.BitsOut() != int64(len(got)*8) {
+			t.Errorf("%s: unexpected number of bits %d", tt.name, r.BitsOut())
+		}
+	}
+}
+
+func TestBitPlaneReaderErrors(t *testing.T) {
+	for _, cfg := range []debias.BitPlaneConfig{
+		{SampleBits: 12},
+		{SampleBits: 64},
+		{Positions: []int{8}},
+		{Positions: []int{-1}},
+		{Positions: []int{}},
+	} {
+		if _, err := debias.NewBitPlaneReader(bytes.NewReader(nil), cfg); !errors.Is(err, debias.ErrInvalidSize) {
+			t.Errorf("%+v: expected ErrInvalidSize, got %v", cfg, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package debias
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// DefaultBlumOrder is the number of previous bits forming the state of the Blum extractor registered for ModeBlum.
+var DefaultBlumOrder = 2
+
+// maxBlumOrder limits the number of states to 2^16.
+const maxBlumOrder = 16
+
+func init() {
+	Register(ModeBlum, "blum", func(r io.Reader) Extractor {
+		ex, err := NewBlum(r, DefaultBlumOrder)
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+		return ex
+	})
+}
+
+// NewBlum returns an Extractor applying the algorithm by Manuel Blum (1986) to the data read from r.
+// The source is modelled as a finite state Markov chain, where the state is formed by the previous order bits.
+//
+// Von Neumann debiasing is applied separately to the sequence of bits leaving each state:
+// the first bit leaving a state is stored, the next bit leaving the same state forms a pair with it.
+// Pairs are handled as for Von Neumann debiasing and the output is written in the order the pairs complete.
+// In contrast to plain Von Neumann debiasing this results in independent and unbiased bits
+// for sources with correlations up to the given order.
+//
+// The order must be in the range 1..16, otherwise ErrInvalidSize is returned.
+func NewBlum(r io.Reader, order int) (*Reader, error) {
+	bl, err := newBlum(order)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(r, bl), nil
+}
+
+// blum implements the Blum Markov chain extractor.
+type blum struct {
+	order   int
+	out     bitWriter
+	padding Padding
+
+	// previous bits, the lowest order bits form the current state
+	state uint32
+	mask  uint32
+
+	// number of bits seen, the first order bits only initialize the state
+	numBits int
+
+	// pending bit that left the state, with pendingSet marking states that have one
+	pending    []byte
+	pendingSet []bool
+}
+
+func newBlum(order int) (*blum, error) {
+	if order < 1 || order > maxBlumOrder {
+		return nil, fmt.Errorf("%w: Blum order %d", ErrInvalidSize, order)
+	}
+	return &blum{
+		order:      order,
+		mask:       1<<uint(order) - 1,
+		pending:    make([]byte, 1<<uint(order)),
+		pendingSet: make([]bool, 1<<uint(order)),
+	}, nil
+}
+
+func (bl *blum) Process(p []byte, out *bytes.Buffer) error {
+	for _, b := range p {
+		for j := 7; j >= 0; j-- {
+
+			bit := (b >> j) & 0x01
+
+			if bl.numBits < bl.order {
+				bl.numBits++
+			} else {
+				s := bl.state & bl.mask
+
+				if !bl.pendingSet[s] {
+					bl.pending[s] = bit
+					bl.pendingSet[s] = true
+				} else {
+					if bl.pending[s] != bit {
+						bl.out.writeBit(bl.pending[s], out)
+					}
+					bl.pendingSet[s] = false
+				}
+			}
+
+			bl.state = bl.state<<1 | uint32(bit)
+		}
+	}
+	return nil
+}
+
+func (bl *blum) Flush(out *bytes.Buffer) error {
+	// write leftover
+	bl.out.flush(out, bl.padding)
+	return nil
+}
+
+func (bl *blum) SetPadding(p Padding) {
+	bl.padding = p
+}
+
+func (bl *blum) BitsOut() int64 {
+	return bl.out.bits
+}
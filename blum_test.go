@@ -0,0 +1,108 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// markov returns size bytes from a first order Markov chain,
+// that changes from 0 to 1 with probability p01 and from 1 to 0 with probability p10.
+func markov(size int, p01, p10 float64, seed int64) []byte {
+	var (
+		rnd  = rand.New(rand.NewSource(seed))
+		data = make([]byte, size)
+		bit  byte
+	)
+	for i := range data {
+		for j := 7; j >= 0; j-- {
+			if bit == 0 && rnd.Float64() < p01 || bit == 1 && rnd.Float64() >= p10 {
+				bit = 1
+			} else {
+				bit = 0
+			}
+			data[i] |= bit << j
+		}
+	}
+	return data
+}
+
+// repeatRatio returns the fraction of bits that are equal to their predecessor,
+// which is 0.5 for independent and unbiased bits.
+func repeatRatio(data []byte) float64 {
+	var (
+		same, n int
+		last    = byte(2)
+	)
+	for _, b := range data {
+		for j := 7; j >= 0; j-- {
+			bit := (b >> j) & 0x01
+			if last != 2 {
+				n++
+				if bit == last {
+					same++
+				}
+			}
+			last = bit
+		}
+	}
+	return float64(same) / float64(n)
+}
+
+func TestBlum(t *testing.T) {
+	r, err := debias.NewBlum(bytes.NewReader(bitString("01101001").bytes()), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// state 1 sees the pair 10 and state 0 the pairs 11 and 01
+	if r.BitsOut() != 2 || !bytes.Equal(out, bitString("10000000").bytes()) {
+		t.Fatalf("expected the bits 10, got %d bits and %08b", r.BitsOut(), out)
+	}
+}
+
+func TestBlumOrder(t *testing.T) {
+	for _, order := range []int{0, 17} {
+		if _, err := debias.NewBlum(bytes.NewReader(nil), order); !errors.Is(err, debias.ErrInvalidSize) {
+			t.Fatalf("order %d: expected ErrInvalidSize, got %v", order, err)
+		}
+	}
+}
+
+func TestBlumMarkovSource(t *testing.T) {
+	data := markov(200000, 0.1, 0.4, 1)
+
+	neumann, err := ioutil.ReadAll(debias.NewVonNeumann(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := debias.NewBlum(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blum, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// consecutive Von Neumann output bits are correlated
+	if ratio := repeatRatio(neumann); ratio > 0.45 {
+		t.Fatal("expected biased Von Neumann output, got a repeat ratio of ", ratio)
+	}
+	if ratio := repeatRatio(blum); ratio < 0.49 || ratio > 0.51 {
+		t.Fatal("Blum output is biased: repeat ratio ", ratio)
+	}
+
+	if len(blum) <= len(neumann) {
+		t.Fatal("expected a higher yield than Von Neumann, got ", len(blum), " bytes")
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/dreadl0ck/debias/bias"
+	"github.com/dreadl0ck/debias/ent"
+	"github.com/dreadl0ck/debias/entropy"
+	"github.com/dreadl0ck/debias/minentropy"
+	"github.com/dreadl0ck/debias/nist"
+)
+
+// analysis holds the results of all analyses of one input.
+type analysis struct {
+	Ent        *ent.Report        `json:"ent"`
+	Bytes      *entropy.Report    `json:"renyiBytes"`
+	Bits       *entropy.Report    `json:"renyiBits"`
+	Bias       *bias.Report       `json:"bias"`
+	MinEntropy *minentropy.Report `json:"minEntropy,omitempty"`
+	NIST       *nist.Report       `json:"nist,omitempty"`
+}
+
+// analysisConfig selects the expensive analyses, which need the data in memory.
+type analysisConfig struct {
+	minEntropy bool
+	nist       bool
+	sequences  int
+}
+
+// analyzer runs the streaming analyses on the data written to it,
+// and keeps the prefix needed by the SP 800-90B estimators and the SP 800-22 tests.
+type analyzer struct {
+	cfg   analysisConfig
+	ent   ent.Analyzer
+	bytes *entropy.Counter
+	bits  *entropy.Counter
+	bias  *bias.Analyzer
+
+	keep []byte
+	max  int
+}
+
+func newAnalyzer(cfg analysisConfig) *analyzer {
+	a := &analyzer{cfg: cfg}
+	a.bytes, _ = entropy.NewCounter(8)
+	a.bits, _ = entropy.NewCounter(1)
+	a.bias, _ = bias.NewAnalyzer(bias.DefaultConfig)
+
+	if cfg.minEntropy {
+		a.max = minentropy.DefaultConfig.MaxSamples
+	}
+	if n := cfg.sequences * nist.DefaultConfig.SequenceLength / 8; cfg.nist && n > a.max {
+		a.max = n
+	}
+	return a
+}
+
+func (a *analyzer) Write(p []byte) (int, error) {
+	a.ent.Write(p)
+	a.bytes.Write(p)
+	a.bits.Write(p)
+	a.bias.Write(p)
+	if n := a.max - len(a.keep); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		a.keep = append(a.keep, p[:n]...)
+	}
+	return len(p), nil
+}
+
+func (a *analyzer) result() (*analysis, error) {
+	if a.bytes.Symbols() == 0 {
+		return nil, ent.ErrNoData
+	}
+	res := &analysis{
+		Ent:  a.ent.Report(),
+		Bias: a.bias.Report(),
+	}
+	res.Bytes, _ = a.bytes.Report()
+	res.Bits, _ = a.bits.Report()
+
+	if a.cfg.minEntropy {
+		samples, err := minentropy.Samples(a.keep, minentropy.DefaultConfig.Width)
+		if err != nil {
+			return nil, err
+		}
+		if len(samples) > minentropy.DefaultConfig.MaxSamples {
+			samples = samples[:minentropy.DefaultConfig.MaxSamples]
+		}
+		if res.MinEntropy, err = minentropy.NonIID(samples, minentropy.DefaultConfig.Width); err != nil {
+			return nil, err
+		}
+	}
+	if a.cfg.nist {
+		var err error
+		res.NIST, err = nist.Run(bytes.NewReader(a.keep), nist.Config{Sequences: a.cfg.sequences})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func analyzeCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		fs     = newFlagSet("analyze", "[file|-]", stderr)
+		asJSON = fs.Bool("json", false, "print the results as JSON")
+		cfg    analysisConfig
+		input  inputFlags
+	)
+	fs.BoolVar(&cfg.minEntropy, "min-entropy", false, "run the SP 800-90B non-IID estimators on the first million bytes")
+	fs.BoolVar(&cfg.nist, "nist", false, "run the SP 800-22 test suite")
+	fs.IntVar(&cfg.sequences, "sequences", 10, "number of sequences of one million bits for -nist")
+	input.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	name := "-"
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	res, err := analyzeInput(name, &input, cfg, stdin)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return printAnalyses(stdout, []string{name}, []*analysis{res})
+}
+
+// analyzeInput decodes and analyzes the named input.
+func analyzeInput(name string, input *inputFlags, cfg analysisConfig, stdin io.Reader) (*analysis, error) {
+	src, err := openInput(name, stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	r, err := input.decode(name, src)
+	if err != nil {
+		return nil, err
+	}
+	a := newAnalyzer(cfg)
+	if _, err = io.Copy(a, r); err != nil {
+		return nil, err
+	}
+	return a.result()
+}
+
+// printAnalyses prints the analyses side by side, in a column per title.
+func printAnalyses(w io.Writer, titles []string, results []*analysis) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	row := func(name string, value func(a *analysis) string) {
+		fmt.Fprint(tw, name)
+		for _, a := range results {
+			fmt.Fprint(tw, "\t", value(a))
+		}
+		fmt.Fprintln(tw)
+	}
+	float := func(format string, value func(a *analysis) float64) func(a *analysis) string {
+		return func(a *analysis) string {
+			return fmt.Sprintf(format, value(a))
+		}
+	}
+
+	fmt.Fprint(tw, "")
+	for _, t := range titles {
+		fmt.Fprint(tw, "\t", t)
+	}
+	fmt.Fprintln(tw)
+
+	row("bytes", func(a *analysis) string { return fmt.Sprint(a.Ent.Bytes) })
+	row("shannon entropy (bits/byte)", float("%.6f", func(a *analysis) float64 { return a.Bytes.Shannon }))
+	row("collision entropy (bits/byte)", float("%.6f", func(a *analysis) float64 { return a.Bytes.Collision }))
+	row("min-entropy (bits/byte)", float("%.6f", func(a *analysis) float64 { return a.Bytes.Min }))
+	row("min-entropy of bits (bits/bit)", float("%.6f", func(a *analysis) float64 { return a.Bits.Min }))
+	row("compression (%)", float("%.2f", func(a *analysis) float64 { return a.Ent.Compression }))
+	row("chi-square", float("%.2f", func(a *analysis) float64 { return a.Ent.ChiSquare }))
+	row("chi-square p-value", float("%.4f", func(a *analysis) float64 { return a.Ent.ChiSquareP }))
+	row("arithmetic mean", float("%.4f", func(a *analysis) float64 { return a.Ent.Mean }))
+	row("monte carlo pi", float("%.6f", func(a *analysis) float64 { return a.Ent.MonteCarloPi }))
+	row("serial correlation", float("%.6f", func(a *analysis) float64 { return a.Ent.SerialCorrelation }))
+	row("ones ratio", float("%.6f", func(a *analysis) float64 { return a.Bias.OnesRatio }))
+	row("autocorrelation lag 1", float("%.6f", func(a *analysis) float64 { return a.Bias.Autocorrelation[0] }))
+	row("predicted von neumann yield", float("%.6f", func(a *analysis) float64 { return a.Bias.PredictedYield }))
+	for i := 7; i >= 0; i-- {
+		i := i
+		row(fmt.Sprintf("P(bit %d)", i), float("%.4f", func(a *analysis) float64 { return a.Bias.BytePositions[i] }))
+	}
+
+	if results[0].MinEntropy != nil {
+		row("SP 800-90B min-entropy (bits/bit)", func(a *analysis) string {
+			if a.MinEntropy == nil {
+				return "-"
+			}
+			return fmt.Sprintf("%.6f", a.MinEntropy.PerBit)
+		})
+	}
+	if results[0].NIST != nil {
+		row("SP 800-22 bits tested", func(a *analysis) string {
+			if a.NIST == nil {
+				return "-"
+			}
+			return fmt.Sprint(a.NIST.BitsTested)
+		})
+
+		// tests with several p-values pass if all of them pass
+		var names []string
+		for _, s := range results[0].NIST.Summary {
+			if len(names) == 0 || names[len(names)-1] != s.Name {
+				names = append(names, s.Name)
+			}
+		}
+		for _, name := range names {
+			name := name
+			row(name, func(a *analysis) string {
+				if a.NIST == nil {
+					return "-"
+				}
+				ok := true
+				for _, s := range a.NIST.Summary {
+					if s.Name == name {
+						ok = ok && s.Passed
+					}
+				}
+				return passed(ok)
+			})
+		}
+		row("SP 800-22", func(a *analysis) string {
+			if a.NIST == nil {
+				return "-"
+			}
+			return passed(a.NIST.Passed)
+		})
+	}
+	return tw.Flush()
+}
+
+func passed(ok bool) string {
+	if ok {
+		return "passed"
+	}
+	return "FAILED"
+}
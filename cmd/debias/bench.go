@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func benchCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		fs    = newFlagSet("bench", "[file]", stderr)
+		size  = fs.Int("size", 1<<16, "number of generated input bytes")
+		p     = fs.Float64("p", 0.7, "probability of a generated bit being 1")
+		seed  = fs.Int64("input-seed", 1, "seed of the generated input")
+		modes = fs.String("modes", "", "comma separated modes, by default all modes but "+debias.ModeTrevisan.String())
+		input inputFlags
+		stage stageFlags
+		ex    extractorFlags
+	)
+	input.register(fs)
+	stage.register(fs)
+	ex.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if _, err := ex.config(); err != nil {
+		return err
+	}
+
+	// the input is held in memory, so reading it is not part of the measurement
+	var (
+		data    []byte
+		decoded io.Reader
+	)
+	if fs.NArg() == 1 {
+		src, err := openInput(fs.Arg(0), stdin)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if decoded, err = input.decode(fs.Arg(0), src); err != nil {
+			return err
+		}
+		if data, err = ioutil.ReadAll(decoded); err != nil {
+			return err
+		}
+	} else {
+		data = biased(*size, *p, *seed)
+	}
+
+	var selected []debias.Mode
+	for _, m := range debias.Modes() {
+		if !slowModes[m] {
+			selected = append(selected, m)
+		}
+	}
+	if *modes != "" {
+		selected = nil
+		for _, name := range strings.Split(*modes, ",") {
+			m, err := debias.ParseMode(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			selected = append(selected, m)
+		}
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tBYTES IN\tBYTES OUT\tEFFICIENCY\tDURATION\tMB/S IN\tMB/S OUT")
+	for _, m := range selected {
+		start := time.Now()
+		r, err := stage.apply(bytes.NewReader(data), decoded)
+		if err != nil {
+			return err
+		}
+		extractor, err := ex.newExtractor(m, r)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(ioutil.Discard, extractor)
+		dur := time.Since(start)
+		extractor.Close()
+		if err != nil {
+			fmt.Fprintf(tw, "%s\t%d\terror: %v\n", m, len(data), err)
+			continue
+		}
+
+		bitsOut := n * 8
+		if bc, ok := extractor.(debias.BitCounter); ok {
+			bitsOut = bc.BitsOut()
+		}
+
+		// with a stage, the extractor only sees the selected or derived bits
+		bitsIn := int64(len(data) * 8)
+		if sr, ok := r.(debias.StatsReporter); ok {
+			var s debias.Stats
+			sr.ReportStats(&s)
+			bitsIn = s.BitsUsed
+		}
+		var (
+			secs       = dur.Seconds()
+			efficiency float64
+		)
+		if bitsIn > 0 {
+			efficiency = float64(bitsOut) / float64(bitsIn)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.4f\t%v\t%.2f\t%.2f\n", m, len(data), n, efficiency, dur.Round(time.Microsecond),
+			float64(len(data))/secs/1e6, float64(n)/secs/1e6)
+	}
+	return tw.Flush()
+}
+
+// slowModes are only benchmarked when they are selected explicitly.
+var slowModes = map[debias.Mode]bool{
+	debias.ModeTrevisan: true,
+}
+
+// biased returns size bytes whose bits are 1 with probability p.
+func biased(size int, p float64, seed int64) []byte {
+	var (
+		rng  = rand.New(rand.NewSource(seed))
+		data = make([]byte, size)
+	)
+	for i := range data {
+		for j := 0; j < 8; j++ {
+			if rng.Float64() < p {
+				data[i] |= 1 << uint(j)
+			}
+		}
+	}
+	return data
+}
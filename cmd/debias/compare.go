@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func compareCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		fs     = newFlagSet("compare", "input [output]", stderr)
+		mode   = fs.String("mode", debias.ModeVonNeumann.String(), "extraction mode used without an output file: "+modeNames())
+		asJSON = fs.Bool("json", false, "print the results as JSON")
+		cfg    analysisConfig
+		input  inputFlags
+		stage  stageFlags
+		ex     extractorFlags
+	)
+	fs.BoolVar(&cfg.minEntropy, "min-entropy", false, "run the SP 800-90B non-IID estimators on the first million bytes")
+	fs.BoolVar(&cfg.nist, "nist", false, "run the SP 800-22 test suite")
+	fs.IntVar(&cfg.sequences, "sequences", 10, "number of sequences of one million bits for -nist")
+	input.register(fs)
+	stage.register(fs)
+	ex.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var (
+		in, out *analysis
+		err     error
+	)
+	if fs.NArg() == 2 {
+		// the output was produced before, it is not decoded
+		if in, err = analyzeInput(fs.Arg(0), &input, cfg, stdin); err != nil {
+			return err
+		}
+		if out, err = analyzeInput(fs.Arg(1), &inputFlags{format: "raw"}, cfg, stdin); err != nil {
+			return err
+		}
+	} else {
+		m, err := debias.ParseMode(*mode)
+		if err != nil {
+			return err
+		}
+		if in, out, err = debiasAndAnalyze(fs.Arg(0), m, &ex, &input, &stage, cfg, stdin); err != nil {
+			return err
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Input  *analysis `json:"input"`
+			Output *analysis `json:"output"`
+		}{in, out})
+	}
+	if err = printAnalyses(stdout, []string{"input", "output"}, []*analysis{in, out}); err != nil {
+		return err
+	}
+	if in.Ent.Bytes > 0 {
+		fmt.Fprintf(stdout, "\noutput/input ratio %.6f, predicted von neumann yield %.6f\n",
+			float64(out.Ent.Bytes)/float64(in.Ent.Bytes), in.Bias.PredictedYield)
+	}
+	return nil
+}
+
+// debiasAndAnalyze debiases the named input and analyzes the decoded input and the output,
+// without writing the output.
+func debiasAndAnalyze(name string, m debias.Mode, exFlags *extractorFlags, input *inputFlags, stage *stageFlags, cfg analysisConfig, stdin io.Reader) (*analysis, *analysis, error) {
+	src, err := openInput(name, stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer src.Close()
+
+	decoded, err := input.decode(name, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the analysis of the input sees the samples before the bit selection
+	var (
+		inAnalyzer  = newAnalyzer(cfg)
+		outAnalyzer = newAnalyzer(cfg)
+		tee         = io.TeeReader(decoded, inAnalyzer)
+	)
+	r, err := stage.apply(tee, decoded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ex, err := exFlags.newExtractor(m, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ex.Close()
+	if _, err = io.Copy(outAnalyzer, ex); err != nil {
+		return nil, nil, err
+	}
+	// count trailing input the extractor left unread
+	if _, err = io.Copy(ioutil.Discard, tee); err != nil {
+		return nil, nil, err
+	}
+
+	in, err := inAnalyzer.result()
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := outAnalyzer.result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("output: %w", err)
+	}
+	return in, out, nil
+}
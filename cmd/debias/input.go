@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/iq"
+	"github.com/dreadl0ck/debias/wav"
+)
+
+// inputFlags select how the input is decoded.
+type inputFlags struct {
+	format    string
+	component string
+	channels  string
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "", "input format: raw, wav, cu8, cs8, cs16 or cf32, by default taken from the file extension")
+	fs.StringVar(&f.component, "component", "interleaved", "IQ component: interleaved, i, q, magnitude or phase")
+	fs.StringVar(&f.channels, "channels", "", "comma separated WAV channels starting at 0, by default all channels")
+}
+
+// openInput opens the named file, - reads from stdin.
+func openInput(name string, stdin io.Reader) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+// resolveFormat returns the format of the named input, stdin is raw unless the format is set.
+func (f *inputFlags) resolveFormat(name string) string {
+	if f.format != "" {
+		return strings.ToLower(f.format)
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	if _, ok := iq.FormatFromExt(ext); ok || ext == "wav" {
+		return ext
+	}
+	return "raw"
+}
+
+func (f *inputFlags) wavConfig() (wav.Config, error) {
+	var cfg wav.Config
+	if f.channels == "" {
+		return cfg, nil
+	}
+	for _, c := range strings.Split(f.channels, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil {
+			return cfg, fmt.Errorf("invalid channel %q", c)
+		}
+		cfg.Channels = append(cfg.Channels, n)
+	}
+	return cfg, nil
+}
+
+func (f *inputFlags) iqConfig() (iq.Config, error) {
+	for c := iq.Interleaved; c <= iq.Phase; c++ {
+		if c.String() == strings.ToLower(f.component) {
+			return iq.Config{Component: c}, nil
+		}
+	}
+	return iq.Config{}, fmt.Errorf("unknown IQ component %q", f.component)
+}
+
+// decode returns the reader of the sample payload of the named input.
+func (f *inputFlags) decode(name string, r io.Reader) (io.Reader, error) {
+	format := f.resolveFormat(name)
+	switch format {
+	case "raw":
+		return r, nil
+	case "wav":
+		cfg, err := f.wavConfig()
+		if err != nil {
+			return nil, err
+		}
+		return wav.NewReader(r, cfg)
+	}
+
+	cfg, err := f.iqConfig()
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if cfg.Format, ok = iq.FormatFromExt(format); !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return iq.NewReader(r, cfg)
+}
+
+// options returns the options decoding the inputs of File and Directory,
+// which select the format by extension unless it is set.
+func (f *inputFlags) options() ([]debias.Option, error) {
+	format := strings.ToLower(f.format)
+	if format == "raw" {
+		return []debias.Option{debias.WithRaw()}, nil
+	}
+	w, err := f.wavConfig()
+	if err != nil {
+		return nil, err
+	}
+	c, err := f.iqConfig()
+	if err != nil {
+		return nil, err
+	}
+	opts := []debias.Option{debias.WithWAV(w), debias.WithIQ(c)}
+	if format != "" {
+		if _, ok := iq.FormatFromExt(format); !ok && format != "wav" {
+			return nil, fmt.Errorf("unknown format %q", f.format)
+		}
+		opts = append(opts, debias.WithFormat(format))
+	}
+	return opts, nil
+}
+
+// stageFlags select the bits of the samples passed to the extractor.
+type stageFlags struct {
+	bits       int
+	sampleBits int
+	bigEndian  bool
+	derive     string
+	window     int
+	level      float64
+}
+
+func (f *stageFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&f.bits, "bits", 0, "pass only the given number of least significant bits of each sample")
+	fs.IntVar(&f.sampleBits, "sample-bits", 8, "sample width in bits for -bits: 8, 16, 24 or 32")
+	fs.BoolVar(&f.bigEndian, "big-endian", false, "read samples big endian for -bits")
+	fs.StringVar(&f.derive, "derive", "", "derive bits from the sample values: difference-sign, median-threshold, mean-threshold or zero-crossing-parity")
+	fs.IntVar(&f.window, "window", debias.DefaultDerivationWindow, "number of samples of the moving thresholds")
+	fs.Float64Var(&f.level, "level", 0, "level crossed for zero-crossing-parity")
+}
+
+func (f *stageFlags) derivation() (*debias.DerivationConfig, error) {
+	if f.derive == "" {
+		return nil, nil
+	}
+	for d := debias.DifferenceSign; d <= debias.ZeroCrossingParity; d++ {
+		if d.String() == f.derive {
+			return &debias.DerivationConfig{Strategy: d, Window: f.window, Level: f.level}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown derivation %q", f.derive)
+}
+
+func (f *stageFlags) bitPlanes() *debias.BitPlaneConfig {
+	if f.bits == 0 {
+		return nil
+	}
+	return &debias.BitPlaneConfig{
+		SampleBits: f.sampleBits,
+		Positions:  debias.LowestBits(f.bits),
+		BigEndian:  f.bigEndian,
+	}
+}
+
+// options returns the options of File and Directory for the extractor.
+func (f *extractorFlags) options() ([]debias.Option, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	return []debias.Option{debias.WithExtractor(cfg), debias.WithPadding(f.padding())}, nil
+}
+
+// options returns the options of File and Directory for the stage.
+func (f *stageFlags) options() ([]debias.Option, error) {
+	d, err := f.derivation()
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		return []debias.Option{debias.WithDerivation(*d)}, nil
+	}
+	if cfg := f.bitPlanes(); cfg != nil {
+		return []debias.Option{debias.WithBitPlanes(*cfg)}, nil
+	}
+	return nil, nil
+}
+
+// apply returns the reader of the bits selected from the sample payload read from r,
+// which has been decoded by decoded.
+func (f *stageFlags) apply(r, decoded io.Reader) (io.Reader, error) {
+	d, err := f.derivation()
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		src, channels := samples(r, decoded)
+		d.Channels = channels
+		deriver, err := debias.NewDeriver(*d)
+		if err != nil {
+			return nil, err
+		}
+		return debias.NewDerivingReader(src, deriver), nil
+	}
+	if cfg := f.bitPlanes(); cfg != nil {
+		return debias.NewBitPlaneReader(r, *cfg)
+	}
+	return r, nil
+}
+
+// samples returns a SampleReader decoding the sample payload read from r in the format of decoded,
+// and the number of interleaved channels of the payload.
+func samples(r, decoded io.Reader) (debias.SampleReader, int) {
+	switch d := decoded.(type) {
+	case *wav.Reader:
+		h := d.Header()
+		return debias.NewSampleDecoder(r, h.BytesPerSample(), h.Sample), len(d.Info().Channels)
+	case *iq.Reader:
+		cfg := d.Config()
+		channels := 1
+		if cfg.Component == iq.Interleaved {
+			channels = 2
+		}
+		return debias.NewSampleDecoder(r, cfg.SampleSize(), cfg.Sample), channels
+	}
+	return debias.ByteSamples(r), 1
+}
+
+// extractorFlags set the parameters of the extractors.
+type extractorFlags struct {
+	rate        float64
+	epsilon     float64
+	seed        string
+	seedFile    string
+	dropPartial bool
+}
+
+func (f *extractorFlags) register(fs *flag.FlagSet) {
+	fs.Float64Var(&f.rate, "entropy-rate", 0, "min-entropy per input bit assumed by the toeplitz and trevisan modes, by default their package defaults")
+	fs.Float64Var(&f.epsilon, "epsilon", 0, "distance from uniform of the output of the toeplitz and trevisan modes")
+	fs.StringVar(&f.seed, "seed", "", "hex encoded seed of the toeplitz and trevisan modes, by default read from crypto/rand")
+	fs.StringVar(&f.seedFile, "seed-file", "", "file to read the seed of the toeplitz and trevisan modes from")
+	fs.BoolVar(&f.dropPartial, "drop-partial", false, "drop the output bits that do not fill a complete byte at the end, instead of padding them with zeroes")
+}
+
+func (f *extractorFlags) padding() debias.Padding {
+	if f.dropPartial {
+		return debias.DropPartial
+	}
+	return debias.PadZeroes
+}
+
+// newExtractor creates the extractor for m reading from r with the parameters of the flags.
+func (f *extractorFlags) newExtractor(m debias.Mode, r io.Reader) (debias.Extractor, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	ex, err := debias.NewExtractorConfig(m, r, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if pd, ok := ex.(debias.Padder); ok {
+		pd.SetPadding(f.padding())
+	}
+	return ex, nil
+}
+
+func (f *extractorFlags) config() (debias.ExtractorConfig, error) {
+	cfg := debias.ExtractorConfig{
+		MinEntropy: f.rate,
+		Epsilon:    f.epsilon,
+		SeedFile:   f.seedFile,
+	}
+	if f.seed != "" {
+		seed, err := hex.DecodeString(f.seed)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid seed: %v", err)
+		}
+		cfg.Seed = seed
+	}
+	return cfg, nil
+}
@@ -0,0 +1,94 @@
+// Command debias extracts randomness from biased captures and evaluates the quality of the result.
+//
+// Usage:
+//
+//	debias run [flags] [file|directory|-]
+//	debias analyze [flags] [file|-]
+//	debias bench [flags] [file]
+//	debias compare [flags] input [output]
+//
+// Without an input or with -, run reads from stdin and writes the debiased data to stdout,
+// so it can be used in a pipe, e.g. rtl_sdr -f 100e6 - | debias run -format cu8 | dieharder -a -g 200.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errUsage is returned for invalid arguments, the usage has been printed already.
+var errUsage = errors.New("invalid arguments")
+
+type command struct {
+	name string
+	help string
+	run  func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"run", "debias a file, a directory or stdin", runCmd},
+	{"analyze", "report entropy and statistical tests of a file or stdin", analyzeCmd},
+	{"bench", "measure the throughput of each mode", benchCmd},
+	{"compare", "compare the quality of input and debiased output", compareCmd},
+}
+
+func main() {
+	err := execute(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err == errUsage || err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "debias:", err)
+		os.Exit(1)
+	}
+}
+
+// execute runs the command selected by the first argument.
+func execute(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		usage(stderr)
+		return errUsage
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	usage(stderr)
+	return errUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: debias <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.help)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run debias <command> -h for the flags of a command")
+}
+
+// newFlagSet returns a flag set for the named command printing its usage to stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: debias %s [flags] %s\n\nflags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the arguments of a command, the flag set has printed the error and the usage already.
+func parse(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && err != flag.ErrHelp {
+		return errUsage
+	}
+	return err
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/iq"
+)
+
+func execTest(t *testing.T, stdin []byte, args ...string) (string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if err := execute(args, bytes.NewReader(stdin), &stdout, &stderr); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, stderr.String())
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestRunPipe(t *testing.T) {
+	in := biased(20000, 0.7, 1)
+
+	out, stderr := execTest(t, in, "run", "-mode", "peres", "-v")
+
+	ex, err := debias.NewExtractor(debias.ModePeres, bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadAll(ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != string(want) {
+		t.Fatal("output differs from the extractor")
+	}
+	if !strings.Contains(stderr, "20000 bytes read") {
+		t.Fatal("unexpected summary: ", stderr)
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.cu8", "b.cs8"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), biased(10000, 0.7, 2), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, stderr := execTest(t, nil, "run", "-json", "-component", "i", dir)
+	var stats []*debias.Stats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].BytesIn != 5000 || stats[0].IQ == nil {
+		t.Fatalf("unexpected stats: %s", out)
+	}
+	if !strings.Contains(stderr, "processing") {
+		t.Fatal("progress not written to stderr: ", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.cu8-neumann-debiased.bin")); err != nil {
+		t.Fatal(err)
+	}
+
+	// an explicit output file streams the decoded samples
+	outFile := filepath.Join(dir, "out.bin")
+	execTest(t, nil, "run", "-component", "q", "-bits", "1", "-o", outFile, filepath.Join(dir, "a.cu8"))
+	data, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 || len(data) > 5000/8/4+1 {
+		t.Fatal("unexpected output size: ", len(data))
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	// an 8 bit mono WAV file without the .wav extension
+	var (
+		payload = biased(10000, 0.7, 6)
+		le      = binary.LittleEndian
+		buf     bytes.Buffer
+	)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, le, uint32(4+8+16+8+len(payload)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, le, uint32(16))
+	binary.Write(&buf, le, uint16(1))
+	binary.Write(&buf, le, uint16(1))
+	binary.Write(&buf, le, uint32(8000))
+	binary.Write(&buf, le, uint32(8000))
+	binary.Write(&buf, le, uint16(1))
+	binary.Write(&buf, le, uint16(8))
+	buf.WriteString("data")
+	binary.Write(&buf, le, uint32(len(payload)))
+	buf.Write(payload)
+
+	files := map[string][]byte{
+		"cap.bin":   biased(20000, 0.7, 7),
+		"audio.dat": buf.Bytes(),
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := func(args ...string) *debias.Stats {
+		t.Helper()
+		out, _ := execTest(t, nil, append([]string{"run", "-json"}, args...)...)
+		var stats []*debias.Stats
+		if err := json.Unmarshal([]byte(out), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 1 {
+			t.Fatalf("unexpected stats: %s", out)
+		}
+		return stats[0]
+	}
+
+	s := stats("-format", "cu8", "-component", "i", filepath.Join(dir, "cap.bin"))
+	if s.IQ == nil || s.IQ.Format != iq.CU8 || s.BytesIn != 10000 {
+		t.Fatalf("unexpected stats for -format cu8: %+v, %d bytes", s.IQ, s.BytesIn)
+	}
+	s = stats("-format", "wav", filepath.Join(dir, "audio.dat"))
+	if s.WAV == nil || s.BytesIn != int64(len(payload)) {
+		t.Fatalf("unexpected stats for -format wav: %+v, %d bytes", s.WAV, s.BytesIn)
+	}
+	s = stats("-format", "raw", filepath.Join(dir, "audio.dat"))
+	if s.WAV != nil || s.BytesIn != int64(buf.Len()) {
+		t.Fatalf("unexpected stats for -format raw: %+v, %d bytes", s.WAV, s.BytesIn)
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	var (
+		in   = biased(8192, 0.7, 8)
+		n    = debias.DefaultToeplitzBlockSize
+		m    = debias.ToeplitzOutputSize(n, debias.DefaultToeplitzMinEntropy, debias.DefaultToeplitzEpsilon)
+		seed = hex.EncodeToString(biased(debias.ToeplitzSeedSize(n, m), 0.5, 9))
+	)
+	out, stderr := execTest(t, in, "run", "-mode", "toeplitz", "-seed", seed, "-v")
+	if len(out) == 0 || !strings.Contains(stderr, "seed from config: "+seed) {
+		t.Fatalf("unexpected output of %d bytes: %s", len(out), stderr)
+	}
+	repeated, _ := execTest(t, in, "run", "-mode", "toeplitz", "-seed", seed)
+	if out != repeated {
+		t.Fatal("output not reproduced from the seed")
+	}
+
+	// a lower min-entropy rate needs a shorter seed and yields less output
+	lower, _ := execTest(t, in, "run", "-mode", "toeplitz", "-seed", seed, "-entropy-rate", "0.3")
+	if len(lower) == 0 || len(lower) >= len(out) {
+		t.Fatal("unexpected output size for a lower min-entropy rate: ", len(lower))
+	}
+}
+
+func TestRunPadding(t *testing.T) {
+	in := biased(1000, 0.7, 10)
+
+	padded, _ := execTest(t, in, "run")
+	dropped, _ := execTest(t, in, "run", "-drop-partial")
+	if len(dropped) != len(padded)-1 || dropped != padded[:len(dropped)] {
+		t.Fatalf("expected the partial byte to be dropped: %d bytes, %d padded", len(dropped), len(padded))
+	}
+
+	file := filepath.Join(t.TempDir(), "in.bin")
+	if err := ioutil.WriteFile(file, in, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := execTest(t, nil, "run", "-json", "-drop-partial", file)
+	var stats []*debias.Stats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].BytesOut != int64(len(dropped)) || stats[0].BitsOut != stats[0].BytesOut*8 {
+		t.Fatalf("unexpected stats: %s", out)
+	}
+}
+
+func TestRunDerivation(t *testing.T) {
+	var buf bytes.Buffer
+	for _, v := range biased(20000, 0.5, 3) {
+		binary.Write(&buf, binary.LittleEndian, int16(v)-128)
+	}
+	out, _ := execTest(t, buf.Bytes(), "run", "-format", "cs16", "-derive", "difference-sign")
+	if len(out) == 0 {
+		t.Fatal("no output")
+	}
+
+	var stderr bytes.Buffer
+	if err := execute([]string{"run", "-derive", "unknown"}, bytes.NewReader(nil), ioutil.Discard, &stderr); err == nil {
+		t.Fatal("expected an error for an unknown derivation")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	out, _ := execTest(t, biased(50000, 0.8, 4), "analyze", "-json")
+
+	var res analysis
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Ent.Bytes != 50000 || res.Bias.OnesRatio < 0.79 || res.Bias.OnesRatio > 0.81 {
+		t.Fatalf("unexpected analysis: %+v %+v", res.Ent, res.Bias)
+	}
+	if res.MinEntropy != nil || res.NIST != nil {
+		t.Fatal("unexpected expensive analyses")
+	}
+
+	out, _ = execTest(t, biased(50000, 0.8, 4), "analyze")
+	if !strings.Contains(out, "shannon entropy") {
+		t.Fatal("unexpected output: ", out)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "in.bin")
+	if err := ioutil.WriteFile(file, biased(50000, 0.8, 5), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _ := execTest(t, nil, "compare", "-json", file)
+	var res struct {
+		Input  *analysis
+		Output *analysis
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Input.Bits.Min > 0.33 || res.Output.Bits.Min < 0.9 {
+		t.Fatalf("unexpected min-entropy: %v in, %v out", res.Input.Bits.Min, res.Output.Bits.Min)
+	}
+
+	out, _ = execTest(t, nil, "compare", file, file)
+	if !strings.Contains(out, "output/input ratio 1.000000") {
+		t.Fatal("unexpected output: ", out)
+	}
+}
+
+func TestBench(t *testing.T) {
+	out, _ := execTest(t, nil, "bench", "-size", "4096", "-modes", "neumann,peres")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "neumann") || !strings.HasPrefix(lines[2], "peres") {
+		t.Fatal("unexpected output: ", out)
+	}
+
+	// the extractor and stage flags apply as for run
+	var (
+		n    = debias.DefaultToeplitzBlockSize
+		m    = debias.ToeplitzOutputSize(n, debias.DefaultToeplitzMinEntropy, debias.DefaultToeplitzEpsilon)
+		seed = hex.EncodeToString(biased(debias.ToeplitzSeedSize(n, m), 0.5, 9))
+	)
+	out, _ = execTest(t, nil, "bench", "-size", "4096", "-input-seed", "2", "-modes", "toeplitz", "-seed", seed, "-drop-partial")
+	if lines = strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "toeplitz") {
+		t.Fatal("unexpected output: ", out)
+	}
+	out, _ = execTest(t, nil, "bench", "-size", "4096", "-modes", "neumann", "-derive", "difference-sign")
+	if lines = strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || strings.Contains(out, "error") {
+		t.Fatal("unexpected output: ", out)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if err := execute(nil, nil, ioutil.Discard, &stderr); err != errUsage {
+		t.Fatal("expected errUsage, got ", err)
+	}
+	if err := execute([]string{"unknown"}, nil, ioutil.Discard, &stderr); err != errUsage {
+		t.Fatal("expected errUsage, got ", err)
+	}
+	if err := execute([]string{"run", "-undefined"}, nil, ioutil.Discard, &stderr); err != errUsage {
+		t.Fatal("expected errUsage, got ", err)
+	}
+	if err := execute([]string{"run", "-mode", "unknown"}, nil, ioutil.Discard, &stderr); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+}
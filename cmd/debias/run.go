@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func runCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		fs      = newFlagSet("run", "[file|directory|-]", stderr)
+		mode    = fs.String("mode", debias.ModeVonNeumann.String(), "extraction mode: "+modeNames())
+		out     = fs.String("o", "", "output file, - for stdout. By default stdin is written to stdout and files next to the input")
+		ext     = fs.String("ext", "", "extension of the files processed in a directory, by default all WAV and IQ captures")
+		asJSON  = fs.Bool("json", false, "print the stats of files and directories as JSON")
+		verbose = fs.Bool("v", false, "print a summary to stderr when streaming")
+		input   inputFlags
+		stage   stageFlags
+		exFlags extractorFlags
+	)
+	input.register(fs)
+	stage.register(fs)
+	exFlags.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	m, err := debias.ParseMode(*mode)
+	if err != nil {
+		return err
+	}
+	if _, err = exFlags.config(); err != nil {
+		return err
+	}
+
+	name := "-"
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	if name != "-" && *out == "" {
+		return runFiles(name, *ext, m, &exFlags, &input, &stage, *asJSON, stdout, stderr)
+	}
+	return runStream(name, *out, m, &exFlags, &input, &stage, *verbose, stdin, stdout, stderr)
+}
+
+// runFiles debiases a file or a directory with File and Directory, writing the output next to the input.
+// Progress messages are written to stderr, so the stats can be piped.
+func runFiles(name, ext string, m debias.Mode, exFlags *extractorFlags, input *inputFlags, stage *stageFlags, asJSON bool, stdout, stderr io.Writer) error {
+	opts, err := input.options()
+	if err != nil {
+		return err
+	}
+	stageOpts, err := stage.options()
+	if err != nil {
+		return err
+	}
+	exOpts, err := exFlags.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, stageOpts...)
+	opts = append(opts, exOpts...)
+	opts = append(opts, debias.WithProgress(stderr))
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	var stats []*debias.Stats
+	if fi.IsDir() {
+		stats, err = debias.Directory(name, ext, m, opts...)
+	} else {
+		var s *debias.Stats
+		s, err = debias.File(filepath.Dir(name), name, fi, m, opts...)
+		if s != nil {
+			stats = append(stats, s)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tBYTES IN\tBYTES OUT\tEFFICIENCY\tDURATION")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.4f\t%v\n", s.FileName, s.BytesIn, s.BytesOut, s.Efficiency, s.Duration)
+	}
+	return tw.Flush()
+}
+
+// runStream debiases the named input, or stdin, to the named output, or stdout.
+func runStream(name, out string, m debias.Mode, exFlags *extractorFlags, input *inputFlags, stage *stageFlags, verbose bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	start := time.Now()
+
+	src, err := openInput(name, stdin)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	counter := debias.NewCountingReader(src)
+	decoded, err := input.decode(name, counter)
+	if err != nil {
+		return err
+	}
+	r, err := stage.apply(decoded, decoded)
+	if err != nil {
+		return err
+	}
+
+	ex, err := exFlags.newExtractor(m, r)
+	if err != nil {
+		return err
+	}
+	defer ex.Close()
+
+	var (
+		dst     = stdout
+		outFile *os.File
+	)
+	if out != "" && out != "-" {
+		outFile, err = os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer outFile.Close()
+		dst = outFile
+	}
+
+	n, err := io.Copy(dst, ex)
+	if err != nil {
+		return err
+	}
+	if outFile != nil {
+		if err = outFile.Close(); err != nil {
+			return err
+		}
+	}
+
+	// the bytes read include the headers of decoded formats
+	if verbose {
+		var ratio float64
+		if counter.Count() > 0 {
+			ratio = float64(n) / float64(counter.Count())
+		}
+		fmt.Fprintf(stderr, "%s: %d bytes read, %d bytes written, ratio %.4f, %v\n", m, counter.Count(), n, ratio, time.Since(start))
+
+		// seeded extractors report their seed, so the run can be repeated with -seed
+		var s debias.Stats
+		if sr, ok := ex.(debias.StatsReporter); ok {
+			sr.ReportStats(&s)
+		}
+		if s.SeedSource != "" {
+			fmt.Fprintf(stderr, "seed from %s: %x\n", s.SeedSource, s.Seed)
+		}
+	}
+	return nil
+}
+
+func modeNames() string {
+	var names string
+	for i, m := range debias.Modes() {
+		if i > 0 {
+			names += ", "
+		}
+		names += m.String()
+	}
+	return names
+}
@@ -0,0 +1,165 @@
+package debias
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// XORCombiner is an Extractor that XORs the output of several independent sources, usually other extractors.
+// The combined output is as unpredictable as the best source, so a single compromised
+// or failing source does not weaken it as long as another source is intact.
+// Each source is read in its own goroutine and buffered.
+//
+// When a source runs dry, the combiner continues with the remaining sources
+// until fewer than the configured minimum are left.
+// The bytes produced with fewer sources are reported as fallback in the Stats.
+type XORCombiner struct {
+	sources    []*sourceBuffer
+	active     []int
+	minSources int
+	bufs       [][]byte
+
+	out bytes.Buffer
+	err error
+
+	bytesOut      int64
+	fallbackBytes int64
+}
+
+// NewXORCombiner returns an XORCombiner for the given sources,
+// that stops once fewer than minSources sources are left.
+// If minSources is zero or negative, the output ends when the first source runs dry.
+func NewXORCombiner(minSources int, sources ...io.Reader) (*XORCombiner, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%w: no sources to combine", ErrInvalidSize)
+	}
+	if minSources <= 0 {
+		minSources = len(sources)
+	}
+	if minSources > len(sources) {
+		return nil, fmt.Errorf("%w: %d sources required but only %d given", ErrInvalidSize, minSources, len(sources))
+	}
+
+	x := &XORCombiner{
+		minSources: minSources,
+	}
+	for i, src := range sources {
+		x.sources = append(x.sources, newSourceBuffer(src, defaultSourceChunks))
+		x.active = append(x.active, i)
+		x.bufs = append(x.bufs, make([]byte, MaxChunkSize))
+	}
+
+	return x, nil
+}
+
+// Read reads the combined data into p.
+func (x *XORCombiner) Read(p []byte) (int, error) {
+	for x.out.Len() == 0 && x.err == nil {
+		x.fill()
+	}
+	if x.out.Len() > 0 {
+		return x.out.Read(p)
+	}
+	return 0, x.err
+}
+
+// Close stops reading from the sources, subsequent reads return ErrClosed.
+// Close does not close the underlying sources.
+func (x *XORCombiner) Close() error {
+	x.stop()
+	x.out.Reset()
+	x.err = ErrClosed
+	return nil
+}
+
+func (x *XORCombiner) stop() {
+	for _, s := range x.sources {
+		s.stop()
+	}
+}
+
+// fill combines the next chunk of the active sources.
+func (x *XORCombiner) fill() {
+	var (
+		size   = MaxChunkSize
+		counts = make([]int, len(x.active))
+		dry    = make([]bool, len(x.active))
+	)
+
+	// read a chunk from every active source, the chunk is cut to the shortest source
+	for i, idx := range x.active {
+		n, err := x.sources[idx].read(x.bufs[idx][:size])
+		counts[i] = n
+		if n < size {
+			size = n
+		}
+		if err == io.EOF {
+			dry[i] = true
+		} else if err != nil {
+			x.stop()
+			x.err = fmt.Errorf("source %d: %w", idx, err)
+			return
+		}
+	}
+
+	// return the bytes beyond the shortest source,
+	// a source that ran dry is only removed once all of its data has been used
+	active := x.active[:0:0]
+	for i, idx := range x.active {
+		x.sources[idx].unread(x.bufs[idx][size:counts[i]])
+		x.sources[idx].use(size)
+		if !dry[i] || counts[i] > size {
+			active = append(active, idx)
+		}
+	}
+
+	if size > 0 {
+		out := x.bufs[x.active[0]][:size]
+		for _, idx := range x.active[1:] {
+			xorBytes(out, x.bufs[idx][:size])
+		}
+		x.out.Write(out)
+		x.bytesOut += int64(size)
+		if len(x.active) < len(x.sources) {
+			x.fallbackBytes += int64(size)
+		}
+	}
+
+	if len(active) == len(x.active) {
+		return
+	}
+
+	// a source ran dry, the bytes left in the others are combined in the next call
+	x.active = active
+	if len(x.active) < x.minSources {
+		x.stop()
+		if x.bytesOut == 0 {
+			x.err = ErrShortInput
+			return
+		}
+		x.err = io.EOF
+	}
+}
+
+func (x *XORCombiner) BitsOut() int64 {
+	return x.bytesOut * 8
+}
+
+// ReportStats sets the statistics for each source and the number of bytes produced with fewer sources.
+func (x *XORCombiner) ReportStats(s *Stats) {
+	s.Sources = nil
+	s.BytesIn = 0
+	for _, src := range x.sources {
+		st := src.stats()
+		s.Sources = append(s.Sources, st)
+		s.BytesIn += st.BytesUsed
+	}
+	s.BytesOut = x.bytesOut
+	s.BitsOut = x.BitsOut()
+	s.Fallback = x.fallbackBytes > 0
+	s.FallbackBytes = x.fallbackBytes
+	if s.BytesIn > 0 {
+		s.Efficiency = float64(s.BitsOut) / float64(s.BytesIn*8)
+	}
+}
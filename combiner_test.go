@@ -0,0 +1,154 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// xorAll returns the XOR of the inputs, each byte combines the inputs that are long enough.
+func xorAll(in ...[]byte) []byte {
+	var out []byte
+	for _, data := range in {
+		for len(out) < len(data) {
+			out = append(out, 0)
+		}
+		for i, b := range data {
+			out[i] ^= b
+		}
+	}
+	return out
+}
+
+func TestXORCombiner(t *testing.T) {
+	var (
+		a = biased(3000, 0.5, 1)
+		b = biased(5000, 0.5, 2)
+		c = biased(8000, 0.5, 3)
+	)
+
+	x, err := debias.NewXORCombiner(0, bytes.NewReader(a), iotest.OneByteReader(bytes.NewReader(b)), bytes.NewReader(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, xorAll(a, b[:3000], c[:3000])) {
+		t.Fatal("unexpected output")
+	}
+
+	var s debias.Stats
+	x.ReportStats(&s)
+	if s.Fallback || s.FallbackBytes != 0 {
+		t.Fatal("unexpected fallback")
+	}
+	for i, src := range s.Sources {
+		if src.BytesUsed != 3000 {
+			t.Fatalf("source %d: expected 3000 bytes used, got %d", i, src.BytesUsed)
+		}
+	}
+}
+
+func TestXORCombinerFallback(t *testing.T) {
+	var (
+		a = biased(3000, 0.5, 1)
+		b = biased(5000, 0.5, 2)
+		c = biased(8000, 0.5, 3)
+	)
+
+	x, err := debias.NewXORCombiner(1, iotest.HalfReader(bytes.NewReader(a)), bytes.NewReader(b), iotest.OneByteReader(bytes.NewReader(c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, xorAll(a, b, c)) {
+		t.Fatal("unexpected output")
+	}
+
+	var s debias.Stats
+	x.ReportStats(&s)
+	if !s.Fallback || s.FallbackBytes != 5000 {
+		t.Fatal("expected 5000 fallback bytes, got ", s.FallbackBytes)
+	}
+	for i, size := range []int64{3000, 5000, 8000} {
+		if s.Sources[i].BytesUsed != size {
+			t.Fatalf("source %d: expected %d bytes used, got %d", i, size, s.Sources[i].BytesUsed)
+		}
+	}
+	if s.BytesIn != 16000 || s.BitsOut != 64000 {
+		t.Fatalf("unexpected totals: %d bytes in, %d bits out", s.BytesIn, s.BitsOut)
+	}
+
+	// stop when only one source is left
+	x, err = debias.NewXORCombiner(2, bytes.NewReader(a), bytes.NewReader(b), bytes.NewReader(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ioutil.ReadAll(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, xorAll(a, b, c[:5000])) {
+		t.Fatal("unexpected output")
+	}
+}
+
+func TestXORCombinerExtractors(t *testing.T) {
+	var (
+		a = debias.NewVonNeumann(bytes.NewReader(biased(20000, 0.7, 4)))
+		b = debias.NewVonNeumann(bytes.NewReader(biased(20000, 0.3, 5)))
+	)
+	x, err := debias.NewXORCombiner(0, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Von Neumann yields p(1-p) = 0.21 output bits per input bit
+	if len(out) < 4000 || len(out) > 4400 {
+		t.Fatal("unexpected output size: ", len(out))
+	}
+}
+
+func TestXORCombinerErrors(t *testing.T) {
+	if _, err := debias.NewXORCombiner(0); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err := debias.NewXORCombiner(3, bytes.NewReader(nil), bytes.NewReader(nil)); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+
+	x, err := debias.NewXORCombiner(1, bytes.NewReader(nil), bytes.NewReader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(x); err != debias.ErrShortInput {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+
+	x, err = debias.NewXORCombiner(0, bytes.NewReader([]byte{0x01, 0x02}), &brokenReader{data: []byte{0x03}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(x)
+	checkSourceError(t, err, 1)
+
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Read(make([]byte, 1)); err != debias.ErrClosed {
+		t.Fatal("expected ErrClosed, got ", err)
+	}
+}
@@ -0,0 +1,322 @@
+package debias
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+)
+
+// Conditioner is an Algorithm implementing one of the vetted conditioning components
+// from NIST SP 800-90B section 3.1.5.1.1.
+//
+// The input is split into blocks of the input size, and each block is conditioned into an output block.
+// Input that does not fill a complete block at the end is discarded.
+// A Conditioner can be chained after any extractor, e.g.:
+//
+//	NewReader(NewVonNeumann(src), conditioner)
+type Conditioner struct {
+	inputSize  int
+	outputSize int
+
+	// narrowest internal width in bits
+	width int
+
+	condition func(block []byte) ([]byte, error)
+	block     []byte
+}
+
+func newConditioner(inputSize, outputSize, width int, condition func(block []byte) ([]byte, error)) *Conditioner {
+	return &Conditioner{
+		inputSize:  inputSize,
+		outputSize: outputSize,
+		width:      width,
+		condition:  condition,
+		block:      make([]byte, 0, inputSize),
+	}
+}
+
+// NewHMACConditioner returns a Conditioner computing the HMAC of each input block with the hash h and key,
+// truncated to outputSize bytes. The output size is limited to the size of the hash.
+func NewHMACConditioner(h func() hash.Hash, key []byte, inputSize, outputSize int) (*Conditioner, error) {
+	size := h().Size()
+	if inputSize <= 0 || outputSize <= 0 || outputSize > size {
+		return nil, fmt.Errorf("%w: input %d, output %d", ErrInvalidSize, inputSize, outputSize)
+	}
+
+	mac := hmac.New(h, key)
+
+	return newConditioner(inputSize, outputSize, size*8, func(block []byte) ([]byte, error) {
+		mac.Reset()
+		mac.Write(block)
+		return mac.Sum(nil)[:outputSize], nil
+	}), nil
+}
+
+// NewCMACConditioner returns a Conditioner computing the AES CMAC (SP 800-38B) of each input block,
+// truncated to outputSize bytes. The key must be 16, 24 or 32 bytes long, the output size is limited to 16 bytes.
+func NewCMACConditioner(key []byte, inputSize, outputSize int) (*Conditioner, error) {
+	if inputSize <= 0 || outputSize <= 0 || outputSize > aes.BlockSize {
+		return nil, fmt.Errorf("%w: input %d, output %d", ErrInvalidSize, inputSize, outputSize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipher, err)
+	}
+
+	// derive the subkeys
+	var (
+		k1 = make([]byte, aes.BlockSize)
+		k2 = make([]byte, aes.BlockSize)
+	)
+	block.Encrypt(k1, k1)
+	gfDouble(k1)
+	copy(k2, k1)
+	gfDouble(k2)
+
+	return newConditioner(inputSize, outputSize, aes.BlockSize*8, func(data []byte) ([]byte, error) {
+		var (
+			numBlocks = (len(data) + aes.BlockSize - 1) / aes.BlockSize
+			last      = make([]byte, aes.BlockSize)
+			mac       = make([]byte, aes.BlockSize)
+		)
+
+		// the last block is masked with k1 if it is complete, otherwise it is padded and masked with k2
+		tail := data[(numBlocks-1)*aes.BlockSize:]
+		copy(last, tail)
+		if len(tail) == aes.BlockSize {
+			xorBytes(last, k1)
+		} else {
+			last[len(tail)] = 0x80
+			xorBytes(last, k2)
+		}
+
+		for i := 0; i < numBlocks-1; i++ {
+			xorBytes(mac, data[i*aes.BlockSize:(i+1)*aes.BlockSize])
+			block.Encrypt(mac, mac)
+		}
+		xorBytes(mac, last)
+		block.Encrypt(mac, mac)
+
+		return mac[:outputSize], nil
+	}), nil
+}
+
+// NewCBCMACConditioner returns a Conditioner computing the AES CBC-MAC of each input block,
+// with an output size of 16 bytes. The key must be 16, 24 or 32 bytes long,
+// the input size has to be a multiple of the AES block size.
+func NewCBCMACConditioner(key []byte, inputSize int) (*Conditioner, error) {
+	if inputSize <= 0 || inputSize%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: input %d is not a multiple of the block size", ErrInvalidSize, inputSize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipher, err)
+	}
+
+	return newConditioner(inputSize, aes.BlockSize, aes.BlockSize*8, func(data []byte) ([]byte, error) {
+		return bcc(block, data), nil
+	}), nil
+}
+
+// NewHashDFConditioner returns a Conditioner applying the Hash_df derivation function (SP 800-90A section 10.3.1)
+// with the hash h to each input block, producing outputSize bytes.
+func NewHashDFConditioner(h func() hash.Hash, inputSize, outputSize int) (*Conditioner, error) {
+	var (
+		hf   = h()
+		size = hf.Size()
+	)
+	if inputSize <= 0 || outputSize <= 0 || outputSize > 255*size {
+		return nil, fmt.Errorf("%w: input %d, output %d", ErrInvalidSize, inputSize, outputSize)
+	}
+
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(outputSize*8))
+
+	return newConditioner(inputSize, outputSize, size*8, func(data []byte) ([]byte, error) {
+		out := make([]byte, 0, (outputSize+size-1)/size*size)
+
+		// Hash(counter || no_of_bits_to_return || input_string)
+		for counter := 1; len(out) < outputSize; counter++ {
+			header[0] = byte(counter)
+			hf.Reset()
+			hf.Write(header[:])
+			hf.Write(data)
+			out = hf.Sum(out)
+		}
+
+		return out[:outputSize], nil
+	}), nil
+}
+
+// maxBlockCipherDFSize is the maximum number of bytes returned by Block_Cipher_df.
+const maxBlockCipherDFSize = 64
+
+// NewBlockCipherDFConditioner returns a Conditioner applying the Block_Cipher_df derivation function
+// (SP 800-90A section 10.3.2) with AES to each input block, producing outputSize bytes.
+// The key size selects AES-128, AES-192 or AES-256 and must be 16, 24 or 32,
+// the output size is limited to 64 bytes.
+func NewBlockCipherDFConditioner(keySize, inputSize, outputSize int) (*Conditioner, error) {
+	if inputSize <= 0 || outputSize <= 0 || outputSize > maxBlockCipherDFSize {
+		return nil, fmt.Errorf("%w: input %d, output %d", ErrInvalidSize, inputSize, outputSize)
+	}
+
+	// K = leftmost(0x00010203...1D1E1F, keylen)
+	key := make([]byte, keySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	initial, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCipher, err)
+	}
+
+	return newConditioner(inputSize, outputSize, aes.BlockSize*8, func(data []byte) ([]byte, error) {
+
+		// S = L || N || input_string || 0x80, padded with zeroes to a multiple of the block size
+		// and prefixed with the block for the IV
+		s := make([]byte, aes.BlockSize+8, aes.BlockSize+8+len(data)+1+aes.BlockSize)
+		binary.BigEndian.PutUint32(s[aes.BlockSize:], uint32(len(data)))
+		binary.BigEndian.PutUint32(s[aes.BlockSize+4:], uint32(outputSize))
+		s = append(s, data...)
+		s = append(s, 0x80)
+		for len(s)%aes.BlockSize != 0 {
+			s = append(s, 0x00)
+		}
+
+		var temp []byte
+		for i := uint32(0); len(temp) < keySize+aes.BlockSize; i++ {
+			// IV = i || 0^(outlen - 32)
+			binary.BigEndian.PutUint32(s, i)
+			temp = append(temp, bcc(initial, s)...)
+		}
+
+		block, err := aes.NewCipher(temp[:keySize])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCipher, err)
+		}
+
+		var (
+			x   = temp[keySize : keySize+aes.BlockSize]
+			out = make([]byte, 0, outputSize+aes.BlockSize)
+		)
+		for len(out) < outputSize {
+			block.Encrypt(x, x)
+			out = append(out, x...)
+		}
+
+		return out[:outputSize], nil
+	}), nil
+}
+
+func (c *Conditioner) Process(p []byte, out *bytes.Buffer) error {
+	for len(p) > 0 {
+		n := c.inputSize - len(c.block)
+		if n > len(p) {
+			n = len(p)
+		}
+		c.block = append(c.block, p[:n]...)
+		p = p[n:]
+
+		if len(c.block) == c.inputSize {
+			data, err := c.condition(c.block)
+			if err != nil {
+				return err
+			}
+			out.Write(data)
+			c.block = c.block[:0]
+		}
+	}
+	return nil
+}
+
+// Flush discards the incomplete input block.
+func (c *Conditioner) Flush(out *bytes.Buffer) error {
+	c.block = c.block[:0]
+	return nil
+}
+
+// InputSize returns the number of input bytes per conditioned block.
+func (c *Conditioner) InputSize() int {
+	return c.inputSize
+}
+
+// OutputSize returns the number of output bytes per conditioned block.
+func (c *Conditioner) OutputSize() int {
+	return c.outputSize
+}
+
+// OutputEntropy returns the entropy of an output block in bits,
+// given the entropy hIn of an input block in bits.
+func (c *Conditioner) OutputEntropy(hIn float64) float64 {
+	return OutputEntropy(c.inputSize*8, c.outputSize*8, c.width, hIn)
+}
+
+// OutputEntropy implements the Output_Entropy function from SP 800-90B section 3.1.5.1.2.
+// It returns the entropy in bits of the nOut output bits of a vetted conditioning component
+// with the narrowest internal width nw, for nIn input bits containing hIn bits of entropy.
+func OutputEntropy(nIn, nOut, nw int, hIn float64) float64 {
+	n := nOut
+	if nw < n {
+		n = nw
+	}
+	if hIn > float64(nIn) {
+		hIn = float64(nIn)
+	}
+
+	var (
+		a = float64(nIn - n)
+
+		// P_high = 2^-h_in
+		pHigh = math.Exp2(-hIn)
+
+		// t = 2^(n_in-n) * P_low, with P_low = (1 - P_high) / (2^n_in - 1),
+		// rearranged to avoid overflows for large n_in
+		t = (1 - pHigh) * math.Exp2(-float64(n)) / -math.Expm1(-float64(nIn)*math.Ln2)
+
+		// Ψ = 2^(n_in-n) * P_low + P_high
+		psi = t + pHigh
+
+		// ω = U * P_low, with U = 2^(n_in-n) + sqrt(2n * 2^(n_in-n) * ln(2))
+		omega = t * (1 + math.Sqrt(2*float64(n)*math.Ln2)*math.Exp2(-a/2))
+	)
+
+	return -math.Log2(math.Max(psi, omega))
+}
+
+// bcc implements the BCC function from SP 800-90A section 10.3.3,
+// which is the last block of the CBC encryption of data with a zero IV.
+func bcc(block cipher.Block, data []byte) []byte {
+	chain := make([]byte, aes.BlockSize)
+	for i := 0; i+aes.BlockSize <= len(data); i += aes.BlockSize {
+		xorBytes(chain, data[i:i+aes.BlockSize])
+		block.Encrypt(chain, chain)
+	}
+	return chain
+}
+
+// gfDouble multiplies b by x in GF(2^128), used for deriving the CMAC subkeys.
+func gfDouble(b []byte) {
+	msb := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] <<= 1
+	if msb == 1 {
+		b[len(b)-1] ^= 0x87
+	}
+}
+
+// xorBytes sets dst[i] ^= src[i] for all bytes of src.
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
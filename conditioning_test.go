@@ -0,0 +1,375 @@
+package debias_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// condition runs data through c and returns the output.
+func condition(t *testing.T, c *debias.Conditioner, data []byte) []byte {
+	out, err := ioutil.ReadAll(debias.NewReader(bytes.NewReader(data), c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// RFC 4493 test vectors.
+func TestCMACConditioner(t *testing.T) {
+	var (
+		key = mustHex("2b7e151628aed2a6abf7158809cf4f3c")
+		msg = mustHex("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	)
+
+	for _, te := range []struct {
+		size int
+		mac  string
+	}{
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	} {
+		c, err := debias.NewCMACConditioner(key, te.size, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := condition(t, c, msg[:te.size])
+		if !bytes.Equal(out, mustHex(te.mac)) {
+			t.Fatalf("size %d: expected %s but got %x", te.size, te.mac, out)
+		}
+	}
+}
+
+func TestHMACConditioner(t *testing.T) {
+	var (
+		key  = []byte("conditioning key")
+		data = biased(4096, 0.8, 3)
+	)
+
+	c, err := debias.NewHMACConditioner(sha512.New, key, 1024, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := condition(t, c, append(data, 1, 2, 3))
+	if len(out) != 4*32 {
+		t.Fatal("unexpected number of output bytes: ", len(out))
+	}
+
+	for i := 0; i < 4; i++ {
+		mac := hmac.New(sha512.New, key)
+		mac.Write(data[i*1024 : (i+1)*1024])
+		if !bytes.Equal(out[i*32:(i+1)*32], mac.Sum(nil)[:32]) {
+			t.Fatal("unexpected output for block ", i)
+		}
+	}
+
+	if _, err = debias.NewHMACConditioner(sha256.New, key, 64, 33); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+}
+
+func TestCBCMACConditioner(t *testing.T) {
+	var (
+		key  = mustHex("2b7e151628aed2a6abf7158809cf4f3c")
+		data = biased(256, 0.8, 4)
+	)
+
+	c, err := debias.NewCBCMACConditioner(key, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected []byte
+	for i := 0; i < 2; i++ {
+		ciphertext := make([]byte, 128)
+		cipher.NewCBCEncrypter(block, make([]byte, 16)).CryptBlocks(ciphertext, data[i*128:(i+1)*128])
+		expected = append(expected, ciphertext[112:]...)
+	}
+
+	if out := condition(t, c, data); !bytes.Equal(out, expected) {
+		t.Fatalf("expected %x but got %x", expected, out)
+	}
+
+	if _, err = debias.NewCBCMACConditioner(key, 100); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err = debias.NewCBCMACConditioner(key[:5], 128); !errors.Is(err, debias.ErrCipher) {
+		t.Fatal("expected ErrCipher, got ", err)
+	}
+}
+
+func TestHashDFConditioner(t *testing.T) {
+	data := biased(100, 0.8, 5)
+
+	c, err := debias.NewHashDFConditioner(sha256.New, 100, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hash(counter || no_of_bits_to_return || input_string) for two counters
+	var expected []byte
+	for counter := byte(1); counter <= 2; counter++ {
+		var header [5]byte
+		header[0] = counter
+		binary.BigEndian.PutUint32(header[1:], 40*8)
+		sum := sha256.Sum256(append(header[:], data...))
+		expected = append(expected, sum[:]...)
+	}
+
+	if out := condition(t, c, data); !bytes.Equal(out, expected[:40]) {
+		t.Fatalf("expected %x but got %x", expected[:40], out)
+	}
+}
+
+// hashDRBG instantiates a Hash_DRBG with SHA-256 (SP 800-90A section 10.1.1) from entropy and nonce,
+// with Hash_df computed by the conditioner, and returns the output of the second request of n bytes
+// as in the CAVP test vectors.
+func hashDRBG(t *testing.T, entropy, nonce []byte, n int) []byte {
+	const seedLen = 55
+	df := func(input []byte) []byte {
+		c, err := debias.NewHashDFConditioner(sha256.New, len(input), seedLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return condition(t, c, input)
+	}
+
+	var (
+		v       = df(append(append([]byte{}, entropy...), nonce...))
+		c       = df(append([]byte{0x00}, v...))
+		counter = int64(1)
+		modulus = new(big.Int).Lsh(big.NewInt(1), seedLen*8)
+		out     []byte
+	)
+	for i := 0; i < 2; i++ {
+		out = out[:0]
+		data := new(big.Int).SetBytes(v)
+		for len(out) < n {
+			sum := sha256.Sum256(data.FillBytes(make([]byte, seedLen)))
+			out = append(out, sum[:]...)
+			data.Add(data, big.NewInt(1)).Mod(data, modulus)
+		}
+
+		h := sha256.Sum256(append([]byte{0x03}, v...))
+		next := new(big.Int).SetBytes(v)
+		next.Add(next, new(big.Int).SetBytes(h[:]))
+		next.Add(next, new(big.Int).SetBytes(c))
+		next.Add(next, big.NewInt(counter)).Mod(next, modulus)
+		v = next.FillBytes(make([]byte, seedLen))
+		counter++
+	}
+	return out[:n]
+}
+
+// ctrDRBG instantiates a CTR_DRBG with AES-128 and the derivation function (SP 800-90A section 10.2.1)
+// from entropy and nonce, with Block_Cipher_df computed by the conditioner, and returns the output
+// of the second request of n bytes as in the CAVP test vectors.
+func ctrDRBG(t *testing.T, entropy, nonce []byte, n int) []byte {
+	const seedLen = 32
+	input := append(append([]byte{}, entropy...), nonce...)
+	c, err := debias.NewBlockCipherDFConditioner(16, len(input), seedLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		key = make([]byte, 16)
+		v   = make([]byte, 16)
+	)
+	increment := func() {
+		for i := len(v) - 1; i >= 0; i-- {
+			v[i]++
+			if v[i] != 0 {
+				break
+			}
+		}
+	}
+	update := func(provided []byte) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		temp := make([]byte, 0, seedLen)
+		for len(temp) < seedLen {
+			increment()
+			enc := make([]byte, 16)
+			block.Encrypt(enc, v)
+			temp = append(temp, enc...)
+		}
+		for i := range temp {
+			temp[i] ^= provided[i]
+		}
+		key, v = temp[:16], temp[16:]
+	}
+
+	update(condition(t, c, input))
+	var out []byte
+	for i := 0; i < 2; i++ {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = out[:0]
+		for len(out) < n {
+			increment()
+			enc := make([]byte, 16)
+			block.Encrypt(enc, v)
+			out = append(out, enc...)
+		}
+		update(make([]byte, seedLen))
+	}
+	return out[:n]
+}
+
+func TestDerivationFunctionVectors(t *testing.T) {
+	// CAVP Hash_DRBG.rsp, [SHA-256], no prediction resistance, COUNT = 0
+	out := hashDRBG(t,
+		mustHex("a65ad0f345db4e0effe875c3a2e71f42c7129d620ff5c119a9ef55f05185e0fb"),
+		mustHex("8581f9317517276e06e9607ddbcbcc2e"), 128)
+	expected := mustHex("d3e160c35b99f340b2628264d1751060e0045da383ff57a57d73a673d2b8d80daaf6a6c35a91bb4579d73fd0c8fed111" +
+		"b0391306828adfed528f018121b3febdc343e797b87dbb63db1333ded9d1ece177cfa6b71fe8ab1da46624ed6415e51ccde2c7ca86e2" +
+		"83990eeaeb91120415528b2295910281b02dd431f4c9f70427df")
+	if !bytes.Equal(out, expected) {
+		t.Fatalf("Hash_df: expected %x but got %x", expected, out)
+	}
+
+	// CAVP CTR_DRBG.rsp, [AES-128 use df], no prediction resistance, COUNT = 0
+	out = ctrDRBG(t, mustHex("890eb067acf7382eff80b0c73bc872c6"), mustHex("aad471ef3ef1d203"), 64)
+	expected = mustHex("a5514ed7095f64f3d0d3a5760394ab42062f373a25072a6ea6bcfd8489e94af6" +
+		"cf18659fea22ed1ca0a9e33f718b115ee536b12809c31b72b08ddd8be1910fa3")
+	if !bytes.Equal(out, expected) {
+		t.Fatalf("Block_Cipher_df: expected %x but got %x", expected, out)
+	}
+}
+
+func TestBlockCipherDFConditioner(t *testing.T) {
+	data := biased(100, 0.8, 6)
+
+	for _, keySize := range []int{16, 24, 32} {
+		c, err := debias.NewBlockCipherDFConditioner(keySize, 50, 48)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := condition(t, c, data)
+		if len(out) != 96 {
+			t.Fatal("unexpected number of output bytes: ", len(out))
+		}
+		if bytes.Equal(out[:48], out[48:]) {
+			t.Fatal("expected different output for different input blocks")
+		}
+		if again := condition(t, c, data); !bytes.Equal(out, again) {
+			t.Fatal("expected a deterministic output")
+		}
+	}
+
+	if _, err := debias.NewBlockCipherDFConditioner(16, 50, 65); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err := debias.NewBlockCipherDFConditioner(20, 50, 16); !errors.Is(err, debias.ErrCipher) {
+		t.Fatal("expected ErrCipher, got ", err)
+	}
+}
+
+func TestOutputEntropy(t *testing.T) {
+	for _, te := range []struct {
+		nIn, nOut, nw int
+		hIn, hOut     float64
+	}{
+		// full entropy input with a large margin
+		{nIn: 512, nOut: 256, nw: 256, hIn: 512, hOut: 256},
+		// the input entropy limits the output entropy
+		{nIn: 512, nOut: 256, nw: 256, hIn: 100, hOut: 100},
+		// h_in = n_out: Ψ = 2^-256 + 2^-256
+		{nIn: 512, nOut: 256, nw: 256, hIn: 256, hOut: 255},
+		// n_in = n: ω = 2^-256 * (1 + sqrt(512 ln(2)))
+		{nIn: 256, nOut: 256, nw: 256, hIn: 256, hOut: 256 - math.Log2(1+math.Sqrt(512*math.Ln2))},
+		// the internal width limits the output entropy
+		{nIn: 8192, nOut: 256, nw: 128, hIn: 4096, hOut: 128},
+	} {
+		hOut := debias.OutputEntropy(te.nIn, te.nOut, te.nw, te.hIn)
+		if math.Abs(hOut-te.hOut) > 1e-6 {
+			t.Fatalf("%+v: got h_out %f", te, hOut)
+		}
+	}
+
+	c, err := debias.NewHMACConditioner(sha256.New, nil, 64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.OutputEntropy(512); math.Abs(h-256) > 1e-6 {
+		t.Fatal("unexpected output entropy: ", h)
+	}
+}
+
+func TestConditionerChain(t *testing.T) {
+	var (
+		data = biased(100000, 0.8, 7)
+		out  bytes.Buffer
+	)
+
+	newConditioner := func() *debias.Conditioner {
+		c, err := debias.NewHMACConditioner(sha256.New, nil, 64, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// condition the von neumann output in blocks of 64 bytes with the streaming reader
+	chained, err := ioutil.ReadAll(debias.NewReader(debias.NewVonNeumann(bytes.NewReader(data)), newConditioner()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// and the same using the writer
+	var (
+		c = newConditioner()
+		w = debias.NewVonNeumannWriter(debias.NewWriter(&out, c))
+	)
+	if _, err = w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	vn, err := ioutil.ReadAll(debias.NewVonNeumann(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chained) != len(vn)/64*32 {
+		t.Fatal("unexpected number of output bytes: ", len(chained))
+	}
+	if !bytes.Equal(chained, out.Bytes()) {
+		t.Fatal("reader and writer output differ")
+	}
+}
@@ -0,0 +1,99 @@
+package debias
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dreadl0ck/debias/iq"
+	"github.com/dreadl0ck/debias/wav"
+)
+
+// decode returns the reader for the sample payload of file, based on its extension,
+// and adds the metadata of the decoded format to s.
+// The extension is replaced by the format set WithFormat, which takes precedence over an IQ format set explicitly.
+// Otherwise all files are decoded as IQ captures if the IQ format is set explicitly.
+// Files with unknown extensions and all files processed WithRaw are passed on unchanged.
+func decode(file string, r io.Reader, o *options, s *Stats) (io.Reader, error) {
+	if o.raw {
+		return r, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(file))
+	if o.format != "" {
+		if !knownFormat(o.format) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, o.format)
+		}
+		ext = o.format
+	}
+	if format, ok := iq.FormatFromExt(ext); ok || o.format == "" && o.iqFormat() != 0 {
+		var cfg iq.Config
+		if o.iq != nil {
+			cfg = *o.iq
+		}
+		if cfg.Format == 0 || o.format != "" {
+			cfg.Format = format
+		}
+		ir, err := iq.NewReader(r, cfg)
+		if err != nil {
+			return nil, err
+		}
+		c := ir.Config()
+		s.IQ = &c
+		return ir, nil
+	}
+
+	switch ext {
+	case ".wav":
+		var cfg wav.Config
+		if o.wav != nil {
+			cfg = *o.wav
+		}
+		wr, err := wav.NewReader(r, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.WAV = wr.Info()
+		return wr, nil
+	}
+	return r, nil
+}
+
+// decodable reports whether the format of file is known from its extension or set explicitly.
+func decodable(file string, o *options) bool {
+	if o.format != "" || o.iqFormat() != 0 {
+		return true
+	}
+	return knownFormat(strings.ToLower(filepath.Ext(file)))
+}
+
+// knownFormat reports whether files with the extension ext can be decoded.
+func knownFormat(ext string) bool {
+	if _, ok := iq.FormatFromExt(ext); ok {
+		return true
+	}
+	return ext == ".wav"
+}
+
+// CountingReader counts the bytes read through it.
+type CountingReader struct {
+	r io.Reader
+	n int64
+}
+
+// NewCountingReader returns a CountingReader reading from r.
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Count returns the number of bytes read so far.
+func (c *CountingReader) Count() int64 {
+	return c.n
+}
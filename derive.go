@@ -0,0 +1,372 @@
+package debias
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/dreadl0ck/debias/internal/sample"
+	"github.com/dreadl0ck/debias/iq"
+)
+
+// SampleReader is implemented by sources of numeric sample values, like the WAV and IQ decoders.
+type SampleReader interface {
+	ReadSamples(dst []float64) (int, error)
+}
+
+// Deriver derives bits from a sequence of samples, instead of passing on the bits of the samples themselves.
+// It keeps the state between calls, so the samples can be passed in chunks. NaN samples are skipped.
+type Deriver interface {
+
+	// Derive appends the bits derived from samples to bits, as values 0 or 1.
+	Derive(samples []float64, bits []byte) []byte
+
+	// String returns the name of the strategy.
+	String() string
+}
+
+// Derivation selects a bit derivation strategy, as used by rtl-entropy.
+type Derivation int
+
+const (
+	// DifferenceSign emits 1 if a sample is above its predecessor and 0 if it is below, equal samples are skipped.
+	DifferenceSign Derivation = iota
+
+	// MedianThreshold emits 1 if a sample is above the median of the previous window and 0 if it is below.
+	MedianThreshold
+
+	// MeanThreshold emits 1 if a sample is above the mean of the previous window and 0 if it is below.
+	MeanThreshold
+
+	// ZeroCrossingParity emits the parity of the number of samples between two crossings of the level.
+	ZeroCrossingParity
+)
+
+var derivationNames = []string{"difference-sign", "median-threshold", "mean-threshold", "zero-crossing-parity"}
+
+func (d Derivation) String() string {
+	if d >= 0 && int(d) < len(derivationNames) {
+		return derivationNames[d]
+	}
+	return fmt.Sprintf("Derivation(%d)", int(d))
+}
+
+// DefaultDerivationWindow is the number of samples the moving thresholds are computed over if no window is configured.
+var DefaultDerivationWindow = 64
+
+// DerivationConfig configures a bit derivation strategy.
+type DerivationConfig struct {
+	Strategy Derivation
+
+	// Window is the number of previous samples for MedianThreshold and MeanThreshold,
+	// zero uses DefaultDerivationWindow.
+	Window int
+
+	// Level is the value crossed for ZeroCrossingParity, samples equal to the level do not cross it.
+	Level float64
+
+	// Channels is the number of interleaved channels of the samples, like the selected channels of a WAV file
+	// or the I and Q values of an IQ capture. Each channel is derived on its own, so samples are only compared
+	// with samples of the same channel. Zero is a single channel.
+	Channels int
+}
+
+// NewDeriver returns a Deriver for cfg.
+func NewDeriver(cfg DerivationConfig) (Deriver, error) {
+	if cfg.Window == 0 {
+		cfg.Window = DefaultDerivationWindow
+	}
+	if cfg.Channels < 0 {
+		return nil, fmt.Errorf("%w: %d channels", ErrInvalidSize, cfg.Channels)
+	}
+	if cfg.Channels > 1 {
+		c := &channelDeriver{
+			derivers: make([]Deriver, cfg.Channels),
+			split:    make([][]float64, cfg.Channels),
+		}
+		cfg.Channels = 1
+		for i := range c.derivers {
+			d, err := NewDeriver(cfg)
+			if err != nil {
+				return nil, err
+			}
+			c.derivers[i] = d
+		}
+		return c, nil
+	}
+	switch cfg.Strategy {
+	case DifferenceSign:
+		return &differenceSign{}, nil
+	case MedianThreshold, MeanThreshold:
+		if cfg.Window < 1 {
+			return nil, fmt.Errorf("%w: window %d", ErrInvalidSize, cfg.Window)
+		}
+		return &movingThreshold{
+			median: cfg.Strategy == MedianThreshold,
+			window: make([]float64, cfg.Window),
+		}, nil
+	case ZeroCrossingParity:
+		return &zeroCrossing{level: cfg.Level}, nil
+	}
+	return nil, fmt.Errorf("%w: derivation %v", ErrUnknownMode, cfg.Strategy)
+}
+
+type differenceSign struct {
+	prev    float64
+	started bool
+}
+
+func (d *differenceSign) Derive(samples []float64, bits []byte) []byte {
+	for _, s := range samples {
+		if math.IsNaN(s) {
+			continue
+		}
+		if d.started {
+			if s > d.prev {
+				bits = append(bits, 1)
+			} else if s < d.prev {
+				bits = append(bits, 0)
+			}
+		}
+		d.prev, d.started = s, true
+	}
+	return bits
+}
+
+func (d *differenceSign) String() string {
+	return DifferenceSign.String()
+}
+
+type movingThreshold struct {
+	median bool
+
+	// ring buffer of the previous samples, and the same samples sorted for the median
+	window []float64
+	sorted []float64
+	next   int
+	sum    float64
+}
+
+func (m *movingThreshold) Derive(samples []float64, bits []byte) []byte {
+	size := len(m.window)
+	for _, s := range samples {
+		if math.IsNaN(s) {
+			continue
+		}
+		if len(m.sorted) == size {
+			t := m.threshold()
+			if s > t {
+				bits = append(bits, 1)
+			} else if s < t {
+				bits = append(bits, 0)
+			}
+
+			// evict the oldest sample
+			old := m.window[m.next]
+			m.sum -= old
+			m.remove(old)
+		}
+
+		m.window[m.next] = s
+		m.next = (m.next + 1) % size
+		m.sum += s
+		i := sort.SearchFloat64s(m.sorted, s)
+		m.sorted = append(m.sorted, 0)
+		copy(m.sorted[i+1:], m.sorted[i:])
+		m.sorted[i] = s
+	}
+	return bits
+}
+
+// remove deletes one occurrence of v from the sorted samples.
+func (m *movingThreshold) remove(v float64) {
+	i := sort.SearchFloat64s(m.sorted, v)
+	if i == len(m.sorted) || m.sorted[i] != v {
+		for i = 0; m.sorted[i] != v; i++ {
+		}
+	}
+	m.sorted = append(m.sorted[:i], m.sorted[i+1:]...)
+}
+
+func (m *movingThreshold) threshold() float64 {
+	n := len(m.sorted)
+	if !m.median {
+		return m.sum / float64(n)
+	}
+	if n%2 == 1 {
+		return m.sorted[n/2]
+	}
+	return (m.sorted[n/2-1] + m.sorted[n/2]) / 2
+}
+
+func (m *movingThreshold) String() string {
+	if m.median {
+		return MedianThreshold.String()
+	}
+	return MeanThreshold.String()
+}
+
+type zeroCrossing struct {
+	level float64
+
+	// sign of the last sample off the level, 0 before the first one
+	sign int
+
+	// samples since the last crossing, seen is set at the first crossing
+	count int64
+	seen  bool
+}
+
+func (z *zeroCrossing) Derive(samples []float64, bits []byte) []byte {
+	for _, s := range samples {
+		if math.IsNaN(s) {
+			continue
+		}
+		sign := z.sign
+		if s > z.level {
+			sign = 1
+		} else if s < z.level {
+			sign = -1
+		}
+
+		if z.sign != 0 && sign != z.sign {
+			// the interval before the first crossing has no defined start
+			if z.seen {
+				bits = append(bits, byte(z.count&0x01))
+			}
+			z.seen = true
+			z.count = 0
+		}
+		z.sign = sign
+		z.count++
+	}
+	return bits
+}
+
+func (z *zeroCrossing) String() string {
+	return ZeroCrossingParity.String()
+}
+
+// channelDeriver derives the bits of interleaved channels separately.
+// The bits of each chunk of samples are appended channel by channel.
+type channelDeriver struct {
+	derivers []Deriver
+	split    [][]float64
+
+	// channel of the next sample
+	next int
+}
+
+func (c *channelDeriver) Derive(samples []float64, bits []byte) []byte {
+	for i := range c.split {
+		c.split[i] = c.split[i][:0]
+	}
+	for _, s := range samples {
+		c.split[c.next] = append(c.split[c.next], s)
+		c.next = (c.next + 1) % len(c.split)
+	}
+	for i, d := range c.derivers {
+		bits = d.Derive(c.split[i], bits)
+	}
+	return bits
+}
+
+func (c *channelDeriver) String() string {
+	return c.derivers[0].String()
+}
+
+// DerivingReader passes on the bits derived from the samples of a source, packed most significant bit first.
+// Bits that do not fill a complete byte at the end of the input are dropped.
+type DerivingReader struct {
+	src     SampleReader
+	deriver Deriver
+
+	samples []float64
+	bits    []byte
+	out     bytes.Buffer
+	bw      bitWriter
+	err     error
+}
+
+// NewDerivingReader returns a DerivingReader applying d to the samples read from src.
+func NewDerivingReader(src SampleReader, d Deriver) *DerivingReader {
+	return &DerivingReader{
+		src:     src,
+		deriver: d,
+		samples: make([]float64, MaxChunkSize),
+	}
+}
+
+// Read reads the derived bits into p.
+func (r *DerivingReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 && r.err == nil {
+		r.fill()
+	}
+	if r.out.Len() > 0 {
+		return r.out.Read(p)
+	}
+	return 0, r.err
+}
+
+// fill reads the next samples from the source and derives their bits.
+func (r *DerivingReader) fill() {
+	n, err := r.src.ReadSamples(r.samples)
+	r.bits = r.deriver.Derive(r.samples[:n], r.bits[:0])
+	for _, b := range r.bits {
+		r.bw.writeBit(b, &r.out)
+	}
+	if err != nil {
+		r.bw.flush(&r.out, DropPartial)
+		r.err = err
+	}
+}
+
+// BitsOut returns the number of derived bits passed on so far.
+func (r *DerivingReader) BitsOut() int64 {
+	return r.bw.bits
+}
+
+// ReportStats sets the number of bits passed on to the extractor and the derivation strategy.
+func (r *DerivingReader) ReportStats(s *Stats) {
+	s.BitsUsed = r.bw.bits
+	s.Derivation = r.deriver.String()
+}
+
+// ByteSamples returns a SampleReader passing on each byte read from r as an unsigned sample.
+func ByteSamples(r io.Reader) SampleReader {
+	return NewSampleDecoder(r, 1, func(b []byte) float64 {
+		return float64(b[0])
+	})
+}
+
+// NewSampleDecoder returns a SampleReader decoding samples of size bytes read from r with decode,
+// like the Sample methods of the WAV and IQ formats. A trailing partial sample is dropped.
+func NewSampleDecoder(r io.Reader, size int, decode func(b []byte) float64) SampleReader {
+	return sample.NewDecoder(r, size, decode)
+}
+
+// payloadSamples returns a SampleReader decoding the sample payload read from r,
+// according to the format of the decoded file recorded in s. Plain files are read as unsigned bytes.
+func payloadSamples(r io.Reader, s *Stats) SampleReader {
+	switch {
+	case s.WAV != nil:
+		h := s.WAV.Header
+		return NewSampleDecoder(r, h.BytesPerSample(), h.Sample)
+	case s.IQ != nil:
+		return NewSampleDecoder(r, s.IQ.SampleSize(), s.IQ.Sample)
+	}
+	return ByteSamples(r)
+}
+
+// payloadChannels returns the number of interleaved channels of the sample payload of the decoded file recorded in s.
+func payloadChannels(s *Stats) int {
+	switch {
+	case s.WAV != nil:
+		return len(s.WAV.Channels)
+	case s.IQ != nil && s.IQ.Component == iq.Interleaved:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,131 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func derive(t *testing.T, cfg debias.DerivationConfig, samples []float64) []byte {
+	t.Helper()
+	d, err := debias.NewDeriver(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// state carries over between chunks
+	var bits []byte
+	for i := 0; i < len(samples); i += 3 {
+		end := i + 3
+		if end > len(samples) {
+			end = len(samples)
+		}
+		bits = d.Derive(samples[i:end], bits)
+	}
+	return bits
+}
+
+func TestDerivers(t *testing.T) {
+	tests := []struct {
+		cfg     debias.DerivationConfig
+		samples []float64
+		want    []byte
+	}{
+		{
+			cfg:     debias.DerivationConfig{Strategy: debias.DifferenceSign},
+			samples: []float64{1, 2, 2, 1, 5, 3, 3, 4},
+			want:    []byte{1, 0, 1, 0, 1},
+		},
+		{
+			// medians of the previous three samples: 2, 3, 2, 3
+			cfg:     debias.DerivationConfig{Strategy: debias.MedianThreshold, Window: 3},
+			samples: []float64{1, 9, 2, 3, 1, 3, 4},
+			want:    []byte{1, 0, 1, 1},
+		},
+		{
+			// means of the previous two samples: 2, 3, 4.5, 4
+			cfg:     debias.DerivationConfig{Strategy: debias.MeanThreshold, Window: 2},
+			samples: []float64{1, 3, 3, 6, 2, 6},
+			want:    []byte{1, 1, 0, 1},
+		},
+		{
+			// intervals between crossings: 3, 1, 1, the sample at the level does not cross it
+			cfg:     debias.DerivationConfig{Strategy: debias.ZeroCrossingParity},
+			samples: []float64{1, -1, -2, 0, 3, -1, 2, 1},
+			want:    []byte{1, 1, 1},
+		},
+		{
+			cfg:     debias.DerivationConfig{Strategy: debias.ZeroCrossingParity, Level: 10},
+			samples: []float64{11, 9, 9, 11, 12, 9},
+			want:    []byte{0, 0},
+		},
+		{
+			// NaN samples never enter the window
+			cfg:     debias.DerivationConfig{Strategy: debias.MedianThreshold, Window: 3},
+			samples: []float64{1, math.NaN(), 9, 2, 3, math.NaN(), 1, 3, 4, math.NaN()},
+			want:    []byte{1, 0, 1, 1},
+		},
+		{
+			// channels 1, 2, 3, 2 and 10, 9, 8, 9, the bits of each chunk of three samples are appended by channel
+			cfg:     debias.DerivationConfig{Strategy: debias.DifferenceSign, Channels: 2},
+			samples: []float64{1, 10, 2, 9, 3, 8, 2, 9},
+			want:    []byte{1, 1, 0, 0, 0, 1},
+		},
+	}
+	for _, tt := range tests {
+		got := derive(t, tt.cfg, tt.samples)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.cfg.Strategy, got, tt.want)
+		}
+	}
+}
+
+func TestDerivingReader(t *testing.T) {
+	var (
+		rng     = rand.New(rand.NewSource(1))
+		samples = make([]byte, 100000)
+	)
+
+	// a slow sine with noise on top, the raw bits are heavily biased
+	for i := range samples {
+		samples[i] = byte(128 + 100*math.Sin(float64(i)/500) + rng.NormFloat64()*4)
+	}
+
+	for _, strategy := range []debias.Derivation{debias.DifferenceSign, debias.MedianThreshold, debias.MeanThreshold} {
+		d, err := debias.NewDeriver(debias.DerivationConfig{Strategy: strategy, Window: 15})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := debias.NewDerivingReader(debias.ByteSamples(bytes.NewReader(samples)), d)
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.BitsOut() != int64(len(out)*8) || len(out) < 8000 {
+			t.Fatalf("%v: unexpected output of %d bytes, %d bits", strategy, len(out), r.BitsOut())
+		}
+
+		// the derived bits follow the noise rather than the signal
+		e := debias.EntropyBitsPerBit(out)
+		if e < 0.8 {
+			t.Fatalf("%v: entropy too low: %v", strategy, e)
+		}
+	}
+}
+
+func TestDeriverErrors(t *testing.T) {
+	if _, err := debias.NewDeriver(debias.DerivationConfig{Strategy: debias.MedianThreshold, Window: -1}); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err := debias.NewDeriver(debias.DerivationConfig{Channels: -1}); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err := debias.NewDeriver(debias.DerivationConfig{Strategy: 9}); !errors.Is(err, debias.ErrUnknownMode) {
+		t.Fatal("expected ErrUnknownMode, got ", err)
+	}
+}
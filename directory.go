@@ -3,36 +3,55 @@ package debias
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
 	"path/filepath"
 	"strings"
 )
 
 // Directory will debias all files in the given directory
 // and only for files that have the given extension.
-func Directory(path string, ext string, mode Mode) []*Stats {
+// An empty extension selects all files of a known capture format, WAV and IQ files,
+// each file is decoded according to its extension. With a format set WithFormat or an explicit IQ format, all files are selected.
+// The output files written by File are never selected, so a directory can be processed again.
+// Processing stops at the first file that fails,
+// the stats for the files processed until then are returned along with the error.
+// The options are applied to each file.
+func Directory(path string, ext string, mode Mode, opts ...Option) ([]*Stats, error) {
+
+	o := newOptions(opts)
 
 	files, err := ioutil.ReadDir(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	if !strings.HasPrefix(ext, ".") {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
 		ext = "." + ext
 	}
 
 	var stats []*Stats
 	for _, f := range files {
 
-		if filepath.Ext(f.Name()) != ext {
+		if f.IsDir() || strings.HasSuffix(f.Name(), outputSuffix) {
+			continue
+		}
+		if ext == "" {
+			if !decodable(f.Name(), o) {
+				continue
+			}
+		} else if filepath.Ext(f.Name()) != ext {
 			continue
 		}
 
 		file := filepath.Join(path, f.Name())
-		fmt.Println("processing", file)
+		fmt.Fprintln(o.progress, "processing", file)
+
+		s, err := File(path, file, f, mode, opts...)
+		if err != nil {
+			return stats, err
+		}
 
-		stats = append(stats, File(path, file, f, mode))
+		stats = append(stats, s)
 	}
 
-	return stats
+	return stats, nil
 }
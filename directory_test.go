@@ -0,0 +1,89 @@
+package debias_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/iq"
+)
+
+func TestDirectoryMixedCaptures(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{
+		"rtl.cu8":     20000,
+		"hackrf.cs8":  20000,
+		"usrp.cs16":   40000,
+		"notes.txt":   100,
+		"dump.bin":    1000,
+		"floats.cf32": 80000,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), biased(size, 0.7, int64(size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := debias.Directory(dir, "", debias.ModeVonNeumann, debias.WithIQ(iq.Config{Component: iq.InPhase}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 4 {
+		t.Fatal("unexpected number of processed files: ", len(stats))
+	}
+
+	formats := map[string]iq.Format{
+		"rtl.cu8":     iq.CU8,
+		"hackrf.cs8":  iq.CS8,
+		"usrp.cs16":   iq.CS16,
+		"floats.cf32": iq.CF32,
+	}
+	for _, s := range stats {
+		if s.IQ == nil || s.IQ.Format != formats[s.FileName] || s.IQ.Component != iq.InPhase {
+			t.Fatalf("%s: unexpected IQ config %+v", s.FileName, s.IQ)
+		}
+		// only the I values reach the extractor
+		if s.BytesIn != 10000 && s.BytesIn != 20000 && s.BytesIn != 40000 {
+			t.Fatalf("%s: unexpected number of input bytes %d", s.FileName, s.BytesIn)
+		}
+	}
+
+	// the outputs of the first run are .bin files as well, but are not processed again
+	stats, err = debias.Directory(dir, "bin", debias.ModeVonNeumann)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 {
+		t.Fatal("unexpected number of processed files: ", len(stats))
+	}
+	var found bool
+	for _, s := range stats {
+		if s.IQ != nil {
+			t.Fatalf("%s: unexpected IQ config", s.FileName)
+		}
+		if s.FileName == "dump.bin" {
+			found = s.BytesIn == 1000
+		}
+	}
+	if !found {
+		t.Fatal("plain file not processed")
+	}
+}
+
+func TestDirectoryForcedFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "capture.raw"), biased(20000, 0.7, 1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second run sees the output of the first one, which is skipped for a forced format
+	for run := 0; run < 2; run++ {
+		stats, err := debias.Directory(dir, "", debias.ModeVonNeumann, debias.WithIQ(iq.Config{Format: iq.CU8}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 1 || stats[0].FileName != "capture.raw" {
+			t.Fatalf("run %d: unexpected stats: %+v", run, stats)
+		}
+	}
+}
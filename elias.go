@@ -0,0 +1,166 @@
+package debias
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// DefaultEliasBlockSize is the block size in bits of the Elias extractor registered for ModeElias.
+var DefaultEliasBlockSize = 32
+
+// maxEliasBlockSize is the largest block size for which all binomial coefficients fit into an uint64.
+const maxEliasBlockSize = 64
+
+// binomial holds the binomial coefficients up to maxEliasBlockSize.
+var binomial = pascal(maxEliasBlockSize)
+
+func init() {
+	Register(ModeElias, "elias", func(r io.Reader) Extractor {
+		ex, err := NewElias(r, DefaultEliasBlockSize)
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+		return ex
+	})
+}
+
+// NewElias returns an Extractor applying the block extractor by Peter Elias (1972)
+// to the data read from r, using blocks of blockSize bits.
+//
+// All blocks with the same Hamming weight k are equally likely for an independent source with a stationary bias.
+// The rank of a block among the C(n, k) blocks of its weight is therefore uniformly distributed,
+// and is turned into output bits by splitting the ranks into intervals with sizes of the powers of two in C(n, k).
+// A block with a rank in an interval of size 2^j produces j bits.
+//
+// The yield increases with the block size, blockSize must be in the range 2..64, otherwise ErrInvalidSize is returned.
+// Bits that do not fill a complete block at the end of the input are discarded.
+func NewElias(r io.Reader, blockSize int) (*Reader, error) {
+	e, err := newElias(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(r, e), nil
+}
+
+// elias implements the Elias block extractor.
+type elias struct {
+	blockSize int
+	out       bitWriter
+	padding   Padding
+
+	// current block
+	block   uint64
+	numBits int
+	numOnes int
+
+	// number of bits plain Von Neumann debiasing would have produced
+	vnBits int64
+}
+
+func newElias(blockSize int) (*elias, error) {
+	if blockSize < 2 || blockSize > maxEliasBlockSize {
+		return nil, fmt.Errorf("%w: Elias block size %d", ErrInvalidSize, blockSize)
+	}
+	return &elias{blockSize: blockSize}, nil
+}
+
+func (e *elias) Process(p []byte, out *bytes.Buffer) error {
+	for _, b := range p {
+
+		e.vnBits += int64(neumannYield(b))
+
+		for j := 7; j >= 0; j-- {
+
+			bit := (b >> j) & 0x01
+
+			e.block = e.block<<1 | uint64(bit)
+			e.numOnes += int(bit)
+			e.numBits++
+
+			if e.numBits == e.blockSize {
+				e.extract(out)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *elias) Flush(out *bytes.Buffer) error {
+	// incomplete blocks are discarded
+	e.block, e.numBits, e.numOnes = 0, 0, 0
+
+	// write leftover
+	e.out.flush(out, e.padding)
+	return nil
+}
+
+// extract writes the output bits for the current block and resets it.
+func (e *elias) extract(out *bytes.Buffer) {
+	var (
+		n     = e.blockSize
+		k     = e.numOnes
+		total = binomial[n][k]
+		rank  uint64
+	)
+
+	// rank of the block in lexicographic order among all blocks with the same weight
+	for i := n - 1; i >= 0 && k > 0; i-- {
+		if (e.block>>uint(i))&0x01 == 1 {
+			// all blocks with a zero at this position come first
+			rank += binomial[i][k]
+			k--
+		}
+	}
+
+	// find the power of two interval the rank belongs to, starting with the largest
+	for j := 63; j >= 0; j-- {
+		size := uint64(1) << uint(j)
+		if total&size == 0 {
+			continue
+		}
+		if rank < size {
+			for i := j - 1; i >= 0; i-- {
+				e.out.writeBit(byte(rank>>uint(i))&0x01, out)
+			}
+			break
+		}
+		rank -= size
+	}
+
+	e.block, e.numBits, e.numOnes = 0, 0, 0
+}
+
+func (e *elias) SetPadding(p Padding) {
+	e.padding = p
+}
+
+func (e *elias) BitsOut() int64 {
+	return e.out.bits
+}
+
+// ReportStats sets the gain over plain Von Neumann debiasing.
+func (e *elias) ReportStats(s *Stats) {
+	if e.vnBits > 0 {
+		s.Gain = float64(e.BitsOut()) / float64(e.vnBits)
+	}
+}
+
+// neumannYield returns the number of bits Von Neumann debiasing produces for b.
+func neumannYield(b byte) int {
+	return bits.OnesCount8((b ^ (b >> 1)) & 0x55)
+}
+
+// pascal returns the binomial coefficients C(n, k) for all n, k <= max.
+func pascal(max int) [][]uint64 {
+	c := make([][]uint64, max+1)
+	for n := range c {
+		c[n] = make([]uint64, max+1)
+		c[n][0] = 1
+		for k := 1; k <= n; k++ {
+			c[n][k] = c[n-1][k-1] + c[n-1][k]
+		}
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/bits"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+var eliasTests = []struct {
+	blockSize int
+	in        string
+	out       string
+}{
+	{
+		// C(4,2) = 6 = 4 + 2: rank 0 is in the first interval and yields two bits,
+		// rank 4 (1010) is in the second interval and yields one bit
+		blockSize: 4,
+		in:        "00111010",
+		out:       "000",
+	},
+	{
+		// 1100 has rank 5, 1000 has rank 3 of C(4,1) = 4
+		blockSize: 4,
+		in:        "11001000",
+		out:       "111",
+	},
+	{
+		// 0000 and 1111 are the only blocks of their weight and yield nothing
+		blockSize: 4,
+		in:        "0000111100001111",
+		out:       "",
+	},
+	{
+		// C(8,1) = 8: 00000100 has rank 2
+		blockSize: 8,
+		in:        "00000100",
+		out:       "010",
+	},
+	{
+		// C(6,3) = 20 = 16 + 4: 011010 has rank 8,
+		// 000000 yields nothing and the trailing incomplete block is discarded
+		blockSize: 6,
+		in:        "0110100000000000",
+		out:       "1000",
+	},
+}
+
+func TestElias(t *testing.T) {
+	for i, te := range eliasTests {
+
+		r, err := debias.NewElias(bytes.NewReader(bitString(te.in).bytes()), te.blockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.BitsOut() != int64(len(te.out)) {
+			t.Fatalf("test #%d: expected %d bits but got %d", i, len(te.out), r.BitsOut())
+		}
+
+		var expected []byte
+		if len(te.out) > 0 {
+			padded := te.out
+			for len(padded)%8 != 0 {
+				padded += "0"
+			}
+			expected = bitString(padded).bytes()
+		}
+		if !bytes.Equal(out, expected) {
+			t.Fatalf("test #%d: expected %s but got %08b", i, te.out, out)
+		}
+	}
+}
+
+func TestEliasBlockSize(t *testing.T) {
+	for _, size := range []int{-1, 0, 1, 65} {
+		if _, err := debias.NewElias(bytes.NewReader(nil), size); !errors.Is(err, debias.ErrInvalidSize) {
+			t.Fatalf("block size %d: expected ErrInvalidSize, got %v", size, err)
+		}
+	}
+}
+
+func TestEliasBias(t *testing.T) {
+	s, out := runFile(t, "in.bin", biased(100000, 0.8, 2), debias.ModeElias)
+	if s.Gain < 2.5 {
+		t.Fatal("expected a gain over Von Neumann, got ", s.Gain)
+	}
+
+	var ones int
+	for _, b := range out {
+		ones += bits.OnesCount8(b)
+	}
+	if ratio := float64(ones) / float64(len(out)*8); ratio < 0.49 || ratio > 0.51 {
+		t.Fatal("output is biased: ones ratio ", ratio)
+	}
+}
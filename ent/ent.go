@@ -0,0 +1,169 @@
+// Package ent computes the statistics of the ent pseudorandom number sequence test program
+// by John Walker (https://www.fourmilab.ch/random/), so results can be compared with published ent output.
+package ent
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// ErrNoData is returned by Analyze if the reader did not provide any data.
+var ErrNoData = errors.New("ent: no data to analyze")
+
+// monteCarloBytes is the number of bytes per point of the Monte Carlo estimate, three bytes per coordinate.
+const monteCarloBytes = 6
+
+// Report holds the statistics of the analyzed data.
+type Report struct {
+
+	// Bytes is the number of analyzed bytes.
+	Bytes int64 `json:"bytes"`
+
+	// Entropy is the Shannon entropy in bits per byte, BitEntropy in bits per bit.
+	Entropy    float64 `json:"entropy"`
+	BitEntropy float64 `json:"bitEntropy"`
+
+	// Compression is the size reduction in percent that optimal compression could achieve.
+	Compression float64 `json:"compression"`
+
+	// ChiSquare is the chi-square statistic of the byte distribution, with 255 degrees of freedom.
+	// ChiSquareP is the probability that a random sequence exceeds it,
+	// values below 0.01 or above 0.99 indicate a non-random sequence.
+	ChiSquare  float64 `json:"chiSquare"`
+	ChiSquareP float64 `json:"chiSquareP"`
+
+	// Mean is the arithmetic mean of the bytes, 127.5 for random data.
+	Mean float64 `json:"mean"`
+
+	// MonteCarloPi is the value of pi estimated from points in the unit square, MonteCarloError the deviation in percent.
+	MonteCarloPi    float64 `json:"monteCarloPi"`
+	MonteCarloError float64 `json:"monteCarloError"`
+
+	// SerialCorrelation is the correlation coefficient of each byte with its successor, 0 for random data.
+	// It is undefined if all bytes are equal and reported as 1 in this case.
+	SerialCorrelation float64 `json:"serialCorrelation"`
+}
+
+// Analyzer accumulates the statistics of the data written to it.
+type Analyzer struct {
+	counts [256]int64
+	total  int64
+
+	// Monte Carlo
+	point   [monteCarloBytes]byte
+	nPoint  int
+	inside  int64
+	nPoints int64
+
+	// serial correlation
+	first        byte
+	last         float64
+	t1, t2, t3   float64
+	havePrevious bool
+}
+
+// Write adds p to the analyzed data, it never fails.
+func (a *Analyzer) Write(p []byte) (int, error) {
+	for _, b := range p {
+		a.counts[b]++
+		a.total++
+
+		a.point[a.nPoint] = b
+		a.nPoint++
+		if a.nPoint == monteCarloBytes {
+			a.addPoint()
+			a.nPoint = 0
+		}
+
+		v := float64(b)
+		if a.havePrevious {
+			a.t1 += a.last * v
+		} else {
+			a.first = b
+			a.havePrevious = true
+		}
+		a.t2 += v
+		a.t3 += v * v
+		a.last = v
+	}
+	return len(p), nil
+}
+
+func (a *Analyzer) addPoint() {
+	var x, y float64
+	for i := 0; i < monteCarloBytes/2; i++ {
+		x = x*256 + float64(a.point[i])
+		y = y*256 + float64(a.point[i+monteCarloBytes/2])
+	}
+	radius := math.Pow(256, monteCarloBytes/2) - 1
+	if x*x+y*y <= radius*radius {
+		a.inside++
+	}
+	a.nPoints++
+}
+
+// Report returns the statistics of the data written so far.
+func (a *Analyzer) Report() *Report {
+	r := &Report{Bytes: a.total}
+	if a.total == 0 {
+		return r
+	}
+
+	var (
+		n        = float64(a.total)
+		expected = n / 256
+		sum      float64
+		ones     int64
+	)
+	for v, c := range a.counts {
+		if c > 0 {
+			p := float64(c) / n
+			r.Entropy -= p * math.Log2(p)
+		}
+		r.ChiSquare += (float64(c) - expected) * (float64(c) - expected) / expected
+		sum += float64(v) * float64(c)
+		for b := v; b > 0; b >>= 1 {
+			ones += int64(b&0x01) * c
+		}
+	}
+	r.Compression = 100 * (8 - r.Entropy) / 8
+	r.ChiSquareP = special.Igamc(255.0/2, r.ChiSquare/2)
+	r.Mean = sum / n
+
+	if p1 := float64(ones) / (n * 8); p1 > 0 && p1 < 1 {
+		r.BitEntropy = -p1*math.Log2(p1) - (1-p1)*math.Log2(1-p1)
+	}
+
+	r.MonteCarloError = 100
+	if a.nPoints > 0 {
+		r.MonteCarloPi = 4 * float64(a.inside) / float64(a.nPoints)
+		r.MonteCarloError = 100 * math.Abs(math.Pi-r.MonteCarloPi) / math.Pi
+	}
+
+	// the sequence wraps around to the first byte
+	var (
+		t1 = a.t1 + a.last*float64(a.first)
+		t2 = a.t2 * a.t2
+		d  = n*a.t3 - t2
+	)
+	r.SerialCorrelation = 1
+	if d != 0 {
+		r.SerialCorrelation = (n*t1 - t2) / d
+	}
+	return r
+}
+
+// Analyze returns the statistics of the data read from r.
+func Analyze(r io.Reader) (*Report, error) {
+	var a Analyzer
+	if _, err := io.Copy(&a, r); err != nil {
+		return nil, err
+	}
+	if a.total == 0 {
+		return nil, ErrNoData
+	}
+	return a.Report(), nil
+}
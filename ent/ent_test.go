@@ -0,0 +1,110 @@
+package ent_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/dreadl0ck/debias/ent"
+)
+
+func analyze(t *testing.T, data []byte) *ent.Report {
+	t.Helper()
+	r, err := ent.Analyze(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestAnalyzeUniform(t *testing.T) {
+	var data []byte
+	for i := 0; i < 4096; i++ {
+		data = append(data, byte(i))
+	}
+	r := analyze(t, data)
+
+	if r.Bytes != 4096 || r.Entropy != 8 || r.BitEntropy != 1 || r.Compression != 0 {
+		t.Fatalf("unexpected entropy: %+v", r)
+	}
+	if r.ChiSquare != 0 || r.ChiSquareP != 1 || r.Mean != 127.5 {
+		t.Fatalf("unexpected distribution: %+v", r)
+	}
+
+	// successive bytes of the ramp are strongly correlated
+	if r.SerialCorrelation < 0.95 {
+		t.Fatal("unexpected serial correlation: ", r.SerialCorrelation)
+	}
+}
+
+func TestAnalyzeConstant(t *testing.T) {
+	r := analyze(t, bytes.Repeat([]byte{0xff}, 1000))
+	if r.Entropy != 0 || r.BitEntropy != 0 || r.Compression != 100 || r.Mean != 255 {
+		t.Fatalf("unexpected statistics: %+v", r)
+	}
+	if r.ChiSquareP > 1e-10 || r.SerialCorrelation != 1 {
+		t.Fatalf("unexpected statistics: %+v", r)
+	}
+}
+
+func TestAnalyzeSmall(t *testing.T) {
+	// the first point lies inside the circle, the second one in the opposite corner outside of it
+	r := analyze(t, []byte{0, 0, 0, 0, 0, 0, 255, 255, 255, 255, 255, 255})
+	if r.MonteCarloPi != 2 {
+		t.Fatal("unexpected Monte Carlo estimate: ", r.MonteCarloPi)
+	}
+
+	r = analyze(t, []byte{0, 255})
+	if r.SerialCorrelation != -1 {
+		t.Fatal("unexpected serial correlation: ", r.SerialCorrelation)
+	}
+
+	if _, err := ent.Analyze(bytes.NewReader(nil)); err != ent.ErrNoData {
+		t.Fatal("expected ErrNoData, got ", err)
+	}
+}
+
+func TestAnalyzeRandom(t *testing.T) {
+	data := make([]byte, 1000000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	// feed the analyzer in chunks
+	var a ent.Analyzer
+	for i := 0; i < len(data); i += 999 {
+		end := i + 999
+		if end > len(data) {
+			end = len(data)
+		}
+		a.Write(data[i:end])
+	}
+	r := a.Report()
+
+	if r.Entropy < 7.999 || r.BitEntropy < 0.99999 {
+		t.Fatalf("unexpected entropy: %+v", r)
+	}
+	if r.ChiSquareP < 0.01 || r.ChiSquareP > 0.99 {
+		t.Fatalf("unexpected chi-square: %+v", r)
+	}
+	if math.Abs(r.Mean-127.5) > 0.5 || r.MonteCarloError > 1 || math.Abs(r.SerialCorrelation) > 0.01 {
+		t.Fatalf("unexpected statistics: %+v", r)
+	}
+	if !reflect.DeepEqual(r, analyze(t, data)) {
+		t.Fatal("expected the same report for chunked input")
+	}
+
+	// reports are serializable
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ent.Report
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != *r {
+		t.Fatalf("expected %+v, got %+v", r, decoded)
+	}
+}
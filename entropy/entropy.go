@@ -0,0 +1,267 @@
+// Package entropy computes the Rényi entropy family of a symbol distribution,
+// including the Hartley (order 0), Shannon (order 1), collision (order 2) and min-entropy (order infinity).
+//
+// Symbols are 1, 2, 4, 8 or 16 bits wide and taken from the data most significant bit first,
+// 16 bit symbols are read big endian. The data is streamed, only the symbol frequencies are kept.
+package entropy
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	// ErrInvalidWidth is returned for symbol widths other than 1, 2, 4, 8 or 16 bits.
+	ErrInvalidWidth = errors.New("entropy: invalid symbol width")
+
+	// ErrInvalidOrder is returned for negative or NaN orders.
+	ErrInvalidOrder = errors.New("entropy: invalid order")
+
+	// ErrNoData is returned if no complete symbol has been read.
+	ErrNoData = errors.New("entropy: no data")
+)
+
+// Orders with a name of their own.
+const (
+	Hartley   = 0
+	Shannon   = 1
+	Collision = 2
+)
+
+// Min is the order of the min-entropy.
+var Min = math.Inf(1)
+
+// Counter accumulates the frequencies of the symbols written to it.
+type Counter struct {
+	width  int
+	counts []int64
+	total  int64
+
+	// pending high byte of a 16 bit symbol
+	high    byte
+	hasHigh bool
+}
+
+// NewCounter returns a Counter for symbols of width bits.
+func NewCounter(width int) (*Counter, error) {
+	switch width {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, ErrInvalidWidth
+	}
+	return &Counter{
+		width:  width,
+		counts: make([]int64, 1<<uint(width)),
+	}, nil
+}
+
+// Write adds the symbols of p to the frequencies, it never returns an error.
+// A 16 bit symbol may span two writes.
+func (c *Counter) Write(p []byte) (int, error) {
+	switch c.width {
+	case 8:
+		for _, b := range p {
+			c.counts[b]++
+		}
+		c.total += int64(len(p))
+	case 16:
+		for _, b := range p {
+			if !c.hasHigh {
+				c.high, c.hasHigh = b, true
+				continue
+			}
+			c.counts[int(c.high)<<8|int(b)]++
+			c.total++
+			c.hasHigh = false
+		}
+	default:
+		var (
+			w    = uint(c.width)
+			mask = byte(1)<<w - 1
+		)
+		for _, b := range p {
+			for shift := 8 - w; ; shift -= w {
+				c.counts[b>>shift&mask]++
+				if shift == 0 {
+					break
+				}
+			}
+		}
+		c.total += int64(len(p)) * int64(8/c.width)
+	}
+	return len(p), nil
+}
+
+// addByte adds delta to the frequencies of the symbols of b, for widths up to 8 bits.
+func (c *Counter) addByte(b byte, delta int64) {
+	if c.width == 8 {
+		c.counts[b] += delta
+		c.total += delta
+		return
+	}
+	var (
+		w    = uint(c.width)
+		mask = byte(1)<<w - 1
+	)
+	for shift := 8 - w; ; shift -= w {
+		c.counts[b>>shift&mask] += delta
+		if shift == 0 {
+			break
+		}
+	}
+	c.total += delta * int64(8/c.width)
+}
+
+// Width returns the number of bits per symbol.
+func (c *Counter) Width() int {
+	return c.width
+}
+
+// Symbols returns the number of symbols counted, a trailing half of a 16 bit symbol is not counted.
+func (c *Counter) Symbols() int64 {
+	return c.total
+}
+
+// Renyi returns the Rényi entropy of the given order in bits per symbol.
+func (c *Counter) Renyi(order float64) (float64, error) {
+	if order < 0 || math.IsNaN(order) {
+		return 0, ErrInvalidOrder
+	}
+	if c.total == 0 {
+		return 0, ErrNoData
+	}
+
+	var (
+		n    = float64(c.total)
+		max  int64
+		seen int
+	)
+	for _, v := range c.counts {
+		if v > 0 {
+			seen++
+		}
+		if v > max {
+			max = v
+		}
+	}
+	pMax := float64(max) / n
+
+	switch {
+	case order == Hartley:
+		return math.Log2(float64(seen)), nil
+	case order == Shannon:
+		var sum float64
+		for _, v := range c.counts {
+			if v > 0 {
+				p := float64(v) / n
+				sum -= p * math.Log2(p)
+			}
+		}
+		return sum, nil
+	case math.IsInf(order, 1):
+		return -math.Log2(pMax), nil
+	}
+
+	// sum p^a = pMax^a * sum (p/pMax)^a keeps the terms in range for large orders
+	var sum float64
+	for _, v := range c.counts {
+		if v > 0 {
+			sum += math.Pow(float64(v)/float64(max), order)
+		}
+	}
+	h := (order*math.Log2(pMax) + math.Log2(sum)) / (1 - order)
+	if h < 0 {
+		// rounding for a single symbol
+		h = 0
+	}
+	return h, nil
+}
+
+// PerBit returns the Rényi entropy of the given order in bits per bit.
+func (c *Counter) PerBit(order float64) (float64, error) {
+	h, err := c.Renyi(order)
+	return h / float64(c.width), err
+}
+
+// Report returns the named entropies of the symbols counted so far.
+func (c *Counter) Report() (*Report, error) {
+	if c.total == 0 {
+		return nil, ErrNoData
+	}
+	r := &Report{
+		Width:   c.width,
+		Symbols: c.total,
+	}
+	r.Hartley, _ = c.Renyi(Hartley)
+	r.Shannon, _ = c.Renyi(Shannon)
+	r.Collision, _ = c.Renyi(Collision)
+	r.Min, _ = c.Renyi(Min)
+	return r, nil
+}
+
+// Reset clears the frequencies.
+func (c *Counter) Reset() {
+	for i := range c.counts {
+		c.counts[i] = 0
+	}
+	c.total = 0
+	c.hasHigh = false
+}
+
+// Report holds the named orders of the Rényi entropy in bits per symbol.
+type Report struct {
+	Width   int   `json:"width"`
+	Symbols int64 `json:"symbols"`
+
+	Hartley   float64 `json:"hartley"`
+	Shannon   float64 `json:"shannon"`
+	Collision float64 `json:"collision"`
+	Min       float64 `json:"min"`
+}
+
+// PerBit returns the report with the entropies in bits per bit.
+func (r *Report) PerBit() *Report {
+	w := float64(r.Width)
+	return &Report{
+		Width:     r.Width,
+		Symbols:   r.Symbols,
+		Hartley:   r.Hartley / w,
+		Shannon:   r.Shannon / w,
+		Collision: r.Collision / w,
+		Min:       r.Min / w,
+	}
+}
+
+// Measure reads r until EOF and reports the entropies of its symbols of width bits.
+func Measure(r io.Reader, width int) (*Report, error) {
+	c, err := count(r, width)
+	if err != nil {
+		return nil, err
+	}
+	return c.Report()
+}
+
+// Renyi reads r until EOF and returns the Rényi entropy of the given order
+// of its symbols of width bits, in bits per symbol.
+func Renyi(r io.Reader, width int, order float64) (float64, error) {
+	if order < 0 || math.IsNaN(order) {
+		return 0, ErrInvalidOrder
+	}
+	c, err := count(r, width)
+	if err != nil {
+		return 0, err
+	}
+	return c.Renyi(order)
+}
+
+func count(r io.Reader, width int) (*Counter, error) {
+	c, err := NewCounter(width)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(c, r); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
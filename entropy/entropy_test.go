@@ -0,0 +1,164 @@
+package entropy_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/entropy"
+)
+
+// biased returns size bytes whose bits are 1 with probability p.
+func biased(size int, p float64, seed int64) []byte {
+	var (
+		rng  = rand.New(rand.NewSource(seed))
+		data = make([]byte, size)
+	)
+	for i := range data {
+		for j := 0; j < 8; j++ {
+			if rng.Float64() < p {
+				data[i] |= 1 << uint(j)
+			}
+		}
+	}
+	return data
+}
+
+func TestRenyiKnownDistribution(t *testing.T) {
+	// symbol probabilities 1/2, 1/4, 1/8, 1/8 over 2 bit symbols
+	data := []byte{0x00, 0x00, 0x55, 0xbe}
+
+	tests := []struct {
+		order float64
+		want  float64
+	}{
+		{entropy.Hartley, 2},
+		{entropy.Shannon, 1.75},
+		{entropy.Collision, -math.Log2(0.25 + 0.0625 + 2*0.015625)},
+		{0.5, 2 * math.Log2(math.Sqrt(0.5)+math.Sqrt(0.25)+2*math.Sqrt(0.125))},
+		{3, -0.5 * math.Log2(0.125+0.015625+2*0.001953125)},
+		{entropy.Min, 1},
+	}
+	for _, tt := range tests {
+		got, err := entropy.Renyi(bytes.NewReader(data), 2, tt.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("order %v: got %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestRenyiOrdering(t *testing.T) {
+	data := biased(20000, 0.7, 1)
+
+	for _, width := range []int{1, 2, 4, 8, 16} {
+		r, err := entropy.Measure(bytes.NewReader(data), width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Symbols != int64(len(data)*8/width) {
+			t.Fatalf("width %d: unexpected number of symbols %d", width, r.Symbols)
+		}
+
+		// the Rényi entropy is non-increasing in the order
+		if !(r.Hartley >= r.Shannon && r.Shannon >= r.Collision && r.Collision >= r.Min) {
+			t.Fatalf("width %d: entropies not ordered: %+v", width, r)
+		}
+
+		// the bits are independent, so the per bit entropy is about the same for all widths,
+		// apart from the sampling error of the wide alphabets
+		pb := r.PerBit()
+		if width <= 8 && math.Abs(pb.Min+math.Log2(0.7)) > 0.02 {
+			t.Fatalf("width %d: unexpected min-entropy %v per bit", width, pb.Min)
+		}
+		if width <= 8 && math.Abs(pb.Shannon-0.8813) > 0.01 {
+			t.Fatalf("width %d: unexpected Shannon entropy %v per bit", width, pb.Shannon)
+		}
+	}
+}
+
+func TestCounterStreaming(t *testing.T) {
+	data := biased(10001, 0.6, 2)
+
+	for _, width := range []int{1, 2, 4, 8, 16} {
+		c, err := entropy.NewCounter(width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// odd chunk sizes split the 16 bit symbols
+		for i := 0; i < len(data); i += 333 {
+			end := i + 333
+			if end > len(data) {
+				end = len(data)
+			}
+			c.Write(data[i:end])
+		}
+		streamed, err := c.Report()
+		if err != nil {
+			t.Fatal(err)
+		}
+		whole, err := entropy.Measure(bytes.NewReader(data), width)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *streamed != *whole {
+			t.Fatalf("width %d: streaming result differs: %+v %+v", width, streamed, whole)
+		}
+	}
+}
+
+func TestVonNeumannImprovement(t *testing.T) {
+	in := biased(50000, 0.8, 3)
+
+	pr, _, _ := debias.VonNeumann(bytes.NewReader(in), false)
+	out, err := ioutil.ReadAll(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := entropy.Measure(bytes.NewReader(in), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := entropy.Measure(bytes.NewReader(out), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.PerBit().Min > 0.33 || after.PerBit().Min < 0.85 {
+		t.Fatalf("unexpected min-entropy per bit: %v before, %v after", before.PerBit().Min, after.PerBit().Min)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := entropy.NewCounter(3); !errors.Is(err, entropy.ErrInvalidWidth) {
+		t.Fatal("expected ErrInvalidWidth, got ", err)
+	}
+	if _, err := entropy.Renyi(bytes.NewReader([]byte{1}), 8, -1); !errors.Is(err, entropy.ErrInvalidOrder) {
+		t.Fatal("expected ErrInvalidOrder, got ", err)
+	}
+	if _, err := entropy.Measure(bytes.NewReader(nil), 8); !errors.Is(err, entropy.ErrNoData) {
+		t.Fatal("expected ErrNoData, got ", err)
+	}
+	// a single byte is not a 16 bit symbol
+	if _, err := entropy.Measure(bytes.NewReader([]byte{1}), 16); !errors.Is(err, entropy.ErrNoData) {
+		t.Fatal("expected ErrNoData, got ", err)
+	}
+
+	c, _ := entropy.NewCounter(8)
+	c.Write(bytes.Repeat([]byte{7}, 10))
+	for _, order := range []float64{0, 0.5, 1, 2, 10, entropy.Min} {
+		if h, err := c.Renyi(order); err != nil || h != 0 {
+			t.Fatalf("order %v: expected zero entropy for a constant, got %v %v", order, h, err)
+		}
+	}
+	c.Reset()
+	if c.Symbols() != 0 {
+		t.Fatal("reset did not clear the frequencies")
+	}
+}
@@ -0,0 +1,178 @@
+package entropy
+
+import "io"
+
+// ProfileConfig configures the windows of an entropy profile.
+type ProfileConfig struct {
+
+	// Window is the number of bytes per window.
+	Window int
+
+	// Step is the number of bytes between the start of two windows,
+	// zero or Window gives tumbling windows, smaller values sliding windows.
+	Step int
+
+	// Width is the number of bits per symbol, 1, 2, 4 or 8.
+	Width int
+}
+
+// DefaultProfileConfig evaluates the bytes of tumbling windows of 64 KiB.
+var DefaultProfileConfig = ProfileConfig{
+	Window: 1 << 16,
+	Step:   1 << 16,
+	Width:  8,
+}
+
+func (c ProfileConfig) withDefaults() ProfileConfig {
+	d := DefaultProfileConfig
+	if c.Window > 0 {
+		d.Window = c.Window
+		d.Step = c.Window
+	}
+	if c.Step > 0 {
+		d.Step = c.Step
+	}
+	if c.Width > 0 {
+		d.Width = c.Width
+	}
+	return d
+}
+
+// Point holds the entropy of one window in bits per symbol.
+type Point struct {
+
+	// Offset is the position of the first byte of the window in the stream.
+	Offset int64 `json:"offset"`
+
+	// Length is the number of bytes in the window, it is only below the window size for the last point.
+	Length int `json:"length"`
+
+	Shannon float64 `json:"shannon"`
+	Min     float64 `json:"min"`
+}
+
+// Profile is the series of window entropies over a stream.
+type Profile struct {
+	Window int     `json:"window"`
+	Step   int     `json:"step"`
+	Width  int     `json:"width"`
+	Bytes  int64   `json:"bytes"`
+	Points []Point `json:"points"`
+}
+
+// MinPoint returns the point with the lowest min-entropy, or nil if the profile is empty.
+func (p *Profile) MinPoint() *Point {
+	var min *Point
+	for i := range p.Points {
+		if min == nil || p.Points[i].Min < min.Min {
+			min = &p.Points[i]
+		}
+	}
+	return min
+}
+
+// Profiler computes an entropy profile of the data written to it.
+// Only the bytes of the current window are kept.
+type Profiler struct {
+	cfg     ProfileConfig
+	counter *Counter
+
+	// ring buffer holding the last Window bytes
+	ring  []byte
+	total int64
+
+	points []Point
+}
+
+// NewProfiler returns a Profiler for cfg, zero values are taken from DefaultProfileConfig.
+func NewProfiler(cfg ProfileConfig) (*Profiler, error) {
+	cfg = cfg.withDefaults()
+	if cfg.Width > 8 {
+		return nil, ErrInvalidWidth
+	}
+	c, err := NewCounter(cfg.Width)
+	if err != nil {
+		return nil, err
+	}
+	return &Profiler{
+		cfg:     cfg,
+		counter: c,
+		ring:    make([]byte, cfg.Window),
+	}, nil
+}
+
+// Write adds p to the stream, it never returns an error.
+func (pr *Profiler) Write(p []byte) (int, error) {
+	var (
+		window = int64(pr.cfg.Window)
+		step   = int64(pr.cfg.Step)
+	)
+	for _, b := range p {
+		i := pr.total % window
+		if pr.total >= window {
+			pr.counter.addByte(pr.ring[i], -1)
+		}
+		pr.ring[i] = b
+		pr.counter.addByte(b, 1)
+		pr.total++
+
+		if start := pr.total - window; start >= 0 && start%step == 0 {
+			pr.points = append(pr.points, pr.point(pr.counter, start, pr.cfg.Window))
+		}
+	}
+	return len(p), nil
+}
+
+func (pr *Profiler) point(c *Counter, offset int64, length int) Point {
+	p := Point{
+		Offset: offset,
+		Length: length,
+	}
+	p.Shannon, _ = c.Renyi(Shannon)
+	p.Min, _ = c.Renyi(Min)
+	return p
+}
+
+// Profile returns the profile of the data written so far.
+// The first incomplete window is added as a shorter point if it holds bytes not covered by the complete windows.
+func (pr *Profiler) Profile() *Profile {
+	p := &Profile{
+		Window: pr.cfg.Window,
+		Step:   pr.cfg.Step,
+		Width:  pr.cfg.Width,
+		Bytes:  pr.total,
+		Points: append([]Point(nil), pr.points...),
+	}
+
+	var (
+		window = int64(pr.cfg.Window)
+		start  int64
+		end    int64
+	)
+	if n := len(pr.points); n > 0 {
+		start = pr.points[n-1].Offset + int64(pr.cfg.Step)
+		end = pr.points[n-1].Offset + window
+	}
+	if start >= pr.total || end >= pr.total {
+		return p
+	}
+
+	c, _ := NewCounter(pr.cfg.Width)
+	for off := start; off < pr.total; off++ {
+		c.addByte(pr.ring[off%window], 1)
+	}
+	p.Points = append(p.Points, pr.point(c, start, int(pr.total-start)))
+	return p
+}
+
+// ReadProfile reads r until EOF and returns its entropy profile.
+func ReadProfile(r io.Reader, cfg ProfileConfig) (*Profile, error) {
+	pr, err := NewProfiler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(pr, r); err != nil {
+		return nil, err
+	}
+	return pr.Profile(), nil
+}
@@ -0,0 +1,132 @@
+package entropy_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias/entropy"
+)
+
+func TestProfileTumbling(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		data = make([]byte, 10000)
+	)
+	rng.Read(data)
+
+	// a saturated burst in the fourth window
+	for i := 3000; i < 4000; i++ {
+		data[i] = 0xff
+	}
+
+	p, err := entropy.ReadProfile(bytes.NewReader(data), entropy.ProfileConfig{Window: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Step != 1000 || p.Width != 8 || p.Bytes != 10000 || len(p.Points) != 10 {
+		t.Fatalf("unexpected profile: %d bytes, %d points, step %d, width %d", p.Bytes, len(p.Points), p.Step, p.Width)
+	}
+	for i, pt := range p.Points {
+		if pt.Offset != int64(i*1000) || pt.Length != 1000 {
+			t.Fatalf("unexpected point %d: %+v", i, pt)
+		}
+		if i != 3 && (pt.Shannon < 7.7 || pt.Min < 6) {
+			t.Fatalf("unexpected entropy of point %d: %+v", i, pt)
+		}
+	}
+	if min := p.MinPoint(); min.Offset != 3000 || min.Shannon != 0 || min.Min != 0 {
+		t.Fatalf("burst not detected: %+v", min)
+	}
+}
+
+func TestProfileSliding(t *testing.T) {
+	data := biased(2600, 0.7, 1)
+
+	p, err := entropy.ReadProfile(bytes.NewReader(data), entropy.ProfileConfig{
+		Window: 1000,
+		Step:   300,
+		Width:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// complete windows at 0, 300, ..., 1500 and the tail from 1800
+	if len(p.Points) != 7 {
+		t.Fatalf("unexpected number of points: %+v", p.Points)
+	}
+	last := p.Points[6]
+	if last.Offset != 1800 || last.Length != 800 {
+		t.Fatalf("unexpected last point: %+v", last)
+	}
+
+	// each point equals the entropy of its window computed separately
+	for _, pt := range p.Points {
+		window := data[pt.Offset : pt.Offset+int64(pt.Length)]
+		c, _ := entropy.NewCounter(1)
+		c.Write(window)
+		shannon, _ := c.Renyi(entropy.Shannon)
+		min, _ := c.Renyi(entropy.Min)
+		if math.Abs(pt.Shannon-shannon) > 1e-9 || math.Abs(pt.Min-min) > 1e-9 {
+			t.Fatalf("point at %d: got %v %v, want %v %v", pt.Offset, pt.Shannon, pt.Min, shannon, min)
+		}
+		if pt.Min > 0.6 {
+			t.Fatalf("point at %d: unexpected min-entropy %v", pt.Offset, pt.Min)
+		}
+	}
+}
+
+func TestProfileChunked(t *testing.T) {
+	data := biased(5000, 0.6, 2)
+	cfg := entropy.ProfileConfig{Window: 512, Step: 100, Width: 4}
+
+	whole, err := entropy.ReadProfile(bytes.NewReader(data), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := entropy.NewProfiler(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i += 77 {
+		end := i + 77
+		if end > len(data) {
+			end = len(data)
+		}
+		pr.Write(data[i:end])
+	}
+	chunked := pr.Profile()
+	if len(chunked.Points) != len(whole.Points) {
+		t.Fatal("unexpected number of points: ", len(chunked.Points), len(whole.Points))
+	}
+	for i := range whole.Points {
+		if math.Abs(chunked.Points[i].Shannon-whole.Points[i].Shannon) > 1e-9 || chunked.Points[i].Offset != whole.Points[i].Offset {
+			t.Fatalf("point %d differs: %+v %+v", i, chunked.Points[i], whole.Points[i])
+		}
+	}
+}
+
+func TestProfileShortInput(t *testing.T) {
+	p, err := entropy.ReadProfile(bytes.NewReader([]byte{1, 2, 3, 4}), entropy.ProfileConfig{Window: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Points) != 1 || p.Points[0].Length != 4 || p.Points[0].Shannon != 2 {
+		t.Fatalf("unexpected profile: %+v", p.Points)
+	}
+
+	p, err = entropy.ReadProfile(bytes.NewReader(nil), entropy.ProfileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Points) != 0 || p.MinPoint() != nil {
+		t.Fatalf("unexpected profile: %+v", p.Points)
+	}
+
+	if _, err = entropy.NewProfiler(entropy.ProfileConfig{Width: 16}); !errors.Is(err, entropy.ErrInvalidWidth) {
+		t.Fatal("expected ErrInvalidWidth, got ", err)
+	}
+}
@@ -0,0 +1,47 @@
+package debias
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned when using an extractor that has been closed.
+	ErrClosed = errors.New("debias: extractor closed")
+
+	// ErrShortInput is returned when the source ended before any input could be read.
+	ErrShortInput = errors.New("debias: short input")
+
+	// ErrInvalidSize is returned when a block or output size is out of the supported range.
+	ErrInvalidSize = errors.New("debias: invalid size")
+
+	// ErrUnknownMode is returned when no extractor has been registered for a mode.
+	ErrUnknownMode = errors.New("debias: unknown mode")
+
+	// ErrUnknownFormat is returned when a file format set explicitly cannot be decoded.
+	ErrUnknownFormat = errors.New("debias: unknown format")
+
+	// ErrCipher is returned when the cipher used for encrypting the output cannot be initialized.
+	ErrCipher = errors.New("debias: cipher init failed")
+
+	// ErrRandom is returned when reading from crypto/rand fails.
+	ErrRandom = errors.New("debias: reading random data failed")
+)
+
+// SourceError reports a failure to read from the biased source.
+type SourceError struct {
+
+	// Offset is the number of bytes successfully read before the failure.
+	Offset int64
+
+	// Err is the error returned by the source.
+	Err error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("debias: reading source failed at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,126 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+var errBrokenSource = errors.New("broken source")
+
+// brokenReader returns data once and fails afterwards.
+type brokenReader struct {
+	data []byte
+	done bool
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	if b.done {
+		return 0, errBrokenSource
+	}
+	b.done = true
+	return copy(p, b.data), nil
+}
+
+func (b *brokenReader) ReadByte() (byte, error) {
+	if len(b.data) == 0 {
+		return 0, errBrokenSource
+	}
+	c := b.data[0]
+	b.data = b.data[1:]
+	return c, nil
+}
+
+func checkSourceError(t *testing.T, err error, offset int64) {
+	var srcErr *debias.SourceError
+	if !errors.As(err, &srcErr) {
+		t.Fatal("expected a SourceError, got ", err)
+	}
+	if !errors.Is(err, errBrokenSource) {
+		t.Fatal("expected the source error to be wrapped, got ", err)
+	}
+	if srcErr.Offset != offset {
+		t.Fatal("unexpected offset: ", srcErr.Offset)
+	}
+}
+
+func TestPipeSourceError(t *testing.T) {
+	in := bitString("1010101010101010").bytes()
+
+	pr, _, _ := debias.VonNeumann(&brokenReader{data: in}, false)
+	out, err := ioutil.ReadAll(pr)
+	checkSourceError(t, err, int64(len(in)))
+
+	// the output produced before the failure is still delivered
+	if !bytes.HasPrefix(out, bitString("11111111").bytes()) {
+		t.Fatalf("unexpected output: %08b", out)
+	}
+}
+
+func TestReaderSourceError(t *testing.T) {
+	in := bitString("1010101010101010").bytes()
+
+	_, err := ioutil.ReadAll(debias.NewVonNeumann(&brokenReader{data: in}))
+	checkSourceError(t, err, int64(len(in)))
+}
+
+func TestShortInput(t *testing.T) {
+	pr, _, _ := debias.Kaminsky(bytes.NewReader(nil), false, 512)
+	if _, err := ioutil.ReadAll(pr); err != debias.ErrShortInput {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+
+	_, err := debias.NewVonNeumann(bytes.NewReader(nil)).Read(make([]byte, 1))
+	if err != debias.ErrShortInput {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := debias.File(dir, filepath.Join(dir, "missing.bin"), nil, debias.ModeVonNeumann)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected a not exist error, got ", err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "empty.bin"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := debias.Directory(dir, "bin", debias.ModeKaminsky)
+	if !errors.Is(err, debias.ErrShortInput) {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "empty.bin-kaminsky-debiased.bin")); !os.IsNotExist(err) {
+		t.Fatal("expected the partial output to be removed, got ", err)
+	}
+	if len(stats) != 0 {
+		t.Fatal("unexpected stats: ", stats)
+	}
+
+	_, err = debias.Directory(filepath.Join(dir, "missing"), "bin", debias.ModeVonNeumann)
+	if err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
+
+func TestKaminskyBlockSize(t *testing.T) {
+	if _, err := debias.NewKaminsky(bytes.NewReader(nil), 0); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+	if _, err := debias.NewKaminskyWriter(ioutil.Discard, -1); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+
+	pr, _, _ := debias.Kaminsky(bytes.NewReader([]byte{0x99}), false, 0)
+	if _, err := ioutil.ReadAll(pr); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+}
@@ -0,0 +1,135 @@
+package debias
+
+import (
+	"bytes"
+	"io"
+)
+
+// Extractor provides the debiased output for a source of biased data.
+type Extractor interface {
+	io.ReadCloser
+}
+
+// Algorithm is the synchronous core of an extraction algorithm.
+// It keeps the state between calls, so the same implementation can be
+// driven by a Reader, a Writer, or through a pipe by VonNeumann and Kaminsky.
+type Algorithm interface {
+
+	// Process consumes the biased input p and appends completed output to out.
+	Process(p []byte, out *bytes.Buffer) error
+
+	// Flush appends the remaining state to out once the input is exhausted.
+	Flush(out *bytes.Buffer) error
+}
+
+// errExtractor is returned by registered constructors that failed, it returns the error on every Read.
+type errExtractor struct {
+	err error
+}
+
+func (e *errExtractor) Read(p []byte) (int, error) {
+	return 0, e.err
+}
+
+func (e *errExtractor) Close() error {
+	return nil
+}
+
+// Reader is an Extractor that runs an Algorithm over the data read from a source.
+type Reader struct {
+	src io.Reader
+	alg Algorithm
+	buf []byte
+	out bytes.Buffer
+	err error
+
+	// number of bytes read from the source
+	numBytes int64
+
+	// number of bytes returned to the caller
+	bytesOut int64
+}
+
+// NewReader returns a Reader applying alg to the data read from src.
+func NewReader(src io.Reader, alg Algorithm) *Reader {
+	return &Reader{
+		src: src,
+		alg: alg,
+		buf: make([]byte, MaxChunkSize),
+	}
+}
+
+// Read reads debiased data into p.
+// It returns io.EOF after the source is exhausted and all output has been read.
+// Errors from the source are returned as *SourceError once the pending output has been read.
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 && r.err == nil {
+		r.fill()
+	}
+	if r.out.Len() > 0 {
+		n, err := r.out.Read(p)
+		r.bytesOut += int64(n)
+		return n, err
+	}
+	return 0, r.err
+}
+
+// Close releases the Reader, subsequent reads return ErrClosed.
+// Close does not close the underlying source.
+func (r *Reader) Close() error {
+	r.out.Reset()
+	r.err = ErrClosed
+	return nil
+}
+
+// fill reads the next chunk from the source and runs it through the algorithm.
+func (r *Reader) fill() {
+	n, err := r.src.Read(r.buf)
+	r.numBytes += int64(n)
+	if n > 0 {
+		if errProcess := r.alg.Process(r.buf[:n], &r.out); errProcess != nil {
+			r.err = errProcess
+			return
+		}
+	}
+	if err == io.EOF {
+		if r.numBytes == 0 {
+			r.err = ErrShortInput
+			return
+		}
+		if errFlush := r.alg.Flush(&r.out); errFlush != nil {
+			r.err = errFlush
+			return
+		}
+		r.err = io.EOF
+		return
+	}
+	if err != nil {
+		r.err = &SourceError{Offset: r.numBytes, Err: err}
+	}
+}
+
+// SetPadding configures how the algorithm handles a partially filled output byte at the end of the input.
+// It has no effect if the algorithm does not implement Padder.
+func (r *Reader) SetPadding(p Padding) {
+	if pd, ok := r.alg.(Padder); ok {
+		pd.SetPadding(p)
+	}
+}
+
+// BitsOut returns the number of valid output bits produced so far, padding bits are not counted.
+// If the algorithm does not implement BitCounter, all bits of the output bytes are counted.
+func (r *Reader) BitsOut() int64 {
+	if bc, ok := r.alg.(BitCounter); ok {
+		return bc.BitsOut()
+	}
+	return r.bytesOut * 8
+}
+
+// ReportStats adds the details reported by the algorithm to s.
+// It has no effect if the algorithm does not implement StatsReporter.
+func (r *Reader) ReportStats(s *Stats) {
+	if sr, ok := r.alg.(StatsReporter); ok {
+		sr.ReportStats(s)
+	}
+}
@@ -0,0 +1,115 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func TestExtractorVonNeumann(t *testing.T) {
+	for i, te := range tests {
+
+		ex, err := debias.NewExtractor(debias.ModeVonNeumann, bytes.NewReader(bitString(te.in).bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadAll(ex)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(data, bitString(te.out).bytes()) {
+			t.Fatalf("test #%d: expected %s but got %08b", i, te.out, data)
+		}
+
+		// output must be identical to the pipe based implementation
+		pr, _, _ := debias.VonNeumann(bytes.NewBuffer(bitString(te.in).bytes()), false)
+		piped, err := ioutil.ReadAll(pr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, piped) {
+			t.Fatalf("test #%d: extractor output %08b differs from pipe output %08b", i, data, piped)
+		}
+	}
+}
+
+func TestExtractorKaminsky(t *testing.T) {
+	data := bytes.Repeat(bitString("10011001").bytes(), 4096)
+
+	ex, err := debias.NewExtractor(debias.ModeKaminsky, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) == 0 || len(out)%16 != 0 {
+		t.Fatal("expected a multiple of the AES block size, got ", len(out), " bytes")
+	}
+}
+
+func TestExtractorClose(t *testing.T) {
+	ex := debias.NewVonNeumann(bytes.NewReader(bitString("1010101010101010").bytes()))
+
+	err := ex.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ex.Read(make([]byte, 1))
+	if err != debias.ErrClosed {
+		t.Fatal("expected ErrClosed, got ", err)
+	}
+}
+
+// identity passes the input through unchanged.
+type identity struct{}
+
+func (identity) Process(p []byte, out *bytes.Buffer) error {
+	out.Write(p)
+	return nil
+}
+
+func (identity) Flush(out *bytes.Buffer) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	var modeIdentity = debias.Mode(100)
+
+	debias.Register(modeIdentity, "identity", func(r io.Reader) debias.Extractor {
+		return debias.NewReader(r, identity{})
+	})
+
+	if modeIdentity.String() != "identity" {
+		t.Fatal("unexpected mode name: ", modeIdentity.String())
+	}
+	if m, err := debias.ParseMode("identity"); err != nil || m != modeIdentity {
+		t.Fatal("unexpected mode for name identity: ", m, err)
+	}
+	if _, err := debias.ParseMode("unknown"); !errors.Is(err, debias.ErrUnknownMode) {
+		t.Fatal("expected ErrUnknownMode, got ", err)
+	}
+
+	in := []byte("not random at all")
+	s, out := runFile(t, "in.bin", in, modeIdentity)
+	if s.BytesOut != int64(len(in)) {
+		t.Fatal("unexpected number of output bytes: ", s.BytesOut)
+	}
+	if !bytes.Equal(in, out) {
+		t.Fatal("unexpected output: ", out)
+	}
+
+	if _, err := debias.NewExtractor(debias.Mode(101), bytes.NewReader(in)); !errors.Is(err, debias.ErrUnknownMode) {
+		t.Fatal("expected ErrUnknownMode, got ", err)
+	}
+}
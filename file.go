@@ -5,79 +5,216 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/dreadl0ck/debias/bias"
+	"github.com/dreadl0ck/debias/ent"
+	"github.com/dreadl0ck/debias/entropy"
 )
 
+// outputSuffix ends the names of the output files written by File.
+const outputSuffix = "-debiased.bin"
+
 // File will debias a file using the chosen method
-func File(path string, file string, finfo fs.FileInfo, mode Mode) *Stats {
+func File(path string, file string, finfo fs.FileInfo, mode Mode, opts ...Option) (*Stats, error) {
 
 	start := time.Now()
+	o := newOptions(opts)
 
 	inFile, err := os.Open(file)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer inFile.Close()
 
-	reader := bufio.NewReader(inFile)
+	s := &Stats{
+		FileName: finfo.Name(),
+		BytesIn:  finfo.Size(),
+	}
+
+	var in io.Reader = bufio.NewReader(inFile)
+	decoded, err := decode(file, in, o, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	payload := NewCountingReader(decoded)
+	var reader io.Reader = payload
 
 	var (
-		pr  *io.PipeReader
-		out string
+		sampleIn    limitedBuffer
+		sampleOut   limitedBuffer
+		analysisIn  ent.Analyzer
+		analysisOut ent.Analyzer
+		profileIn   *entropy.Profiler
+		profileOut  *entropy.Profiler
+		biasIn      *bias.Analyzer
 	)
-	if mode == ModeKaminsky {
-		pr, _, _ = Kaminsky(reader, false, 512)
-		out = filepath.Join(path, finfo.Name()+"-kaminsky-debiased.bin")
-	} else {
-		pr, _, _ = VonNeumann(reader, false)
-		out = filepath.Join(path, finfo.Name()+"-neumann-debiased.bin")
+	if o.health != nil {
+		reader, err = NewHealthReader(reader, *o.health)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.minEntropy != nil {
+		sampleIn.max = sampleBytes(*o.minEntropy)
+		sampleOut.max = sampleIn.max
+		reader = io.TeeReader(reader, &sampleIn)
+	}
+	if o.analysis {
+		reader = io.TeeReader(reader, &analysisIn)
+	}
+	if o.profile != nil {
+		profileIn, err = entropy.NewProfiler(*o.profile)
+		if err != nil {
+			return nil, err
+		}
+		profileOut, _ = entropy.NewProfiler(*o.profile)
+		reader = io.TeeReader(reader, profileIn)
+	}
+	if o.bias != nil {
+		biasIn, err = bias.NewAnalyzer(*o.bias)
+		if err != nil {
+			return nil, err
+		}
+		reader = io.TeeReader(reader, biasIn)
+	}
+
+	var stage StatsReporter
+	switch {
+	case o.derivation != nil:
+		cfg := *o.derivation
+		if cfg.Channels == 0 {
+			cfg.Channels = payloadChannels(s)
+		}
+		d, err := NewDeriver(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dr := NewDerivingReader(payloadSamples(reader, s), d)
+		reader, stage = dr, dr
+	case o.bitPlanes != nil:
+		planes, err := NewBitPlaneReader(reader, *o.bitPlanes)
+		if err != nil {
+			return nil, err
+		}
+		reader, stage = planes, planes
 	}
 
+	ex, err := NewExtractorConfig(mode, reader, o.extractor)
+	if err != nil {
+		return nil, err
+	}
+	defer ex.Close()
+	if pd, ok := ex.(Padder); ok {
+		pd.SetPadding(o.padding)
+	}
+
+	out := filepath.Join(path, finfo.Name()+"-"+mode.String()+outputSuffix)
+
 	f, err := os.Create(out)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	defer f.Close()
+
+	// a partial output is removed, so it is not mistaken for the output of a complete run
+	complete := false
+	defer func() {
+		if !complete {
+			f.Close()
+			os.Remove(out)
+		}
+	}()
 
 	var numBytesWritten int
 
 	for {
 		var data = make([]byte, MaxChunkSize)
-		n, err := pr.Read(data)
+		n, err := ex.Read(data)
 		if err != nil {
-			if err == io.EOF || err == io.ErrUnexpectedEOF {
-				fmt.Println(err)
+			if err == io.EOF {
 				break
 			}
-			log.Fatal(err)
+			return nil, fmt.Errorf("failed to debias %s: %w", file, err)
 		}
 		data = data[:n]
+		sampleOut.Write(data)
+		if o.analysis {
+			analysisOut.Write(data)
+		}
+		if profileOut != nil {
+			profileOut.Write(data)
+		}
 
 		// write output buffer
 		n, err = f.Write(data)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		numBytesWritten += n
 	}
 
+	bitsOut := int64(numBytesWritten) * 8
+	if bc, ok := ex.(BitCounter); ok {
+		bitsOut = bc.BitsOut()
+	}
+
 	dur := time.Since(start)
-	fmt.Println("wrote", numBytesWritten, "bytes to output file", out, "in", dur)
+	fmt.Fprintln(o.progress, "wrote", numBytesWritten, "bytes to output file", out, "in", dur)
 
 	// close output file handle
 	err = f.Close()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	complete = true
 
-	// return stats to caller
-	return &Stats{
-		FileName: finfo.Name(),
-		BytesIn:  finfo.Size(),
-		BytesOut: int64(numBytesWritten),
-		Duration: dur,
+	if decoded != in {
+		s.BytesIn = payload.Count()
 	}
+	s.BytesOut = int64(numBytesWritten)
+	s.BitsOut = bitsOut
+	s.Duration = dur
+	if sr, ok := ex.(StatsReporter); ok {
+		sr.ReportStats(s)
+	}
+
+	// the extractor only sees the bits selected or derived by the stage
+	if stage != nil {
+		stage.ReportStats(s)
+		if s.BitsUsed > 0 {
+			s.Efficiency = float64(s.BitsOut) / float64(s.BitsUsed)
+		}
+	} else if s.BytesIn > 0 {
+		s.Efficiency = float64(s.BitsOut) / float64(s.BytesIn*8)
+	}
+
+	if o.analysis {
+		s.AnalysisIn = analysisIn.Report()
+		s.AnalysisOut = analysisOut.Report()
+	}
+	if o.profile != nil {
+		s.ProfileIn = profileIn.Profile()
+		s.ProfileOut = profileOut.Profile()
+	}
+	if biasIn != nil {
+		s.Bias = biasIn.Report()
+	}
+
+	if o.minEntropy != nil {
+		s.MinEntropyIn, err = minEntropyPerBit(sampleIn.Bytes(), *o.minEntropy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate min-entropy of %s: %w", file, err)
+		}
+		s.MinEntropyOut, err = minEntropyPerBit(sampleOut.Bytes(), *o.minEntropy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate min-entropy of %s output: %w", file, err)
+		}
+	}
+
+	// return stats to caller
+	return s, nil
 }
@@ -1,25 +1,274 @@
 package debias_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"github.com/dreadl0ck/debias"
-	"log"
+	"io/ioutil"
+	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/bias"
+	"github.com/dreadl0ck/debias/entropy"
+	"github.com/dreadl0ck/debias/iq"
+	"github.com/dreadl0ck/debias/minentropy"
+	"github.com/dreadl0ck/debias/wav"
 )
 
+// runFile writes data to the named file in a temporary directory, debiases it with mode
+// and returns the stats along with the output.
+func runFile(t *testing.T, name string, data []byte, mode debias.Mode, opts ...debias.Option) (*debias.Stats, []byte) {
+	t.Helper()
+	var (
+		dir  = t.TempDir()
+		file = filepath.Join(dir, name)
+	)
+	err := ioutil.WriteFile(file, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := debias.File(dir, file, fi, mode, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadFile(filepath.Join(dir, name+"-"+mode.String()+"-debiased.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, out
+}
+
 func TestReadFile(t *testing.T) {
 	file := "data/data-2200000000.wav"
 	fi, err := os.Stat(file)
 	if err != nil {
-		log.Fatal(err)
+		if os.IsNotExist(err) {
+			t.Skip("capture not available: ", file)
+		}
+		t.Fatal(err)
 	}
 
 	start := time.Now()
-	s := debias.File("data", file, fi, debias.ModeVonNeumann)
-	if s == nil {
+	s, err := debias.File("data", file, fi, debias.ModeVonNeumann)
+	if err != nil {
 		t.Fatal(err)
 	}
+	if s == nil {
+		t.Fatal("no stats returned")
+	}
 	fmt.Println(time.Since(start))
 }
+
+func TestFileMinEntropy(t *testing.T) {
+	// p = 0.8: the min-entropy is -log2(0.8) = 0.32 bits per bit
+	data := biased(50000, 0.8, 1)
+
+	s, _ := runFile(t, "in.bin", data, debias.ModeVonNeumann, debias.WithMinEntropy(minentropy.Config{
+		Width:      1,
+		MaxSamples: 100000,
+	}))
+	if s.MinEntropyIn < 0.15 || s.MinEntropyIn > 0.33 {
+		t.Fatal("unexpected min-entropy of the input: ", s.MinEntropyIn)
+	}
+	if s.MinEntropyOut < 0.7 {
+		t.Fatal("unexpected min-entropy of the output: ", s.MinEntropyOut)
+	}
+
+	s, _ = runFile(t, "in.bin", data, debias.ModeVonNeumann)
+	if s.MinEntropyIn != 0 || s.MinEntropyOut != 0 {
+		t.Fatal("unexpected min-entropy without the option")
+	}
+}
+
+func TestFileAnalysis(t *testing.T) {
+	s, _ := runFile(t, "in.bin", biased(50000, 0.8, 1), debias.ModeVonNeumann, debias.WithAnalysis())
+	if s.AnalysisIn == nil || s.AnalysisOut == nil {
+		t.Fatal("missing analysis")
+	}
+	if s.AnalysisIn.Bytes != s.BytesIn || s.AnalysisOut.Bytes != s.BytesOut {
+		t.Fatal("unexpected analysis size: ", s.AnalysisIn.Bytes, s.AnalysisOut.Bytes)
+	}
+	if s.AnalysisOut.Entropy <= s.AnalysisIn.Entropy {
+		t.Fatal("output entropy not above input entropy: ", s.AnalysisIn.Entropy, s.AnalysisOut.Entropy)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded debias.Stats
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if *decoded.AnalysisOut != *s.AnalysisOut {
+		t.Fatal("analysis changed by JSON round trip")
+	}
+}
+
+func TestFileEntropyProfile(t *testing.T) {
+	s, _ := runFile(t, "in.bin", biased(50000, 0.8, 1), debias.ModeVonNeumann, debias.WithEntropyProfile(entropy.ProfileConfig{
+		Window: 4096,
+	}))
+	if s.ProfileIn == nil || s.ProfileOut == nil {
+		t.Fatal("missing profile")
+	}
+	if s.ProfileIn.Bytes != s.BytesIn || s.ProfileOut.Bytes != s.BytesOut {
+		t.Fatal("unexpected profile size: ", s.ProfileIn.Bytes, s.ProfileOut.Bytes)
+	}
+	if len(s.ProfileIn.Points) != 13 || len(s.ProfileOut.Points) != 2 {
+		t.Fatal("unexpected number of points: ", len(s.ProfileIn.Points), len(s.ProfileOut.Points))
+	}
+	if s.ProfileOut.MinPoint().Min <= s.ProfileIn.Points[0].Min {
+		t.Fatal("output min-entropy not above input min-entropy")
+	}
+}
+
+func TestFileBias(t *testing.T) {
+	s, _ := runFile(t, "in.bin", biased(50000, 0.8, 1), debias.ModeVonNeumann, debias.WithBias(bias.DefaultConfig))
+	if s.Bias == nil {
+		t.Fatal("missing bias")
+	}
+	if math.Abs(s.Bias.OnesRatio-0.8) > 0.01 {
+		t.Fatal("unexpected ones ratio: ", s.Bias.OnesRatio)
+	}
+
+	// the input bits are independent, so Von Neumann achieves the predicted yield
+	if math.Abs(s.Efficiency-s.Bias.PredictedYield) > 0.005 {
+		t.Fatalf("efficiency %v differs from predicted yield %v", s.Efficiency, s.Bias.PredictedYield)
+	}
+}
+
+func TestFileWAV(t *testing.T) {
+	var (
+		payload = biased(40000, 0.8, 1)
+		buf     bytes.Buffer
+	)
+
+	// 16 bit stereo PCM, preceded by a metadata chunk that must not reach the extractor
+	le := binary.LittleEndian
+	buf.WriteString("RIFF")
+	binary.Write(&buf, le, uint32(4+8+16+8+16+8+len(payload)))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []interface{}{uint32(16), uint16(1), uint16(2), uint32(44100), uint32(44100 * 4), uint16(4), uint16(16)} {
+		binary.Write(&buf, le, v)
+	}
+	buf.WriteString("LIST")
+	binary.Write(&buf, le, uint32(16))
+	buf.Write(bytes.Repeat([]byte{0xaa}, 16))
+	buf.WriteString("data")
+	binary.Write(&buf, le, uint32(len(payload)))
+	buf.Write(payload)
+
+	s, _ := runFile(t, "capture.wav", buf.Bytes(), debias.ModeVonNeumann, debias.WithWAV(wav.Config{Channels: []int{1}}))
+	if s.WAV == nil || s.WAV.Channels[0] != 1 || s.WAV.Header.Channels != 2 || s.WAV.SampleRate != 44100 {
+		t.Fatalf("unexpected WAV info: %+v", s.WAV)
+	}
+	if s.BytesIn != int64(len(payload)/2) {
+		t.Fatal("unexpected number of input bytes: ", s.BytesIn)
+	}
+
+	s, _ = runFile(t, "capture.wav", buf.Bytes(), debias.ModeVonNeumann, debias.WithRaw())
+	if s.WAV != nil || s.BytesIn != int64(buf.Len()) {
+		t.Fatal("unexpected stats for raw processing: ", s.BytesIn)
+	}
+}
+
+func TestFileBitPlanes(t *testing.T) {
+	var (
+		rng = rand.New(rand.NewSource(1))
+		buf bytes.Buffer
+	)
+
+	// a strong signal in the upper bits with a random least significant bit
+	for i := 0; i < 20000; i++ {
+		binary.Write(&buf, binary.LittleEndian, int16(i%64)<<8|int16(rng.Intn(2)))
+	}
+	s, _ := runFile(t, "in.cs16", buf.Bytes(), debias.ModeVonNeumann, debias.WithIQ(iq.Config{Component: iq.InPhase}), debias.WithBitPlanes(debias.BitPlaneConfig{SampleBits: 16}))
+	if s.BytesIn != 20000 || s.BitsUsed != 10000/8*8 {
+		t.Fatalf("unexpected input: %d bytes, %d bits used", s.BytesIn, s.BitsUsed)
+	}
+
+	// Von Neumann yields a quarter of the random input bits
+	if s.BitsOut < s.BitsUsed/4-200 || s.BitsOut > s.BitsUsed/4+200 {
+		t.Fatal("unexpected number of output bits: ", s.BitsOut)
+	}
+	if s.Efficiency != float64(s.BitsOut)/float64(s.BitsUsed) {
+		t.Fatal("efficiency is not relative to the bits used: ", s.Efficiency)
+	}
+}
+
+func TestFilePadding(t *testing.T) {
+	data := biased(1000, 0.7, 3)
+
+	padded, out := runFile(t, "in.bin", data, debias.ModeVonNeumann)
+	if padded.BitsOut%8 == 0 {
+		t.Fatal("the output fills complete bytes: ", padded.BitsOut)
+	}
+	if padded.BytesOut != (padded.BitsOut+7)/8 {
+		t.Fatalf("expected the partial byte to be padded: %d bytes for %d bits", padded.BytesOut, padded.BitsOut)
+	}
+
+	dropped, dropOut := runFile(t, "in.bin", data, debias.ModeVonNeumann, debias.WithPadding(debias.DropPartial))
+	if dropped.BitsOut != padded.BitsOut/8*8 || dropped.BytesOut != padded.BitsOut/8 {
+		t.Fatalf("expected the partial byte to be dropped: %d bytes for %d bits", dropped.BytesOut, dropped.BitsOut)
+	}
+	if !bytes.Equal(dropOut, out[:len(dropOut)]) {
+		t.Fatal("output differs from the padded output")
+	}
+}
+
+func TestFileExplicitIQFormat(t *testing.T) {
+	// the format set explicitly applies to files of any extension
+	s, _ := runFile(t, "capture.bin", biased(20000, 0.8, 1), debias.ModeVonNeumann, debias.WithIQ(iq.Config{
+		Format:    iq.CU8,
+		Component: iq.InPhase,
+	}))
+	if s.IQ == nil || s.IQ.Format != iq.CU8 || s.BytesIn != 10000 {
+		t.Fatalf("unexpected stats: %+v, %d bytes", s.IQ, s.BytesIn)
+	}
+
+	// the format overrides the extension
+	s, _ = runFile(t, "capture.cs16", biased(20000, 0.8, 1), debias.ModeVonNeumann, debias.WithIQ(iq.Config{
+		Format:    iq.CU8,
+		Component: iq.Quadrature,
+	}))
+	if s.IQ == nil || s.IQ.Format != iq.CU8 || s.BytesIn != 10000 {
+		t.Fatalf("unexpected stats: %+v, %d bytes", s.IQ, s.BytesIn)
+	}
+}
+
+func TestFileDerivation(t *testing.T) {
+	var (
+		rng = rand.New(rand.NewSource(1))
+		buf bytes.Buffer
+	)
+
+	for i := 0; i < 20000; i++ {
+		binary.Write(&buf, binary.LittleEndian, int16(rng.NormFloat64()*1000))
+	}
+	s, _ := runFile(t, "in.cs16", buf.Bytes(), debias.ModeVonNeumann, debias.WithDerivation(debias.DerivationConfig{
+		Strategy: debias.ZeroCrossingParity,
+	}))
+	if s.Derivation != "zero-crossing-parity" || s.BytesIn != 40000 {
+		t.Fatalf("unexpected stats: %s, %d bytes", s.Derivation, s.BytesIn)
+	}
+
+	// I and Q are derived separately, about half of the values of each component cross zero
+	if s.BitsUsed < 8000 || s.BitsUsed > 12000 {
+		t.Fatal("unexpected number of derived bits: ", s.BitsUsed)
+	}
+	if s.BitsOut == 0 || s.BitsOut > s.BitsUsed/2 {
+		t.Fatal("unexpected number of output bits: ", s.BitsOut)
+	}
+}
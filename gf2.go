@@ -0,0 +1,135 @@
+package debias
+
+// gfElement is an element of GF(2^l) for l <= 128, stored as the high and low 64 bits.
+type gfElement struct {
+	hi, lo uint64
+}
+
+// gfField implements arithmetic in GF(2^l) for the supported sizes of 32, 64 and 128 bits.
+type gfField struct {
+	bits int
+
+	// low terms of the irreducible polynomial, the x^l term is implied
+	poly uint64
+}
+
+// gfFields are the supported binary fields, ordered by size.
+var gfFields = []gfField{
+	// x^32 + x^7 + x^3 + x^2 + 1
+	{bits: 32, poly: 0x8d},
+	// x^64 + x^4 + x^3 + x + 1
+	{bits: 64, poly: 0x1b},
+	// x^128 + x^7 + x^2 + x + 1
+	{bits: 128, poly: 0x87},
+}
+
+// mul returns a * b.
+func (f gfField) mul(a, b gfElement) gfElement {
+	var r gfElement
+	for i := 0; i < f.bits; i++ {
+		var set bool
+		if i < 64 {
+			set = (b.lo>>uint(i))&0x01 == 1
+		} else {
+			set = (b.hi>>uint(i-64))&0x01 == 1
+		}
+		if set {
+			r.hi ^= a.hi
+			r.lo ^= a.lo
+		}
+		a = f.mulX(a)
+	}
+	return r
+}
+
+// mulX returns a * x.
+func (f gfField) mulX(a gfElement) gfElement {
+	var carry uint64
+	if f.bits == 128 {
+		carry = a.hi >> 63
+		a.hi = a.hi<<1 | a.lo>>63
+		a.lo <<= 1
+	} else {
+		carry = (a.lo >> uint(f.bits-1)) & 0x01
+		a.lo <<= 1
+		if f.bits < 64 {
+			a.lo &= 1<<uint(f.bits) - 1
+		}
+	}
+	if carry == 1 {
+		a.lo ^= f.poly
+	}
+	return a
+}
+
+// gfTable evaluates a linear map of GF(2^l) over GF(2) with a table for every four bits of the argument.
+type gfTable [][16]gfElement
+
+// newGFTable returns the table for the linear map with the images of the basis elements x^j.
+func newGFTable(images []gfElement) gfTable {
+	t := make(gfTable, len(images)/4)
+	for p := range t {
+		for b := 0; b < 4; b++ {
+			img := images[4*p+b]
+			for v := 1 << uint(b); v < 1<<uint(b+1); v++ {
+				prev := t[p][v-1<<uint(b)]
+				t[p][v] = gfElement{hi: prev.hi ^ img.hi, lo: prev.lo ^ img.lo}
+			}
+		}
+	}
+	return t
+}
+
+// apply returns the image of e.
+func (t gfTable) apply(e gfElement) gfElement {
+	var r gfElement
+	for p := range t {
+		var v uint64
+		if p < 16 {
+			v = e.lo >> uint(4*p) & 0x0f
+		} else {
+			v = e.hi >> uint(4*(p-16)) & 0x0f
+		}
+		r.hi ^= t[p][v].hi
+		r.lo ^= t[p][v].lo
+	}
+	return r
+}
+
+// gfSmall implements arithmetic in GF(2^k) for k <= 20.
+type gfSmall struct {
+	bits uint
+	poly uint32
+}
+
+// gfSmallPolys are irreducible polynomials for GF(2^k), including the x^k term.
+var gfSmallPolys = []uint32{
+	0, 0x3, 0x7, 0xb, 0x13, 0x25, 0x43, 0x83, 0x11d, 0x211, 0x409,
+	0x805, 0x1053, 0x201b, 0x4443, 0x8003, 0x1100b, 0x20009, 0x40081, 0x80027, 0x100009,
+}
+
+// mul returns a * b.
+func (f gfSmall) mul(a, b uint32) uint32 {
+	var r uint32
+	for b != 0 {
+		if b&0x01 == 1 {
+			r ^= a
+		}
+		b >>= 1
+		a <<= 1
+		if a>>f.bits == 1 {
+			a ^= f.poly
+		}
+	}
+	return r
+}
+
+// gfFromBits builds a field element from bits, one bit per byte, most significant bit first.
+func gfFromBits(bits []byte) gfElement {
+	var e gfElement
+	for _, b := range bits {
+		e.hi = e.hi<<1 | e.lo>>63
+		e.lo = e.lo<<1 | uint64(b)
+	}
+	return e
+}
@@ -0,0 +1,254 @@
+package debias
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrHealth is matched by the errors of failed health tests, use errors.As with *HealthError for the details.
+var ErrHealth = errors.New("debias: health test failed")
+
+// DefaultHealthFalsePositive is the default probability of a false alarm per test of a healthy source,
+// SP 800-90B recommends values from 2^-20 to 2^-40.
+var DefaultHealthFalsePositive = math.Pow(2, -30)
+
+// Names of the health tests.
+const (
+	RepetitionCount    = "repetition count"
+	AdaptiveProportion = "adaptive proportion"
+)
+
+// HealthError reports the failure of a continuous health test.
+type HealthError struct {
+
+	// Test is the name of the failed test.
+	Test string
+
+	// Sample is the index of the sample that triggered the failure.
+	Sample int64
+
+	// Count is the number of repetitions or occurrences, which reached Cutoff.
+	Count  int
+	Cutoff int
+}
+
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("debias: %s test failed at sample %d: count %d reached cutoff %d", e.Test, e.Sample, e.Count, e.Cutoff)
+}
+
+func (e *HealthError) Unwrap() error {
+	return ErrHealth
+}
+
+// HealthConfig holds the parameters of the continuous health tests.
+type HealthConfig struct {
+
+	// Width is the number of bits per sample from 1 to 8, zero means 8.
+	Width int
+
+	// MinEntropy is the assessed min-entropy per sample H, the cutoffs are derived from it.
+	MinEntropy float64
+
+	// FalsePositive is the probability α of a false alarm, zero uses DefaultHealthFalsePositive.
+	FalsePositive float64
+
+	// RepetitionCutoff and ProportionCutoff override the derived cutoffs if they are set.
+	RepetitionCutoff int
+	ProportionCutoff int
+
+	// OnFailure is called for every failure if set, and the data is passed on.
+	// Otherwise reading stops with the *HealthError.
+	OnFailure func(err *HealthError)
+}
+
+// proportionWindow returns the window size W of the adaptive proportion test.
+func proportionWindow(width int) int {
+	if width == 1 {
+		return 1024
+	}
+	return 512
+}
+
+// Cutoffs returns the cutoffs of the repetition count and adaptive proportion tests,
+// as described in SP 800-90B section 4.4.
+func (c HealthConfig) Cutoffs() (int, int) {
+	c = c.withDefaults()
+
+	rct := c.RepetitionCutoff
+	if rct == 0 {
+		rct = 1 + int(math.Ceil(-math.Log2(c.FalsePositive)/c.MinEntropy))
+	}
+
+	apt := c.ProportionCutoff
+	if apt == 0 {
+		apt = 1 + critBinom(proportionWindow(c.Width), math.Pow(2, -c.MinEntropy), 1-c.FalsePositive)
+	}
+	return rct, apt
+}
+
+func (c HealthConfig) withDefaults() HealthConfig {
+	if c.Width == 0 {
+		c.Width = 8
+	}
+	if c.FalsePositive == 0 {
+		c.FalsePositive = DefaultHealthFalsePositive
+	}
+	return c
+}
+
+// critBinom returns the smallest k for which the binomial distribution with n trials
+// of probability p has a cumulative probability of at least alpha.
+func critBinom(n int, p, alpha float64) int {
+	if p >= 1 {
+		return n
+	}
+	var (
+		lgN, _ = math.Lgamma(float64(n + 1))
+		sum    float64
+	)
+	for k := 0; k < n; k++ {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		sum += math.Exp(lgN - lgK - lgNK + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p))
+		if sum >= alpha {
+			return k
+		}
+	}
+	return n
+}
+
+// HealthReader runs the continuous health tests of SP 800-90B section 4.4 on the samples read from a source.
+// When a test fails, the data before the byte holding the failing sample is returned
+// and the next read returns the *HealthError, unless a callback has been configured.
+type HealthReader struct {
+	src io.Reader
+	cfg HealthConfig
+	err error
+
+	rctCutoff, aptCutoff int
+	window               int
+
+	// current sample
+	sample   int
+	bits     int
+	nSamples int64
+
+	// repetition count test
+	rctValue, rctCount int
+
+	// adaptive proportion test
+	aptValue, aptCount, aptIndex int
+
+	failures int
+}
+
+// NewHealthReader returns a HealthReader testing the samples read from src.
+func NewHealthReader(src io.Reader, cfg HealthConfig) (*HealthReader, error) {
+	cfg = cfg.withDefaults()
+	if cfg.Width < 1 || cfg.Width > 8 {
+		return nil, fmt.Errorf("%w: sample width %d", ErrInvalidSize, cfg.Width)
+	}
+	if cfg.MinEntropy <= 0 || cfg.MinEntropy > float64(cfg.Width) {
+		return nil, fmt.Errorf("debias: min-entropy %g out of range for samples of %d bits", cfg.MinEntropy, cfg.Width)
+	}
+
+	h := &HealthReader{
+		src:    src,
+		cfg:    cfg,
+		window: proportionWindow(cfg.Width),
+	}
+	h.rctCutoff, h.aptCutoff = cfg.Cutoffs()
+	return h, nil
+}
+
+// Read reads from the source and tests the samples.
+func (h *HealthReader) Read(p []byte) (int, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+
+	n, err := h.src.Read(p)
+	for i, b := range p[:n] {
+		failures := h.testByte(b)
+		if len(failures) == 0 {
+			continue
+		}
+		if h.cfg.OnFailure == nil {
+			h.err = failures[0]
+			return i, nil
+		}
+		for _, f := range failures {
+			h.cfg.OnFailure(f)
+		}
+	}
+	return n, err
+}
+
+// Failures returns the number of failures, including those passed to the callback.
+func (h *HealthReader) Failures() int {
+	return h.failures
+}
+
+// Samples returns the number of tested samples.
+func (h *HealthReader) Samples() int64 {
+	return h.nSamples
+}
+
+// testByte splits b into samples and tests them.
+func (h *HealthReader) testByte(b byte) []*HealthError {
+	var failures []*HealthError
+	for i := 7; i >= 0; i-- {
+		h.sample = h.sample<<1 | int(b>>uint(i))&0x01
+		h.bits++
+		if h.bits < h.cfg.Width {
+			continue
+		}
+		failures = append(failures, h.test(h.sample)...)
+		h.sample, h.bits = 0, 0
+	}
+	return failures
+}
+
+// test runs both tests on the next sample.
+func (h *HealthReader) test(v int) []*HealthError {
+	var failures []*HealthError
+
+	// repetition count test
+	if h.nSamples > 0 && v == h.rctValue {
+		h.rctCount++
+		if h.rctCount >= h.rctCutoff {
+			failures = append(failures, h.fail(RepetitionCount, h.rctCount, h.rctCutoff))
+
+			// restart the count, so a stuck source is reported once per cutoff
+			h.rctCount = 1
+		}
+	} else {
+		h.rctValue, h.rctCount = v, 1
+	}
+
+	// adaptive proportion test
+	if h.aptIndex == 0 {
+		h.aptValue, h.aptCount = v, 1
+	} else if v == h.aptValue {
+		h.aptCount++
+		if h.aptCount == h.aptCutoff {
+			failures = append(failures, h.fail(AdaptiveProportion, h.aptCount, h.aptCutoff))
+		}
+	}
+	h.aptIndex = (h.aptIndex + 1) % h.window
+
+	h.nSamples++
+	return failures
+}
+
+func (h *HealthReader) fail(test string, count, cutoff int) *HealthError {
+	h.failures++
+	return &HealthError{
+		Test:   test,
+		Sample: h.nSamples,
+		Count:  count,
+		Cutoff: cutoff,
+	}
+}
@@ -0,0 +1,142 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func TestHealthCutoffs(t *testing.T) {
+	// the example of SP 800-90B section 4.4.1
+	rct, _ := debias.HealthConfig{MinEntropy: 2, FalsePositive: 1.0 / (1 << 20)}.Cutoffs()
+	if rct != 11 {
+		t.Fatal("expected a repetition count cutoff of 11, got ", rct)
+	}
+
+	// a binary source with full entropy: 512 expected occurrences with a standard deviation of 16
+	_, apt := debias.HealthConfig{Width: 1, MinEntropy: 1, FalsePositive: 1.0 / (1 << 20)}.Cutoffs()
+	if apt < 570 || apt > 600 {
+		t.Fatal("unexpected adaptive proportion cutoff: ", apt)
+	}
+
+	var last int
+	for _, h := range []float64{0.5, 1, 2, 4, 8} {
+		_, apt := debias.HealthConfig{MinEntropy: h}.Cutoffs()
+		if last != 0 && apt >= last {
+			t.Fatalf("expected the cutoff to decrease with the min-entropy, got %d after %d", apt, last)
+		}
+		last = apt
+	}
+}
+
+func TestHealthReader(t *testing.T) {
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	h, err := debias.NewHealthReader(bytes.NewReader(data), debias.HealthConfig{MinEntropy: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) || h.Failures() != 0 || h.Samples() != 100000 {
+		t.Fatal("expected the data to pass, failures: ", h.Failures())
+	}
+
+	// a stuck source
+	stuck := append(append([]byte{}, data[:5000]...), make([]byte, 100)...)
+	h, err = debias.NewHealthReader(bytes.NewReader(stuck), debias.HealthConfig{MinEntropy: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ioutil.ReadAll(h)
+
+	var he *debias.HealthError
+	if !errors.As(err, &he) || he.Test != debias.RepetitionCount {
+		t.Fatal("expected a repetition count failure, got ", err)
+	}
+
+	// the cutoff of 1 + ceil(30 / 7) = 6 is reached by the sixth zero
+	if he.Cutoff != 6 || he.Sample != 5005 || len(out) != 5005 {
+		t.Fatalf("unexpected failure %v after %d bytes", he, len(out))
+	}
+}
+
+func TestHealthExtractor(t *testing.T) {
+	data := make([]byte, 20000)
+	rand.New(rand.NewSource(2)).Read(data)
+	for i := 10000; i < 11000; i++ {
+		data[i] = 0xff
+	}
+
+	h, err := debias.NewHealthReader(bytes.NewReader(data), debias.HealthConfig{Width: 1, MinEntropy: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(debias.NewVonNeumann(h))
+	if !errors.Is(err, debias.ErrHealth) {
+		t.Fatal("expected a health test failure, got ", err)
+	}
+	var srcErr *debias.SourceError
+	if !errors.As(err, &srcErr) {
+		t.Fatal("expected a SourceError, got ", err)
+	}
+}
+
+func TestHealthCallback(t *testing.T) {
+	// every second sample is zero, without repetitions
+	var (
+		rnd  = rand.New(rand.NewSource(3))
+		data = make([]byte, 10000)
+	)
+	for i := 1; i < len(data); i += 2 {
+		data[i] = byte(1 + rnd.Intn(255))
+	}
+
+	var failures []*debias.HealthError
+	h, err := debias.NewHealthReader(bytes.NewReader(data), debias.HealthConfig{
+		MinEntropy: 6,
+		OnFailure: func(err *debias.HealthError) {
+			failures = append(failures, err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatal("expected the data to be passed on")
+	}
+
+	// one failure per window of 512 samples starting with zero, including the incomplete last window
+	if len(failures) != 20 || h.Failures() != 20 {
+		t.Fatal("expected 20 failures, got ", len(failures))
+	}
+	for _, f := range failures {
+		if f.Test != debias.AdaptiveProportion {
+			t.Fatal("unexpected failure: ", f)
+		}
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	for _, cfg := range []debias.HealthConfig{
+		{},
+		{MinEntropy: 9},
+		{Width: 1, MinEntropy: 2},
+		{Width: 9, MinEntropy: 1},
+	} {
+		if _, err := debias.NewHealthReader(bytes.NewReader(nil), cfg); err == nil {
+			t.Fatalf("expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Package sample decodes numeric sample values from a stream of fixed size samples.
+package sample
+
+import "io"
+
+// Decoder decodes samples of a fixed size read from a byte stream.
+type Decoder struct {
+	r      io.Reader
+	size   int
+	decode func(b []byte) float64
+	buf    []byte
+}
+
+// NewDecoder returns a Decoder reading samples of size bytes from r and decoding each with decode.
+func NewDecoder(r io.Reader, size int, decode func(b []byte) float64) *Decoder {
+	return &Decoder{
+		r:      r,
+		size:   size,
+		decode: decode,
+	}
+}
+
+// ReadSamples decodes up to len(dst) samples into dst and returns their number.
+// A trailing partial sample is dropped.
+func (d *Decoder) ReadSamples(dst []float64) (int, error) {
+	if cap(d.buf) < len(dst)*d.size {
+		d.buf = make([]byte, len(dst)*d.size)
+	}
+	buf := d.buf[:len(dst)*d.size]
+	n, err := io.ReadFull(d.r, buf)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	n /= d.size
+	for i := 0; i < n; i++ {
+		dst[i] = d.decode(buf[i*d.size : (i+1)*d.size])
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, err
+}
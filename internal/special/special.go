@@ -0,0 +1,106 @@
+// Package special implements the special functions used by the statistical tests.
+package special
+
+import "math"
+
+// constants of the Cephes implementation of the incomplete gamma function
+const (
+	machEp = 1.11022302462515654042e-16
+	maxLog = 7.09782712893383996843e2
+	big    = 4.503599627370496e15
+	bigInv = 2.22044604925031308085e-16
+)
+
+// Igamc is the regularized upper incomplete gamma function Q(a, x).
+func Igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	if x < 1 || x < a {
+		return 1 - Igam(a, x)
+	}
+
+	lg, _ := math.Lgamma(a)
+	ax := a*math.Log(x) - x - lg
+	if ax < -maxLog {
+		return 0
+	}
+	ax = math.Exp(ax)
+
+	// continued fraction
+	var (
+		y     = 1 - a
+		z     = x + y + 1
+		c     = 0.0
+		pkm2  = 1.0
+		qkm2  = x
+		pkm1  = x + 1
+		qkm1  = z * x
+		ans   = pkm1 / qkm1
+		t     float64
+		pk, q float64
+	)
+	for {
+		c++
+		y++
+		z += 2
+		yc := y * c
+		pk = pkm1*z - pkm2*yc
+		q = qkm1*z - qkm2*yc
+		if q != 0 {
+			r := pk / q
+			t = math.Abs((ans - r) / r)
+			ans = r
+		} else {
+			t = 1
+		}
+		pkm2, pkm1 = pkm1, pk
+		qkm2, qkm1 = qkm1, q
+		if math.Abs(pk) > big {
+			pkm2 *= bigInv
+			pkm1 *= bigInv
+			qkm2 *= bigInv
+			qkm1 *= bigInv
+		}
+		if t <= machEp {
+			return ans * ax
+		}
+	}
+}
+
+// Igam is the regularized lower incomplete gamma function P(a, x).
+func Igam(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 0
+	}
+	if x > 1 && x > a {
+		return 1 - Igamc(a, x)
+	}
+
+	lg, _ := math.Lgamma(a)
+	ax := a*math.Log(x) - x - lg
+	if ax < -maxLog {
+		return 0
+	}
+	ax = math.Exp(ax)
+
+	// power series
+	var (
+		r   = a
+		c   = 1.0
+		ans = 1.0
+	)
+	for {
+		r++
+		c *= x / r
+		ans += c
+		if c/ans <= machEp {
+			return ans * ax / a
+		}
+	}
+}
+
+// Normal is the cumulative distribution function of the standard normal distribution.
+func Normal(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
@@ -0,0 +1,288 @@
+// Package iq decodes raw interleaved IQ captures of software defined radios,
+// as written by rtl_sdr (unsigned 8 bit), hackrf_transfer (signed 8 bit) and other tools (signed 16 bit, complex float32).
+//
+// The Reader passes on the in-phase or quadrature component, both, or the magnitude or phase of each sample.
+// Components are passed on as stored, little endian for multi-byte formats,
+// magnitude and phase are computed and written as little endian float32.
+package iq
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/dreadl0ck/debias/internal/sample"
+)
+
+var (
+	// ErrFormat is returned for unknown sample formats.
+	ErrFormat = errors.New("iq: unknown sample format")
+
+	// ErrComponent is returned for unknown components.
+	ErrComponent = errors.New("iq: unknown component")
+)
+
+// Format is the encoding of the I and Q values of a sample.
+type Format int
+
+const (
+	// CU8 is unsigned 8 bit, centered at 127.5, as written by rtl_sdr.
+	CU8 Format = iota + 1
+
+	// CS8 is signed 8 bit, as written by hackrf_transfer.
+	CS8
+
+	// CS16 is signed 16 bit little endian.
+	CS16
+
+	// CF32 is 32 bit little endian IEEE float.
+	CF32
+)
+
+var formatNames = map[Format]string{
+	CU8:  "cu8",
+	CS8:  "cs8",
+	CS16: "cs16",
+	CF32: "cf32",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// MarshalText encodes the format by its name.
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText decodes a format from its name.
+func (f *Format) UnmarshalText(text []byte) error {
+	format, ok := FormatFromExt(string(text))
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrFormat, text)
+	}
+	*f = format
+	return nil
+}
+
+// Size returns the number of bytes of one I or Q value.
+func (f Format) Size() int {
+	switch f {
+	case CS16:
+		return 2
+	case CF32:
+		return 4
+	}
+	return 1
+}
+
+// FormatFromExt returns the format for a file extension like ".cu8", it reports false for unknown extensions.
+func FormatFromExt(ext string) (Format, bool) {
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+	for f, name := range formatNames {
+		if name == ext {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// Component selects the part of each sample passed on.
+type Component int
+
+const (
+	// Interleaved passes on the I and Q values of each sample.
+	Interleaved Component = iota
+
+	// InPhase passes on the I values.
+	InPhase
+
+	// Quadrature passes on the Q values.
+	Quadrature
+
+	// Magnitude passes on the magnitude of each sample.
+	Magnitude
+
+	// Phase passes on the phase of each sample in radians.
+	Phase
+)
+
+var componentNames = []string{"interleaved", "i", "q", "magnitude", "phase"}
+
+func (c Component) String() string {
+	if c >= 0 && int(c) < len(componentNames) {
+		return componentNames[c]
+	}
+	return fmt.Sprintf("Component(%d)", int(c))
+}
+
+// MarshalText encodes the component by its name.
+func (c Component) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a component from its name.
+func (c *Component) UnmarshalText(text []byte) error {
+	for i, name := range componentNames {
+		if name == string(text) {
+			*c = Component(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrComponent, text)
+}
+
+// Config selects the sample format and the component passed on.
+type Config struct {
+	Format    Format    `json:"format"`
+	Component Component `json:"component"`
+}
+
+// Reader decodes IQ samples read from a source.
+type Reader struct {
+	src io.Reader
+	cfg Config
+
+	sample []byte
+	out    []byte
+	buf    []byte
+	err    error
+
+	// decoder of the values passed on by Read, created by ReadSamples
+	values *sample.Decoder
+
+	// number of complete samples read
+	samples int64
+}
+
+// NewReader returns a Reader decoding the samples read from r.
+func NewReader(r io.Reader, cfg Config) (*Reader, error) {
+	if _, ok := formatNames[cfg.Format]; !ok {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, cfg.Format)
+	}
+	if cfg.Component < Interleaved || cfg.Component > Phase {
+		return nil, fmt.Errorf("%w: %v", ErrComponent, cfg.Component)
+	}
+	return &Reader{
+		src:    r,
+		cfg:    cfg,
+		sample: make([]byte, 2*cfg.Format.Size()),
+	}, nil
+}
+
+// Config returns the configuration of the reader.
+func (r *Reader) Config() Config {
+	return r.cfg
+}
+
+// Samples returns the number of complete samples read from the source.
+func (r *Reader) Samples() int64 {
+	return r.samples
+}
+
+// Read reads the selected component of the samples into p.
+// A trailing partial sample is dropped.
+func (r *Reader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(r.out) == 0 {
+			if r.err != nil {
+				break
+			}
+			r.next()
+			continue
+		}
+		c := copy(p[n:], r.out)
+		r.out = r.out[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// next reads one sample and queues its selected component.
+func (r *Reader) next() {
+	if _, err := io.ReadFull(r.src, r.sample); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.err = err
+		return
+	}
+	r.samples++
+
+	size := r.cfg.Format.Size()
+	switch r.cfg.Component {
+	case Interleaved:
+		r.out = r.sample
+	case InPhase:
+		r.out = r.sample[:size]
+	case Quadrature:
+		r.out = r.sample[size:]
+	default:
+		var (
+			i = r.cfg.Format.value(r.sample[:size])
+			q = r.cfg.Format.value(r.sample[size:])
+			v float64
+		)
+		if r.cfg.Component == Magnitude {
+			v = math.Hypot(i, q)
+		} else {
+			v = math.Atan2(q, i)
+		}
+		if r.buf == nil {
+			r.buf = make([]byte, 4)
+		}
+		binary.LittleEndian.PutUint32(r.buf, math.Float32bits(float32(v)))
+		r.out = r.buf
+	}
+}
+
+// ReadSamples decodes the values of the selected component into dst.
+// I and Q values of CU8 samples are shifted to be centered at zero, the other formats keep their value.
+// ReadSamples and Read consume the same samples.
+func (r *Reader) ReadSamples(dst []float64) (int, error) {
+	if r.values == nil {
+		r.values = sample.NewDecoder(r, r.cfg.SampleSize(), r.cfg.Sample)
+	}
+	return r.values.ReadSamples(dst)
+}
+
+// SampleSize returns the number of bytes per value passed on by a Reader.
+func (c Config) SampleSize() int {
+	if c.Component == Magnitude || c.Component == Phase {
+		return 4
+	}
+	return c.Format.Size()
+}
+
+// Sample decodes one value of SampleSize bytes passed on by a Reader, as returned by ReadSamples.
+func (c Config) Sample(b []byte) float64 {
+	if c.Component == Magnitude || c.Component == Phase {
+		// computed values are float32
+		return CF32.value(b)
+	}
+	return c.Format.value(b)
+}
+
+// value decodes one I or Q value.
+func (f Format) value(b []byte) float64 {
+	switch f {
+	case CU8:
+		return float64(b[0]) - 127.5
+	case CS8:
+		return float64(int8(b[0]))
+	case CS16:
+		return float64(int16(binary.LittleEndian.Uint16(b)))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+}
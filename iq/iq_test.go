@@ -0,0 +1,142 @@
+package iq_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/dreadl0ck/debias/iq"
+)
+
+func read(t *testing.T, data []byte, cfg iq.Config) []byte {
+	t.Helper()
+	r, err := iq.NewReader(bytes.NewReader(data), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestComponents(t *testing.T) {
+	// three cs16 samples and a partial one
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+
+	tests := []struct {
+		component iq.Component
+		want      []byte
+	}{
+		{iq.Interleaved, data[:12]},
+		{iq.InPhase, []byte{1, 2, 5, 6, 9, 10}},
+		{iq.Quadrature, []byte{3, 4, 7, 8, 11, 12}},
+	}
+	for _, tt := range tests {
+		got := read(t, data, iq.Config{Format: iq.CS16, Component: tt.component})
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	tests := []struct {
+		format iq.Format
+		data   []byte
+		i, q   float64
+	}{
+		{iq.CU8, []byte{255, 0}, 127.5, -127.5},
+		{iq.CS8, []byte{0x80, 0x7f}, -128, 127},
+		{iq.CS16, []byte{0x00, 0x80, 0x01, 0x00}, -32768, 1},
+		{iq.CF32, []byte{0x00, 0x00, 0x40, 0x40, 0x00, 0x00, 0x80, 0x40}, 3, 4},
+	}
+	for _, tt := range tests {
+		r, err := iq.NewReader(bytes.NewReader(tt.data), iq.Config{Format: tt.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]float64, 4)
+		n, err := r.ReadSamples(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 || got[0] != tt.i || got[1] != tt.q {
+			t.Errorf("%v: got %v, want %v %v", tt.format, got[:n], tt.i, tt.q)
+		}
+		if r.Samples() != 1 {
+			t.Errorf("%v: unexpected number of samples %d", tt.format, r.Samples())
+		}
+	}
+}
+
+func TestMagnitudePhase(t *testing.T) {
+	// I = 3, Q = 4 and I = -1, Q = 0 as cs8
+	data := []byte{3, 4, 0xff, 0}
+
+	for _, tt := range []struct {
+		component iq.Component
+		want      []float64
+	}{
+		{iq.Magnitude, []float64{5, 1}},
+		{iq.Phase, []float64{math.Atan2(4, 3), math.Pi}},
+	} {
+		out := read(t, data, iq.Config{Format: iq.CS8, Component: tt.component})
+		if len(out) != 8 {
+			t.Fatalf("%v: unexpected output size %d", tt.component, len(out))
+		}
+		for k, want := range tt.want {
+			got := float64(math.Float32frombits(binary.LittleEndian.Uint32(out[4*k:])))
+			if math.Abs(got-want) > 1e-6 {
+				t.Errorf("%v: sample %d is %v, want %v", tt.component, k, got, want)
+			}
+		}
+
+		r, _ := iq.NewReader(bytes.NewReader(data), iq.Config{Format: iq.CS8, Component: tt.component})
+		samples := make([]float64, 4)
+		n, err := r.ReadSamples(samples)
+		if err != nil || n != 2 || math.Abs(samples[0]-tt.want[0]) > 1e-6 {
+			t.Errorf("%v: unexpected samples %v %v", tt.component, samples[:n], err)
+		}
+	}
+}
+
+func TestFormatFromExt(t *testing.T) {
+	for ext, want := range map[string]iq.Format{".cu8": iq.CU8, "cs8": iq.CS8, ".CS16": iq.CS16, ".cf32": iq.CF32} {
+		if f, ok := iq.FormatFromExt(ext); !ok || f != want {
+			t.Errorf("%s: got %v, want %v", ext, f, want)
+		}
+	}
+	if _, ok := iq.FormatFromExt(".wav"); ok {
+		t.Error("unexpected format for .wav")
+	}
+
+	data, err := json.Marshal(iq.Config{Format: iq.CS16, Component: iq.Magnitude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"format":"cs16","component":"magnitude"}` {
+		t.Error("unexpected JSON: ", string(data))
+	}
+	var cfg iq.Config
+	if err = json.Unmarshal(data, &cfg); err != nil || cfg.Format != iq.CS16 || cfg.Component != iq.Magnitude {
+		t.Error("unexpected decoded config: ", cfg, err)
+	}
+	if err = json.Unmarshal([]byte(`{"format":"cs4"}`), &cfg); !errors.Is(err, iq.ErrFormat) {
+		t.Error("expected ErrFormat, got ", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := iq.NewReader(bytes.NewReader(nil), iq.Config{}); !errors.Is(err, iq.ErrFormat) {
+		t.Fatal("expected ErrFormat, got ", err)
+	}
+	if _, err := iq.NewReader(bytes.NewReader(nil), iq.Config{Format: iq.CU8, Component: 7}); !errors.Is(err, iq.ErrComponent) {
+		t.Fatal("expected ErrComponent, got ", err)
+	}
+}
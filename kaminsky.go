@@ -7,116 +7,124 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"fmt"
 	"io"
-	"log"
 )
 
+// DefaultKaminskyBlockSize is the number of input bytes after which
+// the Kaminsky extractor registered for ModeKaminsky encrypts its output.
+var DefaultKaminskyBlockSize int64 = 512
+
+func init() {
+	Register(ModeKaminsky, "kaminsky", func(r io.Reader) Extractor {
+		ex, err := NewKaminsky(r, DefaultKaminskyBlockSize)
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+		return ex
+	})
+}
+
 // The Von Neumann Debiasing algorithm works on pairs of bits, and produces output as follows:
 // - If the input is "00" or "11", the input is discarded (no output).
 // - If the input is "10", output a "1".
 // - If the input is "01", output a "0".
 //
 // Kaminsky addition:
-// - collect discarded bytes
-// - use discarded bytes as input for SHA512
-// - use the SHA512 hash as key for encrypting the output data with AES
+// - collect the values of the discarded pairs
+// - use the discarded bits as input for SHA-256
+// - use the SHA-256 hash as key for encrypting the output data with AES
+//
+// A blockSize below one fails the first read from the pipe with ErrInvalidSize.
 func Kaminsky(reader io.ByteReader, wait bool, blockSize int64) (*io.PipeReader, context.Context, context.CancelFunc) {
-	var (
-		// internal buffer
-		buf        bytes.Buffer
-		discardBuf bytes.Buffer
-
-		// discard byte
-		discardByte     = byte(0)
-		discardBitCount uint
-
-		// out byte
-		outByte     = byte(0)
-		outBitCount uint
-
-		ctx, cancel = context.WithCancel(context.Background())
-
-		numBytes int64
-		pr, pw   = io.Pipe()
-		outBuf   bytes.Buffer
-	)
-
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				// write leftover
-				buf.WriteByte(outByte)
-				discardBuf.WriteByte(discardByte)
-				aesEncrypt(&buf, &discardBuf, pw, &outBuf, true)
-				err := pw.Close()
-				if err != nil {
-					log.Println("failed to close pipe writer:", err)
-				}
-				return
-			default:
-				b, err := reader.ReadByte()
-				if err != nil {
-
-					if !wait {
-						// write leftover
-						buf.WriteByte(outByte)
-						discardBuf.WriteByte(discardByte)
-						aesEncrypt(&buf, &discardBuf, pw, &outBuf, true)
-						err := pw.Close()
-						if err != nil {
-							log.Println("failed to close pipe writer:", err)
-						}
-						cancel()
-						return
-					}
-				}
-
-				numBytes++
-				if numBytes%blockSize == 0 {
-					aesEncrypt(&buf, &discardBuf, pw, &outBuf, false)
-				}
-
-				for j := 0; j < 8; j += 2 {
-
-					ch := (b >> (7 - j)) & 0x01
-					ch2 := (b >> (7 - (j + 1))) & 0x01
-
-					if ch != ch2 {
-
-						if ch == 1 {
-							// store a 1 in our bitBuffer
-							outByte = setBit(outByte, 7-outBitCount)
-
-						} // else: leave the buffer alone, it's already 0 at this bit
-
-						outBitCount++
-					} else {
-						// discarded bits: collect
-						discardByte = setBit(discardByte, 7-discardBitCount)
-						discardBitCount++
-					}
-
-					if discardBitCount == 8 {
-						discardBitCount = 0
-
-						discardBuf.WriteByte(discardByte)
-						discardByte = byte(0)
-					}
-
-					// is the byte full?
-					if outBitCount == 8 {
-						outBitCount = 0
-
-						buf.WriteByte(outByte)
-						outByte = byte(0)
-					}
-				}
+	k, err := newKaminsky(blockSize)
+	if err != nil {
+		return failedPipe(err)
+	}
+	return pipe(reader, wait, k)
+}
+
+// NewKaminsky returns an Extractor applying Kaminsky debiasing to the data read from r,
+// the output is encrypted every blockSize input bytes.
+// It returns ErrInvalidSize if blockSize is below one.
+func NewKaminsky(r io.Reader, blockSize int64) (*Reader, error) {
+	k, err := newKaminsky(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(r, k), nil
+}
+
+// kaminsky implements the Kaminsky debiasing algorithm.
+type kaminsky struct {
+	blockSize int64
+	numBytes  int64
+
+	// internal buffers
+	buf        bytes.Buffer
+	discardBuf bytes.Buffer
+
+	out     bitWriter
+	discard bitWriter
+	padding Padding
+}
+
+func newKaminsky(blockSize int64) (*kaminsky, error) {
+	if blockSize < 1 {
+		return nil, fmt.Errorf("%w: Kaminsky block size %d", ErrInvalidSize, blockSize)
+	}
+	return &kaminsky{blockSize: blockSize}, nil
+}
+
+func (k *kaminsky) Process(p []byte, out *bytes.Buffer) error {
+	for _, b := range p {
+
+		k.numBytes++
+		if k.numBytes%k.blockSize == 0 {
+			err := k.encrypt(out)
+			if err != nil {
+				return err
 			}
 		}
-	}()
 
-	return pr, ctx, cancel
+		for j := 0; j < 8; j += 2 {
+
+			ch := (b >> (7 - j)) & 0x01
+			ch2 := (b >> (7 - (j + 1))) & 0x01
+
+			if ch != ch2 {
+				k.out.writeBit(ch, &k.buf)
+			} else {
+				// discarded bits: collect
+				k.discard.writeBit(ch, &k.discardBuf)
+			}
+		}
+	}
+	return nil
+}
+
+func (k *kaminsky) Flush(out *bytes.Buffer) error {
+	// write leftover
+	k.out.flush(&k.buf, k.padding)
+	k.discard.flush(&k.discardBuf, PadZeroes)
+	return k.encrypt(out)
+}
+
+// SetPadding configures the handling of the partial plaintext byte before the final encryption.
+func (k *kaminsky) SetPadding(p Padding) {
+	k.padding = p
+}
+
+// BitsOut returns the number of debiased bits passed on to the encryption.
+// The PKCS#5 padding added by each encryption is not counted,
+// so the output is longer than BitsOut / 8 bytes.
+func (k *kaminsky) BitsOut() int64 {
+	return k.out.bits
+}
+
+// encrypt the collected output.
+func (k *kaminsky) encrypt(out *bytes.Buffer) error {
+	return aesEncrypt(&k.buf, &k.discardBuf, out)
 }
 
 // this function:
@@ -125,7 +133,7 @@ func Kaminsky(reader io.ByteReader, wait bool, blockSize int64) (*io.PipeReader,
 // - calculates the key based on the key buffer
 // - writes the result in the output buffer
 // - resets the input buffer and the key buffer
-func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, pw *io.PipeWriter, outBuf *bytes.Buffer, flush bool) {
+func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, outBuf *bytes.Buffer) error {
 
 	// create SHA256
 	var hash [32]byte
@@ -137,7 +145,7 @@ func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, pw *io.PipeWriter, outB
 		var k = make([]byte, 32)
 		_, err := rand.Read(k)
 		if err != nil {
-			log.Fatal("failed to read from /dev/random")
+			return fmt.Errorf("%w: key: %v", ErrRandom, err)
 		}
 		// create hash
 		hash = sha256.Sum256(k)
@@ -153,7 +161,7 @@ func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, pw *io.PipeWriter, outB
 	iv := make([]byte, 16)
 	_, err := rand.Read(iv)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("%w: iv: %v", ErrRandom, err)
 	}
 
 	// pad plaintext to aes.BlockSize
@@ -162,7 +170,7 @@ func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, pw *io.PipeWriter, outB
 	// init AES cipher with SHA256 as key
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("%w: %v", ErrCipher, err)
 	}
 
 	// create CBC block mode and encrypt data
@@ -177,26 +185,7 @@ func aesEncrypt(buf *bytes.Buffer, keyBuf *bytes.Buffer, pw *io.PipeWriter, outB
 	keyBuf.Reset()
 
 	// write output
-	if outBuf.Len()+len(ciphertext) > MaxChunkSize {
-
-		available := MaxChunkSize - outBuf.Len()
-
-		// fill outBuf until MaxChunkSize
-		outBuf.Write(ciphertext[:available])
+	outBuf.Write(ciphertext)
 
-		// flush outBuf through pipe
-		pw.Write(outBuf.Bytes())
-
-		// reset outBuf and add remaining ciphertext
-		outBuf.Reset()
-		outBuf.Write(ciphertext[available+1:])
-	} else {
-		// collect ciphertext
-		outBuf.Write(ciphertext)
-	}
-
-	if flush {
-		// flush outBuf through pipe
-		pw.Write(outBuf.Bytes())
-	}
+	return nil
 }
@@ -24,7 +24,7 @@ func TestKaminskyEntropy(t *testing.T) {
 		t.Fatal("unexpected number of output bytes, want 288 but got ", len(data))
 	}
 
-	if debias.ShannonEntropy(data) < 2100 {
-		t.Fatal("entropy too low: got ", debias.ShannonEntropy(data), " expected > ", 2100)
+	if e := debias.EntropyBitsPerByte(data); e < 7 {
+		t.Fatal("entropy too low: got ", e, " expected > ", 7)
 	}
 }
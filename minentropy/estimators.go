@@ -0,0 +1,197 @@
+package minentropy
+
+import "math"
+
+// MostCommonValue is the most common value estimate of SP 800-90B section 6.3.1,
+// it returns the min-entropy per sample from the frequency of the most common value.
+func MostCommonValue(s []byte, k int) (float64, error) {
+	if len(s) < 2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		counts [256]int
+		max    int
+	)
+	for _, v := range s {
+		counts[v]++
+		if counts[v] > max {
+			max = counts[v]
+		}
+	}
+
+	pu := upperBound(float64(max)/float64(len(s)), len(s))
+	return -math.Log2(pu), nil
+}
+
+// Collision is the collision estimate of SP 800-90B section 6.3.2 for binary samples,
+// it returns the min-entropy per bit from the mean time until the first repeated value.
+func Collision(s []byte) (float64, error) {
+	var t []float64
+	for i := 0; i+1 < len(s); {
+		if s[i] == s[i+1] {
+			t = append(t, 2)
+			i += 2
+		} else if i+2 < len(s) {
+			t = append(t, 3)
+			i += 3
+		} else {
+			break
+		}
+	}
+	if len(t) < 2 {
+		return 0, ErrTooShort
+	}
+
+	mean, sigma := meanDeviation(t)
+	xbar := mean - zAlpha*sigma/math.Sqrt(float64(len(t)))
+
+	// the expected collision time of SP 800-90B simplifies to 2 + 2pq for binary samples,
+	// the bound is clamped to the range from p = 1/2 to p = 1
+	xbar = math.Max(2, math.Min(2.5, xbar))
+	p := (1 + math.Sqrt(5-2*xbar)) / 2
+
+	return -math.Log2(p), nil
+}
+
+// meanDeviation returns the mean and the sample standard deviation of x.
+func meanDeviation(x []float64) (float64, float64) {
+	var sum, sq float64
+	for _, v := range x {
+		sum += v
+	}
+	mean := sum / float64(len(x))
+	for _, v := range x {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(x)-1))
+}
+
+// Markov is the Markov estimate of SP 800-90B section 6.3.3 for binary samples,
+// it returns the min-entropy per bit of the most likely 128 bit sequence of a first order Markov model.
+func Markov(s []byte) (float64, error) {
+	if len(s) < 2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		ones        int
+		transitions [2][2]float64
+	)
+	for i, v := range s {
+		ones += int(v)
+		if i > 0 {
+			transitions[s[i-1]][v]++
+		}
+	}
+
+	var (
+		p1 = float64(ones) / float64(len(s))
+		p0 = 1 - p1
+		t  [2][2]float64
+	)
+	for a := range transitions {
+		if n := transitions[a][0] + transitions[a][1]; n > 0 {
+			t[a][0] = transitions[a][0] / n
+			t[a][1] = transitions[a][1] / n
+		}
+	}
+
+	// log probabilities of the most likely sequences
+	lg := math.Log2
+	candidates := []float64{
+		lg(p0) + 127*lg(t[0][0]),
+		lg(p0) + 64*lg(t[0][1]) + 63*lg(t[1][0]),
+		lg(p0) + lg(t[0][1]) + 126*lg(t[1][1]),
+		lg(p1) + lg(t[1][0]) + 126*lg(t[0][0]),
+		lg(p1) + 64*lg(t[1][0]) + 63*lg(t[0][1]),
+		lg(p1) + 127*lg(t[1][1]),
+	}
+
+	max := math.Inf(-1)
+	for _, c := range candidates {
+		if !math.IsNaN(c) && c > max {
+			max = c
+		}
+	}
+
+	return math.Min(-max/128, 1), nil
+}
+
+const (
+	// compressionBlockSize is the number of bits per block b of the compression estimate
+	compressionBlockSize = 6
+
+	// compressionDictionary is the number of blocks d used to initialize the dictionary of the compression estimate
+	compressionDictionary = 1000
+)
+
+// Compression is the compression estimate of SP 800-90B section 6.3.4 for binary samples,
+// it returns the min-entropy per bit from the distances between repeated 6 bit blocks,
+// which is the statistic of Maurer's universal test.
+func Compression(s []byte) (float64, error) {
+	const (
+		b = compressionBlockSize
+		d = compressionDictionary
+	)
+	var (
+		n = len(s) / b
+		v = n - d
+	)
+	if v < 2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		dict       [1 << b]int
+		sum, sumSq float64
+	)
+	for i := 1; i <= n; i++ {
+		var block int
+		for _, bit := range s[(i-1)*b : i*b] {
+			block = block<<1 | int(bit)
+		}
+		if i > d {
+			dist := i
+			if dict[block] != 0 {
+				dist = i - dict[block]
+			}
+			l := math.Log2(float64(dist))
+			sum += l
+			sumSq += l * l
+		}
+		dict[block] = i
+	}
+
+	var (
+		xbar  = sum / float64(v)
+		sigma = 0.5907 * math.Sqrt(sumSq/float64(v-1)-xbar*xbar)
+		x     = xbar - zAlpha*sigma/math.Sqrt(float64(v))
+	)
+
+	// expected statistic for a source with the most likely block of probability p,
+	// the remaining probability is distributed evenly
+	g := func(z float64) float64 {
+		var (
+			inner float64
+			pw    = 1.0
+			total float64
+		)
+		for t := 1; t <= n; t++ {
+			// inner holds the sum over u < t, pw is (1-z)^(t-1)
+			if t > d {
+				total += inner + math.Log2(float64(t))*z*pw
+			}
+			inner += math.Log2(float64(t)) * z * z * pw
+			pw *= 1 - z
+		}
+		return total / float64(v)
+	}
+	expected := func(p float64) float64 {
+		q := (1 - p) / (1<<b - 1)
+		return g(p) + (1<<b-1)*g(q)
+	}
+
+	p := bisect(expected, x, 1.0/(1<<b), 1)
+	return -math.Log2(p) / b, nil
+}
@@ -0,0 +1,135 @@
+package minentropy_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias/minentropy"
+)
+
+// biasedBits returns n bits that are set with probability p.
+func biasedBits(n int, p float64, seed int64) []byte {
+	var (
+		rnd  = rand.New(rand.NewSource(seed))
+		bits = make([]byte, n)
+	)
+	for i := range bits {
+		if rnd.Float64() < p {
+			bits[i] = 1
+		}
+	}
+	return bits
+}
+
+// randomSamples returns n uniformly distributed samples of width bits.
+func randomSamples(n, width int, seed int64) []byte {
+	var (
+		rnd     = rand.New(rand.NewSource(seed))
+		samples = make([]byte, n)
+	)
+	for i := range samples {
+		samples[i] = byte(rnd.Intn(1 << uint(width)))
+	}
+	return samples
+}
+
+// periodic returns n samples repeating the values 0 to period-1.
+func periodic(n, period int) []byte {
+	samples := make([]byte, n)
+	for i := range samples {
+		samples[i] = byte(i % period)
+	}
+	return samples
+}
+
+func checkRange(t *testing.T, name string, h float64, err error, min, max float64) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if h < min || h > max {
+		t.Fatalf("%s: expected min-entropy between %f and %f, got %f", name, min, max, h)
+	}
+}
+
+func TestMostCommonValue(t *testing.T) {
+	samples := append(make([]byte, 500), periodic(500, 100)...)
+	h, err := minentropy.MostCommonValue(samples, 256)
+
+	// 505 of 1000 samples are zero
+	var (
+		p        = 0.505
+		expected = -math.Log2(p + 2.576*math.Sqrt(p*(1-p)/999))
+	)
+	checkRange(t, "MostCommonValue", h, err, expected-1e-12, expected+1e-12)
+}
+
+func TestBinaryEstimators(t *testing.T) {
+	var (
+		alternating = periodic(100000, 2)
+		constant    = make([]byte, 100000)
+		random      = biasedBits(100000, 0.5, 1)
+		biased      = biasedBits(100000, 0.8, 2)
+
+		// min-entropy of the biased bits
+		hb = -math.Log2(0.8)
+	)
+
+	for _, e := range []struct {
+		name string
+		fn   func([]byte) (float64, error)
+	}{
+		{"Collision", minentropy.Collision},
+		{"Markov", minentropy.Markov},
+		{"Compression", minentropy.Compression},
+	} {
+		h, err := e.fn(constant)
+		checkRange(t, e.name+" constant", h, err, 0, 0.01)
+		// the compression estimate is conservative for short inputs
+		h, err = e.fn(random)
+		checkRange(t, e.name+" random", h, err, 0.7, 1)
+		h, err = e.fn(biased)
+		checkRange(t, e.name+" biased", h, err, 0.5*hb, 1.1*hb)
+	}
+
+	// the collision estimate does not detect the dependency, the Markov estimate does
+	h, err := minentropy.Collision(alternating)
+	checkRange(t, "Collision alternating", h, err, 1, 1)
+	h, err = minentropy.Markov(alternating)
+	checkRange(t, "Markov alternating", h, err, 1.0/128, 1.0/128)
+
+	if _, err := minentropy.Compression(random[:6000]); err != minentropy.ErrTooShort {
+		t.Fatal("expected ErrTooShort, got ", err)
+	}
+}
+
+func TestPredictors(t *testing.T) {
+	var (
+		random = randomSamples(50000, 8, 3)
+		cyclic = periodic(50000, 7)
+	)
+
+	for _, e := range []struct {
+		name string
+		fn   func([]byte, int) (float64, error)
+	}{
+		{"MultiMCW", minentropy.MultiMCW},
+		{"Lag", minentropy.Lag},
+		{"MultiMMC", minentropy.MultiMMC},
+		{"LZ78Y", minentropy.LZ78Y},
+	} {
+		h, err := e.fn(random, 256)
+		checkRange(t, e.name+" random", h, err, 6.5, 8)
+
+		if e.name == "MultiMCW" {
+			continue
+		}
+		h, err = e.fn(cyclic, 256)
+		checkRange(t, e.name+" periodic", h, err, 0, 0.01)
+	}
+
+	// a constant source is predicted by all predictors
+	h, err := minentropy.MultiMCW(make([]byte, 50000), 256)
+	checkRange(t, "MultiMCW constant", h, err, 0, 0.01)
+}
@@ -0,0 +1,326 @@
+package minentropy
+
+import (
+	"bytes"
+	"compress/flate"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// DefaultPermutations is the number of permutations of the IID tests recommended by SP 800-90B.
+var DefaultPermutations = 10000
+
+// TestResult is the result of one permutation test statistic.
+type TestResult struct {
+	Name string
+
+	// Value is the statistic of the original samples.
+	Value float64
+
+	// Greater and Equal count the permutations with a statistic below and equal to the original.
+	Greater int
+	Equal   int
+
+	// Permutations is the number of permutations that were evaluated for the statistic.
+	Permutations int
+
+	Passed bool
+}
+
+// statistic computes a test statistic of the samples.
+type statistic struct {
+	name string
+	fn   func(s *iidSamples) float64
+}
+
+// iidSamples holds the representations of the samples used by the statistics.
+type iidSamples struct {
+	values []float64
+	median float64
+
+	// samples for the directional runs and collision statistics, converted for binary samples
+	directional []float64
+	collision   []float64
+}
+
+// iidStatistics holds the statistics of SP 800-90B section 5.1.
+var iidStatistics = []statistic{
+	{"Excursion", excursion},
+	{"NumDirectionalRuns", func(s *iidSamples) float64 { n, _, _ := directionalRuns(s.directional); return n }},
+	{"LenDirectionalRuns", func(s *iidSamples) float64 { _, l, _ := directionalRuns(s.directional); return l }},
+	{"NumIncreasesDecreases", func(s *iidSamples) float64 { _, _, m := directionalRuns(s.directional); return m }},
+	{"NumRunsMedian", func(s *iidSamples) float64 { n, _ := medianRuns(s.values, s.median); return n }},
+	{"LenRunsMedian", func(s *iidSamples) float64 { _, l := medianRuns(s.values, s.median); return l }},
+	{"AvgCollision", func(s *iidSamples) float64 { a, _ := collisions(s.collision); return a }},
+	{"MaxCollision", func(s *iidSamples) float64 { _, m := collisions(s.collision); return m }},
+	{"Periodicity1", periodicity(1)},
+	{"Periodicity2", periodicity(2)},
+	{"Periodicity8", periodicity(8)},
+	{"Periodicity16", periodicity(16)},
+	{"Periodicity32", periodicity(32)},
+	{"Covariance1", covariance(1)},
+	{"Covariance2", covariance(2)},
+	{"Covariance8", covariance(8)},
+	{"Covariance16", covariance(16)},
+	{"Covariance32", covariance(32)},
+	{"Compression", compression},
+}
+
+// newIIDSamples prepares the samples of width bits for the statistics.
+func newIIDSamples(samples []byte, width int) *iidSamples {
+	s := &iidSamples{values: make([]float64, len(samples))}
+	for i, v := range samples {
+		s.values[i] = float64(v)
+	}
+
+	sorted := append([]float64(nil), s.values...)
+	sort.Float64s(sorted)
+	if n := len(sorted); n%2 == 1 {
+		s.median = sorted[n/2]
+	} else if n > 0 {
+		s.median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	s.directional, s.collision = s.values, s.values
+	if width == 1 {
+		// conversion I: Hamming weight of 8 bit blocks, conversion II: value of 8 bit blocks
+		s.directional, s.collision = nil, nil
+		for i := 0; i+8 <= len(samples); i += 8 {
+			var weight, value float64
+			for _, b := range samples[i : i+8] {
+				weight += float64(b)
+				value = value*2 + float64(b)
+			}
+			s.directional = append(s.directional, weight)
+			s.collision = append(s.collision, value)
+		}
+	}
+	return s
+}
+
+// permute shuffles the samples and recomputes the converted samples for binary data.
+func (s *iidSamples) permute(rnd *rand.Rand, binary bool) {
+	rnd.Shuffle(len(s.values), func(i, j int) {
+		s.values[i], s.values[j] = s.values[j], s.values[i]
+	})
+	if !binary {
+		s.directional, s.collision = s.values, s.values
+		return
+	}
+	for i := 0; i+8 <= len(s.values); i += 8 {
+		var weight, value float64
+		for _, b := range s.values[i : i+8] {
+			weight += b
+			value = value*2 + b
+		}
+		s.directional[i/8] = weight
+		s.collision[i/8] = value
+	}
+}
+
+// IID runs the permutation tests of SP 800-90B section 5.1 on the samples of width bits.
+// The samples are shuffled up to permutations times with a generator seeded with seed,
+// a statistic is no longer computed once the original value is known not to be extreme.
+// The samples are considered IID if all tests pass.
+func IID(samples []byte, width, permutations int, seed int64) ([]TestResult, error) {
+	if width < 1 || width > 8 {
+		return nil, ErrInvalidWidth
+	}
+	if len(samples) < 64 {
+		return nil, ErrTooShort
+	}
+	if permutations <= 0 {
+		permutations = DefaultPermutations
+	}
+
+	var (
+		s       = newIIDSamples(samples, width)
+		results = make([]TestResult, len(iidStatistics))
+		less    = make([]int, len(iidStatistics))
+		rnd     = rand.New(rand.NewSource(seed))
+		pending = len(iidStatistics)
+	)
+	for i, st := range iidStatistics {
+		results[i] = TestResult{Name: st.name, Value: st.fn(s)}
+	}
+
+	for p := 0; p < permutations && pending > 0; p++ {
+		s.permute(rnd, width == 1)
+
+		for i, st := range iidStatistics {
+			r := &results[i]
+			if r.Passed {
+				continue
+			}
+			switch v := st.fn(s); {
+			case r.Value > v:
+				r.Greater++
+			case r.Value == v:
+				r.Equal++
+			default:
+				less[i]++
+			}
+			r.Permutations++
+
+			// the original statistic is neither among the lowest nor the highest
+			if r.Greater+r.Equal > 5 && r.Equal+less[i] > 5 {
+				r.Passed = true
+				pending--
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// excursion returns the maximal deviation of the running sum from the running average.
+func excursion(s *iidSamples) float64 {
+	var (
+		mean, sum, max float64
+	)
+	for _, v := range s.values {
+		mean += v
+	}
+	mean /= float64(len(s.values))
+	for i, v := range s.values {
+		sum += v
+		if d := math.Abs(sum - float64(i+1)*mean); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// directionalRuns returns the number of runs, the longest run and the larger number of increases or decreases
+// of the sequence of directions between successive samples.
+func directionalRuns(x []float64) (float64, float64, float64) {
+	if len(x) < 2 {
+		return 0, 0, 0
+	}
+	var (
+		runs, run, longest = 1, 1, 1
+		increases          int
+		last               = x[1] >= x[0]
+	)
+	for i := 1; i < len(x); i++ {
+		up := x[i] >= x[i-1]
+		if up {
+			increases++
+		}
+		if i == 1 {
+			continue
+		}
+		if up == last {
+			run++
+		} else {
+			runs++
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		last = up
+	}
+	decreases := len(x) - 1 - increases
+	if decreases > increases {
+		increases = decreases
+	}
+	return float64(runs), float64(longest), float64(increases)
+}
+
+// medianRuns returns the number of runs and the longest run of samples below or above the median.
+func medianRuns(x []float64, median float64) (float64, float64) {
+	var (
+		runs, run, longest int
+		last               bool
+	)
+	for i, v := range x {
+		above := v >= median
+		if i > 0 && above == last {
+			run++
+		} else {
+			runs++
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		last = above
+	}
+	return float64(runs), float64(longest)
+}
+
+// collisions returns the average and the maximal number of samples until a value repeats.
+func collisions(x []float64) (float64, float64) {
+	var (
+		// seen holds the index of the current segment for the values observed in it, offset by one
+		seen       [256]int
+		segment    = 1
+		sum, count float64
+		max        float64
+		start      int
+	)
+	for i, v := range x {
+		if seen[int(v)] != segment {
+			seen[int(v)] = segment
+			continue
+		}
+		d := float64(i - start + 1)
+		sum += d
+		count++
+		if d > max {
+			max = d
+		}
+		start = i + 1
+		segment++
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return sum / count, max
+}
+
+// periodicity returns the statistic counting the samples equal to the sample p positions later.
+func periodicity(p int) func(s *iidSamples) float64 {
+	return func(s *iidSamples) float64 {
+		var n int
+		for i := 0; i+p < len(s.values); i++ {
+			if s.values[i] == s.values[i+p] {
+				n++
+			}
+		}
+		return float64(n)
+	}
+}
+
+// covariance returns the statistic summing the products of the samples p positions apart.
+func covariance(p int) func(s *iidSamples) float64 {
+	return func(s *iidSamples) float64 {
+		var sum float64
+		for i := 0; i+p < len(s.values); i++ {
+			sum += s.values[i] * s.values[i+p]
+		}
+		return sum
+	}
+}
+
+// compression returns the compressed length of the samples encoded as decimal numbers separated by spaces.
+// SP 800-90B uses bzip2, which is not available for compression in the standard library, DEFLATE is used instead.
+func compression(s *iidSamples) float64 {
+	var (
+		buf bytes.Buffer
+		enc []byte
+	)
+	for i, v := range s.values {
+		if i > 0 {
+			enc = append(enc, ' ')
+		}
+		enc = strconv.AppendInt(enc, int64(v), 10)
+	}
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	w.Write(enc)
+	w.Close()
+	return float64(buf.Len())
+}
@@ -0,0 +1,51 @@
+package minentropy_test
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/debias/minentropy"
+)
+
+func TestIID(t *testing.T) {
+	results, err := minentropy.IID(randomSamples(5000, 8, 1), 8, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 19 {
+		t.Fatal("expected 19 statistics, got ", len(results))
+	}
+	for _, r := range results {
+		if !r.Passed {
+			t.Fatalf("%s: expected random samples to pass: %+v", r.Name, r)
+		}
+	}
+
+	results, err = minentropy.IID(biasedBits(8000, 0.5, 2), 1, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Passed {
+			t.Fatalf("%s: expected random bits to pass: %+v", r.Name, r)
+		}
+	}
+
+	// samples with a slow trend fail the tests on runs and excursions
+	var trend []byte
+	for _, v := range randomSamples(5000, 4, 3) {
+		trend = append(trend, byte(len(trend)*240/5000)+v)
+	}
+	results, err = minentropy.IID(trend, 8, 200, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := make(map[string]bool)
+	for _, r := range results {
+		if !r.Passed {
+			failed[r.Name] = true
+		}
+	}
+	if !failed["Excursion"] || !failed["NumRunsMedian"] {
+		t.Fatal("expected a trend to fail, failed tests: ", failed)
+	}
+}
@@ -0,0 +1,238 @@
+// Package minentropy implements the min-entropy estimators of NIST SP 800-90B
+// for the evaluation of entropy sources, along with the permutation tests of the IID assumption.
+//
+// The estimators operate on samples of up to 8 bits, stored as one byte per sample.
+// Samples unpacks a bit stream into samples of the configured width.
+package minentropy
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// ErrTooShort is returned by an estimator if the input is too short for its parameters.
+var ErrTooShort = errors.New("minentropy: not enough samples")
+
+// ErrInvalidWidth is returned for sample widths outside of 1 to 8 bits.
+var ErrInvalidWidth = errors.New("minentropy: invalid sample width")
+
+// zAlpha is the quantile of the standard normal distribution for the upper bound
+// of the 99 % confidence interval used by the estimators.
+const zAlpha = 2.576
+
+// maxBitstring is the number of bits the estimators are applied to for the bitstring estimate of non-binary samples.
+const maxBitstring = 1000000
+
+// Config holds the parameters for the estimation.
+type Config struct {
+
+	// Width is the number of bits per sample, from 1 to 8.
+	Width int
+
+	// MaxSamples limits the number of samples, zero uses all samples.
+	MaxSamples int
+}
+
+// DefaultConfig estimates the entropy of bytes over the one million samples recommended by SP 800-90B.
+var DefaultConfig = Config{
+	Width:      8,
+	MaxSamples: 1000000,
+}
+
+// Samples unpacks data into samples of width bits, most significant bit first.
+// Trailing bits that do not fill a sample are dropped.
+func Samples(data []byte, width int) ([]byte, error) {
+	if width < 1 || width > 8 {
+		return nil, ErrInvalidWidth
+	}
+	if width == 8 {
+		return append([]byte(nil), data...), nil
+	}
+
+	var (
+		samples = make([]byte, 0, len(data)*8/width)
+		v       byte
+		n       int
+	)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			v = v<<1 | (b>>uint(i))&0x01
+			n++
+			if n == width {
+				samples = append(samples, v)
+				v, n = 0, 0
+			}
+		}
+	}
+	return samples, nil
+}
+
+// Read reads the samples for cfg from r.
+func Read(r io.Reader, cfg Config) ([]byte, error) {
+	if cfg.MaxSamples > 0 {
+		r = io.LimitReader(r, int64((cfg.MaxSamples*cfg.Width+7)/8))
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := Samples(data, cfg.Width)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxSamples > 0 && len(samples) > cfg.MaxSamples {
+		samples = samples[:cfg.MaxSamples]
+	}
+	return samples, nil
+}
+
+// bitstring returns the bits of the samples, most significant bit first.
+func bitstring(samples []byte, width int) []byte {
+	bits := make([]byte, 0, len(samples)*width)
+	for _, s := range samples {
+		for i := width - 1; i >= 0; i-- {
+			bits = append(bits, (s>>uint(i))&0x01)
+		}
+	}
+	return bits
+}
+
+// Estimate is the result of a single estimator.
+type Estimate struct {
+	Name string
+
+	// Entropy is the estimated min-entropy per sample, or per bit for bitstring estimates.
+	Entropy float64
+
+	// Bitstring is set if the estimator was applied to the bits of the samples.
+	Bitstring bool
+
+	// Err is set if the estimator could not be applied, e.g. ErrTooShort.
+	Err error
+}
+
+// Report holds the results of the non-IID estimators.
+type Report struct {
+	Width   int
+	Samples int
+
+	Estimates []Estimate
+
+	// Original is the minimum of the estimates for the samples in bits per sample,
+	// Bitstring is the minimum of the estimates for the bits of the samples in bits per bit.
+	Original  float64
+	Bitstring float64
+
+	// MinEntropy is the assessed min-entropy in bits per sample, min(Original, Width·Bitstring),
+	// PerBit is the same value normalized to a single bit.
+	MinEntropy float64
+	PerBit     float64
+}
+
+// estimator computes the min-entropy of samples from an alphabet of k symbols.
+type estimator struct {
+	name   string
+	binary bool
+	fn     func(s []byte, k int) (float64, error)
+}
+
+// estimators holds the non-IID estimators of SP 800-90B section 6.3, in the order of the standard.
+// Binary estimators are only applied to bits.
+var estimators = []estimator{
+	{name: "MostCommonValue", fn: MostCommonValue},
+	{name: "Collision", binary: true, fn: func(s []byte, _ int) (float64, error) { return Collision(s) }},
+	{name: "Markov", binary: true, fn: func(s []byte, _ int) (float64, error) { return Markov(s) }},
+	{name: "Compression", binary: true, fn: func(s []byte, _ int) (float64, error) { return Compression(s) }},
+	{name: "TTuple", fn: TTuple},
+	{name: "LRS", fn: LRS},
+	{name: "MultiMCW", fn: MultiMCW},
+	{name: "Lag", fn: Lag},
+	{name: "MultiMMC", fn: MultiMMC},
+	{name: "LZ78Y", fn: LZ78Y},
+}
+
+// NonIID applies the non-IID estimators to the samples of width bits.
+// For samples of more than one bit, the estimators are also applied to the first million bits of the samples,
+// and the assessed min-entropy is the lower one of both estimates, as described in SP 800-90B section 3.1.3.
+func NonIID(samples []byte, width int) (*Report, error) {
+	if width < 1 || width > 8 {
+		return nil, ErrInvalidWidth
+	}
+	if len(samples) == 0 {
+		return nil, ErrTooShort
+	}
+
+	r := &Report{
+		Width:   width,
+		Samples: len(samples),
+	}
+
+	var (
+		k    = 1 << uint(width)
+		bits []byte
+	)
+	if width == 1 {
+		r.Original = r.apply(samples, 2, false, 1)
+		r.Bitstring = r.Original
+	} else {
+		r.Original = r.apply(samples, k, false, float64(width))
+		bits = bitstring(samples, width)
+		if len(bits) > maxBitstring {
+			bits = bits[:maxBitstring]
+		}
+		r.Bitstring = r.apply(bits, 2, true, 1)
+	}
+
+	r.MinEntropy = math.Min(r.Original, float64(width)*r.Bitstring)
+	r.PerBit = r.MinEntropy / float64(width)
+	return r, nil
+}
+
+// apply runs the estimators on s and returns the minimum estimate, which is at most max.
+// The binary estimators are skipped for non-binary samples.
+func (r *Report) apply(s []byte, k int, bitstring bool, max float64) float64 {
+	h := max
+	for _, e := range estimators {
+		if e.binary && k != 2 {
+			continue
+		}
+		v, err := e.fn(s, k)
+		r.Estimates = append(r.Estimates, Estimate{
+			Name:      e.name,
+			Entropy:   v,
+			Bitstring: bitstring,
+			Err:       err,
+		})
+		if err == nil && v < h {
+			h = v
+		}
+	}
+	return h
+}
+
+// upperBound returns the upper bound of the 99 % confidence interval for the probability p estimated from n samples.
+func upperBound(p float64, n int) float64 {
+	return math.Min(1, p+zAlpha*math.Sqrt(p*(1-p)/float64(n-1)))
+}
+
+// bisect returns x in [lo, hi] with f(x) = target for a monotonically decreasing function f.
+// Targets above f(lo) return lo, targets below f(hi) return hi.
+func bisect(f func(float64) float64, target, lo, hi float64) float64 {
+	if target >= f(lo) {
+		return lo
+	}
+	if target <= f(hi) {
+		return hi
+	}
+	for i := 0; i < 100 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if f(mid) > target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
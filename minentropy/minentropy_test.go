@@ -0,0 +1,68 @@
+package minentropy_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/dreadl0ck/debias/minentropy"
+)
+
+func TestSamples(t *testing.T) {
+	samples, err := minentropy.Samples([]byte{0xb4, 0x0f}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 101 101 000 000 111 1
+	if !bytes.Equal(samples, []byte{5, 5, 0, 0, 7}) {
+		t.Fatal("unexpected samples: ", samples)
+	}
+
+	samples, err = minentropy.Read(bytes.NewReader([]byte{0xb4, 0x0f}), minentropy.Config{Width: 4, MaxSamples: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(samples, []byte{0xb, 0x4, 0x0}) {
+		t.Fatal("unexpected samples: ", samples)
+	}
+
+	if _, err := minentropy.Samples(nil, 9); err != minentropy.ErrInvalidWidth {
+		t.Fatal("expected ErrInvalidWidth, got ", err)
+	}
+}
+
+func TestNonIID(t *testing.T) {
+	// bits with a min-entropy of -log2(0.8) = 0.32
+	r, err := minentropy.NonIID(biasedBits(100000, 0.8, 1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Estimates) != 10 {
+		t.Fatal("expected 10 estimates, got ", len(r.Estimates))
+	}
+	// the compression estimate is the lowest, it assumes that all blocks except the most likely one are uniform
+	if r.PerBit < 0.15 || r.PerBit > -math.Log2(0.8) {
+		t.Fatal("unexpected min-entropy: ", r.PerBit)
+	}
+	if r.Estimates[0].Entropy < 0.3 {
+		t.Fatal("unexpected most common value estimate: ", r.Estimates[0].Entropy)
+	}
+
+	// uniform samples of 4 bits
+	r, err = minentropy.NonIID(randomSamples(50000, 4, 2), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Estimates) != 17 {
+		t.Fatal("expected 7 estimates for the samples and 10 for the bits, got ", len(r.Estimates))
+	}
+	for _, e := range r.Estimates {
+		if e.Err != nil {
+			t.Fatalf("%s: %v", e.Name, e.Err)
+		}
+	}
+	if r.MinEntropy > 4 || r.MinEntropy != math.Min(r.Original, 4*r.Bitstring) || r.PerBit < 0.7 {
+		t.Fatalf("unexpected min-entropy: %+v", r)
+	}
+}
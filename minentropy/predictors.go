@@ -0,0 +1,302 @@
+package minentropy
+
+import "math"
+
+// predictions tracks the performance of a predictor.
+type predictions struct {
+	n, correct int
+	run, max   int
+}
+
+func (p *predictions) add(correct bool) {
+	p.n++
+	if correct {
+		p.correct++
+		p.run++
+		if p.run > p.max {
+			p.max = p.run
+		}
+	} else {
+		p.run = 0
+	}
+}
+
+// entropy returns the min-entropy per sample from the global and local performance of the predictor,
+// as described in SP 800-90B section 6.3.7 step 7 to 9.
+func (p *predictions) entropy(k int) (float64, error) {
+	if p.n < 2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		n       = float64(p.n)
+		pGlobal = float64(p.correct) / n
+	)
+	if p.correct == 0 {
+		pGlobal = 1 - math.Pow(0.01, 1/n)
+	} else {
+		pGlobal = upperBound(pGlobal, p.n)
+	}
+
+	// probability that the longest run of correct predictions is shorter than r
+	r := float64(p.max + 1)
+	noRun := func(pl float64) float64 {
+		var (
+			q = 1 - pl
+			x = 1.0
+		)
+		for i := 0; i < 10; i++ {
+			x = 1 + q*math.Pow(pl, r)*math.Pow(x, r+1)
+		}
+		v := math.Log(1-pl*x) - math.Log((r+1-r*x)*q) - (n+1)*math.Log(x)
+		if math.IsNaN(v) {
+			return math.Inf(-1)
+		}
+		return v
+	}
+	pLocal := bisect(noRun, math.Log(0.99), 0, 1-1e-12)
+
+	return -math.Log2(math.Max(math.Max(pGlobal, pLocal), 1/float64(k))), nil
+}
+
+// none marks a missing prediction.
+const none = -1
+
+// mcwWindows are the window sizes of the most common in window subpredictors.
+var mcwWindows = []int{63, 255, 1023, 4095}
+
+// window tracks the most common value in a sliding window, ties are resolved to the most recent value.
+type window struct {
+	size     int
+	counts   [256]int
+	last     [256]int
+	frequent int
+}
+
+func (w *window) add(s []byte, i int) {
+	v := s[i]
+	w.counts[v]++
+	w.last[v] = i
+	if w.frequent == none || w.counts[v] >= w.counts[w.frequent] {
+		w.frequent = int(v)
+	}
+	if i < w.size {
+		return
+	}
+
+	old := s[i-w.size]
+	w.counts[old]--
+	if int(old) != w.frequent {
+		return
+	}
+
+	// the most common value lost an occurrence
+	for c := range w.counts {
+		if w.counts[c] > w.counts[w.frequent] || w.counts[c] == w.counts[w.frequent] && w.counts[c] > 0 && w.last[c] > w.last[w.frequent] {
+			w.frequent = c
+		}
+	}
+}
+
+// MultiMCW is the multi most common in window prediction estimate of SP 800-90B section 6.3.7,
+// it predicts the most common value in the last 63, 255, 1023 and 4095 samples.
+func MultiMCW(s []byte, k int) (float64, error) {
+	if len(s) <= mcwWindows[0] {
+		return 0, ErrTooShort
+	}
+
+	var (
+		windows    = make([]window, len(mcwWindows))
+		scoreboard = make([]int, len(mcwWindows))
+		winner     int
+		result     predictions
+	)
+	for j, size := range mcwWindows {
+		windows[j] = window{size: size, frequent: none}
+	}
+
+	for i := 0; i < len(s); i++ {
+		if i >= mcwWindows[0] {
+			if windows[winner].frequent == int(s[i]) {
+				result.add(true)
+			} else {
+				result.add(false)
+			}
+			for j := range windows {
+				if i >= windows[j].size && windows[j].frequent == int(s[i]) {
+					scoreboard[j]++
+					if scoreboard[j] >= scoreboard[winner] {
+						winner = j
+					}
+				}
+			}
+		}
+		for j := range windows {
+			windows[j].add(s, i)
+		}
+	}
+
+	return result.entropy(k)
+}
+
+// lagDepth is the number of lags D of the lag prediction estimate.
+const lagDepth = 128
+
+// Lag is the lag prediction estimate of SP 800-90B section 6.3.8,
+// it predicts the sample at each of the last 128 positions.
+func Lag(s []byte, k int) (float64, error) {
+	if len(s) < 2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		scoreboard [lagDepth + 1]int
+		winner     = 1
+		result     predictions
+	)
+	for i := 1; i < len(s); i++ {
+		result.add(i-winner >= 0 && s[i-winner] == s[i])
+		for d := 1; d <= lagDepth && d <= i; d++ {
+			if s[i-d] == s[i] {
+				scoreboard[d]++
+				if scoreboard[d] >= scoreboard[winner] {
+					winner = d
+				}
+			}
+		}
+	}
+
+	return result.entropy(k)
+}
+
+const (
+	// mmcDepth is the highest order D of the Markov model subpredictors of the MultiMMC estimate.
+	mmcDepth = 16
+
+	// mmcMaxEntries limits the number of contexts of each Markov model.
+	mmcMaxEntries = 100000
+)
+
+// successor counts the occurrences of a sample after a context.
+type successor struct {
+	value byte
+	count int
+}
+
+// counter maps a context to the samples that followed it.
+type counter map[string][]successor
+
+// add counts next after ctx, new contexts are only added while the counter has less than max entries.
+func (c counter) add(ctx []byte, next byte, max int) {
+	successors, ok := c[string(ctx)]
+	if !ok && len(c) >= max {
+		return
+	}
+	for i := range successors {
+		if successors[i].value == next {
+			successors[i].count++
+			return
+		}
+	}
+	c[string(ctx)] = append(successors, successor{value: next, count: 1})
+}
+
+// predict returns the most frequent sample after ctx and its count, ties are resolved to the highest value.
+func (c counter) predict(ctx []byte) (int, int) {
+	var (
+		prediction = none
+		max        int
+	)
+	for _, s := range c[string(ctx)] {
+		if s.count > max || s.count == max && int(s.value) > prediction {
+			prediction, max = int(s.value), s.count
+		}
+	}
+	return prediction, max
+}
+
+// MultiMMC is the multi Markov model with counting prediction estimate of SP 800-90B section 6.3.9,
+// it predicts the most frequent successor of the last 1 to 16 samples.
+func MultiMMC(s []byte, k int) (float64, error) {
+	if len(s) < 3 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		models     = make([]counter, mmcDepth+1)
+		scoreboard [mmcDepth + 1]int
+		winner     = 1
+		result     predictions
+		predicted  [mmcDepth + 1]int
+	)
+	for d := range models {
+		models[d] = make(counter)
+	}
+
+	for i := 2; i < len(s); i++ {
+		// update the models with the transition to the previous sample
+		for d := 1; d <= mmcDepth && d < i; d++ {
+			models[d].add(s[i-d-1:i-1], s[i-1], mmcMaxEntries)
+		}
+
+		for d := 1; d <= mmcDepth; d++ {
+			predicted[d] = none
+			if d <= i {
+				predicted[d], _ = models[d].predict(s[i-d : i])
+			}
+		}
+
+		result.add(predicted[winner] == int(s[i]))
+		for d := 1; d <= mmcDepth; d++ {
+			if predicted[d] == int(s[i]) {
+				scoreboard[d]++
+				if scoreboard[d] >= scoreboard[winner] {
+					winner = d
+				}
+			}
+		}
+	}
+
+	return result.entropy(k)
+}
+
+const (
+	// lz78yDepth is the maximum length B of the strings in the dictionary of the LZ78Y estimate.
+	lz78yDepth = 16
+
+	// lz78yMaxEntries limits the size of the dictionary of the LZ78Y estimate.
+	lz78yMaxEntries = 65536
+)
+
+// LZ78Y is the LZ78Y prediction estimate of SP 800-90B section 6.3.10,
+// it predicts the most frequent successor of the longest previously seen strings of up to 16 samples.
+func LZ78Y(s []byte, k int) (float64, error) {
+	const b = lz78yDepth
+	if len(s) < b+2 {
+		return 0, ErrTooShort
+	}
+
+	var (
+		dict   = make(counter)
+		result predictions
+	)
+	for i := b + 1; i < len(s); i++ {
+		// add the strings ending before the previous sample
+		for j := b; j >= 1; j-- {
+			dict.add(s[i-j-1:i-1], s[i-1], lz78yMaxEntries)
+		}
+
+		var (
+			prediction = none
+			max        int
+		)
+		for j := b; j >= 1; j-- {
+			if y, n := dict.predict(s[i-j : i]); n > max {
+				prediction, max = y, n
+			}
+		}
+		result.add(prediction == int(s[i]))
+	}
+
+	return result.entropy(k)
+}
@@ -0,0 +1,264 @@
+package minentropy
+
+import "math"
+
+// tupleCutoff is the minimum number of occurrences of the most common tuple for the t-tuple estimate.
+const tupleCutoff = 35
+
+// suffixArray returns the start positions of the suffixes of s in lexicographic order.
+// The suffixes are sorted as cyclic shifts of s with an appended sentinel by prefix doubling.
+func suffixArray(s []byte) []int {
+	var (
+		n      = len(s) + 1
+		p      = make([]int, n)
+		c      = make([]int, n)
+		pn     = make([]int, n)
+		cn     = make([]int, n)
+		cnt    = make([]int, 257)
+		symbol = func(i int) int {
+			if i == len(s) {
+				return 0
+			}
+			return int(s[i]) + 1
+		}
+	)
+
+	// sort by the first symbol
+	for i := 0; i < n; i++ {
+		cnt[symbol(i)]++
+	}
+	for i := 1; i < len(cnt); i++ {
+		cnt[i] += cnt[i-1]
+	}
+	for i := n - 1; i >= 0; i-- {
+		cnt[symbol(i)]--
+		p[cnt[symbol(i)]] = i
+	}
+	classes := 1
+	for i := 1; i < n; i++ {
+		if symbol(p[i]) != symbol(p[i-1]) {
+			classes++
+		}
+		c[p[i]] = classes - 1
+	}
+
+	// sort by prefixes of twice the length, using the order of the previous round for the second half
+	for h := 1; h < n && classes < n; h <<= 1 {
+		for i := range p {
+			pn[i] = p[i] - h
+			if pn[i] < 0 {
+				pn[i] += n
+			}
+		}
+
+		cnt = cnt[:0]
+		for i := 0; i < classes; i++ {
+			cnt = append(cnt, 0)
+		}
+		for i := 0; i < n; i++ {
+			cnt[c[pn[i]]]++
+		}
+		for i := 1; i < classes; i++ {
+			cnt[i] += cnt[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			cnt[c[pn[i]]]--
+			p[cnt[c[pn[i]]]] = pn[i]
+		}
+
+		cn[p[0]] = 0
+		classes = 1
+		for i := 1; i < n; i++ {
+			if c[p[i]] != c[p[i-1]] || c[(p[i]+h)%n] != c[(p[i-1]+h)%n] {
+				classes++
+			}
+			cn[p[i]] = classes - 1
+		}
+		c, cn = cn, c
+	}
+
+	// drop the sentinel
+	return p[1:]
+}
+
+// lcpArray returns the length of the longest common prefix of each suffix in sa with its predecessor.
+func lcpArray(s []byte, sa []int) []int {
+	var (
+		n    = len(s)
+		rank = make([]int, n)
+		lcp  = make([]int, n)
+		h    int
+	)
+	for i, p := range sa {
+		rank[p] = i
+	}
+	for i := 0; i < n; i++ {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := sa[rank[i]-1]
+		for i+h < n && j+h < n && s[i+h] == s[j+h] {
+			h++
+		}
+		lcp[rank[i]] = h
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
+
+// tuples holds the statistics of the tuples of all lengths in a sequence.
+type tuples struct {
+
+	// maxCount[w] is the number of occurrences of the most common tuple of length w
+	maxCount []int
+
+	// pairs[w] is the number of pairs of positions that start with the same tuple of length w
+	pairs []float64
+}
+
+// countTuples computes the tuple statistics of s from its suffix and LCP arrays.
+func countTuples(s []byte) *tuples {
+	var (
+		n   = len(s)
+		sa  = suffixArray(s)
+		lcp = lcpArray(s, sa)
+		max int
+	)
+	for _, l := range lcp {
+		if l > max {
+			max = l
+		}
+	}
+
+	// previous index with a smaller or equal LCP, previous and next index with a smaller LCP
+	var (
+		prevLE = make([]int, n)
+		prevLT = make([]int, n)
+		nextLT = make([]int, n)
+		stack  []int
+	)
+	for i := 1; i < n; i++ {
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] > lcp[i] {
+			stack = stack[:len(stack)-1]
+		}
+		prevLE[i] = 0
+		if len(stack) > 0 {
+			prevLE[i] = stack[len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+	stack = stack[:0]
+	for i := 1; i < n; i++ {
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] >= lcp[i] {
+			stack = stack[:len(stack)-1]
+		}
+		prevLT[i] = 0
+		if len(stack) > 0 {
+			prevLT[i] = stack[len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+	stack = stack[:0]
+	for i := n - 1; i >= 1; i-- {
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] >= lcp[i] {
+			stack = stack[:len(stack)-1]
+		}
+		nextLT[i] = n
+		if len(stack) > 0 {
+			nextLT[i] = stack[len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+
+	t := &tuples{
+		maxCount: make([]int, max+2),
+		pairs:    make([]float64, max+2),
+	}
+	for i := 1; i < n; i++ {
+		l := lcp[i]
+
+		// the suffixes from prevLT to nextLT-1 share a prefix of length l
+		if c := nextLT[i] - prevLT[i]; c > t.maxCount[l] {
+			t.maxCount[l] = c
+		}
+
+		// pairs of suffixes whose common prefix is limited by position i, counted at the leftmost minimum
+		t.pairs[l] += float64(i-prevLE[i]) * float64(nextLT[i]-i)
+	}
+	for w := max - 1; w >= 0; w-- {
+		if t.maxCount[w+1] > t.maxCount[w] {
+			t.maxCount[w] = t.maxCount[w+1]
+		}
+		t.pairs[w] += t.pairs[w+1]
+	}
+	for w := 1; w <= max+1 && w <= n; w++ {
+		if t.maxCount[w] == 0 {
+			t.maxCount[w] = 1
+		}
+	}
+	return t
+}
+
+// count returns the number of occurrences of the most common tuple of length w.
+func (t *tuples) count(w int) int {
+	if w < len(t.maxCount) {
+		return t.maxCount[w]
+	}
+	return 1
+}
+
+// TTuple is the t-tuple estimate of SP 800-90B section 6.3.5,
+// it returns the min-entropy per sample from the frequencies of the most common tuples
+// up to the longest tuple that occurs at least 35 times.
+func TTuple(s []byte, k int) (float64, error) {
+	return countTuples(s).tTuple(len(s))
+}
+
+func (t *tuples) tTuple(l int) (float64, error) {
+	var pmax float64
+	for w := 1; t.count(w) >= tupleCutoff; w++ {
+		p := math.Pow(float64(t.count(w))/float64(l-w+1), 1/float64(w))
+		if p > pmax {
+			pmax = p
+		}
+	}
+	if pmax == 0 {
+		return 0, ErrTooShort
+	}
+	return -math.Log2(upperBound(pmax, l)), nil
+}
+
+// LRS is the longest repeated substring estimate of SP 800-90B section 6.3.6,
+// it returns the min-entropy per sample from the collision probabilities of the tuples
+// longer than those used by the t-tuple estimate.
+func LRS(s []byte, k int) (float64, error) {
+	return countTuples(s).lrs(len(s))
+}
+
+func (t *tuples) lrs(l int) (float64, error) {
+	var (
+		u    = 1
+		v    = len(t.pairs) - 2
+		pmax float64
+	)
+	for t.count(u) >= tupleCutoff {
+		u++
+	}
+	if u > v {
+		return 0, ErrTooShort
+	}
+
+	for w := u; w <= v; w++ {
+		var (
+			positions = float64(l - w + 1)
+			p         = t.pairs[w] / (positions * (positions - 1) / 2)
+		)
+		if p = math.Pow(p, 1/float64(w)); p > pmax {
+			pmax = p
+		}
+	}
+	return -math.Log2(upperBound(pmax, l)), nil
+}
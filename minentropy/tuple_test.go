@@ -0,0 +1,81 @@
+package minentropy_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dreadl0ck/debias/minentropy"
+)
+
+// tupleCounts returns the number of occurrences of each tuple of length w.
+func tupleCounts(s []byte, w int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+w <= len(s); i++ {
+		counts[string(s[i:i+w])]++
+	}
+	return counts
+}
+
+// naiveTuple computes the t-tuple and LRS estimates by counting all tuples.
+func naiveTuple(s []byte) (float64, float64) {
+	var (
+		l     = float64(len(s))
+		bound = func(p float64) float64 {
+			return -math.Log2(math.Min(1, p+2.576*math.Sqrt(p*(1-p)/(l-1))))
+		}
+		tmax, lmax float64
+		w          = 1
+	)
+	for ; ; w++ {
+		var max int
+		for _, c := range tupleCounts(s, w) {
+			if c > max {
+				max = c
+			}
+		}
+		if max < 35 {
+			break
+		}
+		tmax = math.Max(tmax, math.Pow(float64(max)/(l-float64(w)+1), 1/float64(w)))
+	}
+	for ; ; w++ {
+		var (
+			pairs    float64
+			repeated bool
+		)
+		for _, c := range tupleCounts(s, w) {
+			pairs += float64(c) * float64(c-1) / 2
+			repeated = repeated || c > 1
+		}
+		if !repeated {
+			break
+		}
+		n := l - float64(w) + 1
+		lmax = math.Max(lmax, math.Pow(pairs/(n*(n-1)/2), 1/float64(w)))
+	}
+	return bound(tmax), bound(lmax)
+}
+
+func TestTuple(t *testing.T) {
+	for i, s := range [][]byte{
+		biasedBits(3000, 0.7, 4),
+		randomSamples(3000, 2, 5),
+		append(periodic(1000, 5), randomSamples(1000, 3, 6)...),
+	} {
+		var (
+			tt, errT                = minentropy.TTuple(s, 8)
+			lrs, errL               = minentropy.LRS(s, 8)
+			ttExpected, lrsExpected = naiveTuple(s)
+		)
+		if errT != nil || errL != nil {
+			t.Fatal(errT, errL)
+		}
+		if math.Abs(tt-ttExpected) > 1e-9 || math.Abs(lrs-lrsExpected) > 1e-9 {
+			t.Fatalf("test #%d: expected %f and %f, got %f and %f", i, ttExpected, lrsExpected, tt, lrs)
+		}
+	}
+
+	if _, err := minentropy.TTuple(randomSamples(20, 8, 7), 256); err != minentropy.ErrTooShort {
+		t.Fatal("expected ErrTooShort, got ", err)
+	}
+}
@@ -1,8 +1,168 @@
 package debias
 
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+)
+
+// Mode selects the extraction algorithm.
 type Mode int
 
 const (
-	ModeVonNeumann = 0
-	ModeKaminsky   = 1
+	ModeVonNeumann Mode = iota
+	ModeKaminsky
+	ModePeres
+	ModeElias
+	ModeBlum
+	ModeToeplitz
+	ModeTrevisan
 )
+
+// ExtractorFunc creates a new Extractor that debiases the data read from r.
+type ExtractorFunc func(r io.Reader) Extractor
+
+// ExtractorConfigFunc creates a new Extractor that debiases the data read from r with the parameters in cfg.
+type ExtractorConfigFunc func(r io.Reader, cfg ExtractorConfig) Extractor
+
+// ExtractorConfig holds the parameters of extractors that need more than the biased input,
+// like the seeded extractors registered for ModeToeplitz and ModeTrevisan.
+// Zero values use the defaults of the extractor.
+type ExtractorConfig struct {
+
+	// MinEntropy is the min-entropy per input bit assumed for the input.
+	MinEntropy float64
+
+	// Epsilon is the maximum distance of the output from uniform.
+	Epsilon float64
+
+	// Seed is the seed of the extractor. If it is empty, the seed is read from the beginning of SeedFile,
+	// or from crypto/rand if no seed file is set either.
+	Seed     []byte
+	SeedFile string
+}
+
+// Sources of the seed of seeded extractors, as reported in Stats.SeedSource.
+// Seeds read from a file report the name of the file.
+const (
+	SeedSourceRandom = "crypto/rand"
+	SeedSourceConfig = "config"
+)
+
+// seed returns the seed of size bytes for cfg and its source.
+func (cfg ExtractorConfig) seed(size int) ([]byte, string, error) {
+	switch {
+	case len(cfg.Seed) > 0:
+		return cfg.Seed, SeedSourceConfig, nil
+	case cfg.SeedFile != "":
+		seed, err := readSeed(cfg.SeedFile, size)
+		return seed, cfg.SeedFile, err
+	}
+
+	seed := make([]byte, size)
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: seed: %v", ErrRandom, err)
+	}
+	return seed, SeedSourceRandom, nil
+}
+
+// readSeed reads a seed of size bytes from the beginning of file.
+func readSeed(file string, size int) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf("%w: seed file %s has %d bytes, need %d", ErrShortInput, file, len(data), size)
+	}
+	return data[:size], nil
+}
+
+type registration struct {
+	name string
+	fn   ExtractorConfigFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[Mode]registration)
+)
+
+// Register makes an extraction algorithm available under the given mode.
+// The name is returned by Mode.String and used to name output files.
+// Registering an existing mode again replaces the previous algorithm.
+func Register(mode Mode, name string, fn ExtractorFunc) {
+	RegisterConfig(mode, name, func(r io.Reader, _ ExtractorConfig) Extractor {
+		return fn(r)
+	})
+}
+
+// RegisterConfig makes an extraction algorithm that takes an ExtractorConfig available under the given mode,
+// see Register.
+func RegisterConfig(mode Mode, name string, fn ExtractorConfigFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[mode] = registration{name: name, fn: fn}
+}
+
+// NewExtractor creates the extractor registered for mode reading biased data from r.
+func NewExtractor(mode Mode, r io.Reader) (Extractor, error) {
+	return NewExtractorConfig(mode, r, ExtractorConfig{})
+}
+
+// NewExtractorConfig creates the extractor registered for mode reading biased data from r,
+// passing cfg to extractors registered with RegisterConfig. Other extractors ignore it.
+func NewExtractorConfig(mode Mode, r io.Reader, cfg ExtractorConfig) (Extractor, error) {
+	registryMu.RLock()
+	reg, ok := registry[mode]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMode, int(mode))
+	}
+	return reg.fn(r, cfg), nil
+}
+
+// Modes returns all registered modes in ascending order.
+func Modes() []Mode {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	modes := make([]Mode, 0, len(registry))
+	for m := range registry {
+		modes = append(modes, m)
+	}
+	sort.Slice(modes, func(i, j int) bool {
+		return modes[i] < modes[j]
+	})
+	return modes
+}
+
+// ParseMode returns the mode registered with name.
+func ParseMode(name string) (Mode, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for m, reg := range registry {
+		if reg.name == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownMode, name)
+}
+
+// String returns the name the mode has been registered with.
+func (m Mode) String() string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if reg, ok := registry[m]; ok {
+		return reg.name
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
@@ -4,89 +4,60 @@ import (
 	"bytes"
 	"context"
 	"io"
-	"log"
 )
 
 var MaxChunkSize = 1024
 
+func init() {
+	Register(ModeVonNeumann, "neumann", func(r io.Reader) Extractor {
+		return NewVonNeumann(r)
+	})
+}
+
 // The Von Neumann Debiasing algorithm works on pairs of bits, and produces output as follows:
 // - If the input is "00" or "11", the input is discarded (no output).
 // - If the input is "10", output a "1".
 // - If the input is "01", output a "0".
 func VonNeumann(reader io.ByteReader, wait bool) (*io.PipeReader, context.Context, context.CancelFunc) {
-	var (
-		outByte     = byte(0)
-		bitCount    uint
-		ctx, cancel = context.WithCancel(context.Background())
-		pr, pw      = io.Pipe()
-
-		outBuf bytes.Buffer
-	)
-
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-
-				// write leftover
-				outBuf.WriteByte(outByte)
-				pw.Write(outBuf.Bytes())
-				err := pw.Close()
-				if err != nil {
-					log.Println("failed to close pipe writer:", err)
-				}
-
-				return
-			default:
-				b, err := reader.ReadByte()
-				if err != nil {
-
-					if !wait {
-						// write leftover
-						outBuf.WriteByte(outByte)
-						pw.Write(outBuf.Bytes())
-						err := pw.Close()
-						if err != nil {
-							log.Println("failed to close pipe writer:", err)
-						}
-						cancel()
-						return
-					}
-				}
-
-				if outBuf.Len()+1 > MaxChunkSize {
-					pw.Write(outBuf.Bytes())
-					outBuf.Reset()
-				}
-
-				for j := 0; j < 8; j += 2 {
-
-					ch := (b >> (7 - j)) & 0x01
-					ch2 := (b >> (7 - (j + 1))) & 0x01
-
-					if ch != ch2 {
+	return pipe(reader, wait, &vonNeumann{})
+}
 
-						if ch == 1 {
-							// store a 1 in our bitbuffer
-							outByte = setBit(outByte, 7-bitCount)
-						} // else: leave the buffer alone, it's already 0 at this bit
+// NewVonNeumann returns an Extractor applying Von Neumann debiasing to the data read from r.
+func NewVonNeumann(r io.Reader) *Reader {
+	return NewReader(r, &vonNeumann{})
+}
 
-						bitCount++
-					}
+// vonNeumann implements the Von Neumann debiasing algorithm.
+type vonNeumann struct {
+	out     bitWriter
+	padding Padding
+}
 
-					// is the byte full?
-					if bitCount == 8 {
-						bitCount = 0
+func (v *vonNeumann) Process(p []byte, out *bytes.Buffer) error {
+	for _, b := range p {
+		for j := 0; j < 8; j += 2 {
 
-						outBuf.WriteByte(outByte)
+			ch := (b >> (7 - j)) & 0x01
+			ch2 := (b >> (7 - (j + 1))) & 0x01
 
-						//fmt.Printf("%08b\n", outByte)
-						outByte = byte(0)
-					}
-				}
+			if ch != ch2 {
+				v.out.writeBit(ch, out)
 			}
 		}
-	}()
+	}
+	return nil
+}
+
+func (v *vonNeumann) Flush(out *bytes.Buffer) error {
+	// write leftover
+	v.out.flush(out, v.padding)
+	return nil
+}
+
+func (v *vonNeumann) SetPadding(p Padding) {
+	v.padding = p
+}
 
-	return pr, ctx, cancel
+func (v *vonNeumann) BitsOut() int64 {
+	return v.out.bits
 }
@@ -29,8 +29,8 @@ func TestNeumannEntropy(t *testing.T) {
 		t.Fatal("unexpected number of output bytes, want 258 but got ", len(data))
 	}
 
-	if debias.ShannonEntropy(data) < 2000 {
-		t.Fatal("entropy too low: got ", debias.ShannonEntropy(data), " expected > ", 2000)
+	if e := debias.EntropyBitsPerByte(data); e < 7 {
+		t.Fatal("entropy too low: got ", e, " expected > ", 7)
 	}
 }
 
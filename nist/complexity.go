@@ -0,0 +1,97 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// linearComplexityPi holds the probabilities of the classes of the linear complexity test.
+var linearComplexityPi = []float64{0.010417, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+
+// LinearComplexity is the linear complexity test, it checks whether the shortest linear feedback
+// shift register generating each block of m bits is as long as expected for a random sequence.
+func LinearComplexity(e []byte, m int) Result {
+	const name = "LinearComplexity"
+	n := len(e) / m
+	if m <= 0 || n == 0 {
+		return skipped(name, fmt.Sprintf("no block of %d bits", m))
+	}
+
+	var (
+		fm   = float64(m)
+		sign = 1.0
+	)
+	if m%2 == 1 {
+		sign = -1
+	}
+	mu := fm/2 + (9+(-sign))/36 - (fm/3+2.0/9)/math.Pow(2, fm)
+
+	nu := make([]int, len(linearComplexityPi))
+	for i := 0; i < n; i++ {
+		var (
+			l = BerlekampMassey(e[i*m : (i+1)*m])
+			t = sign*(float64(l)-mu) + 2.0/9
+		)
+		switch {
+		case t <= -2.5:
+			nu[0]++
+		case t <= -1.5:
+			nu[1]++
+		case t <= -0.5:
+			nu[2]++
+		case t <= 0.5:
+			nu[3]++
+		case t <= 1.5:
+			nu[4]++
+		case t <= 2.5:
+			nu[5]++
+		default:
+			nu[6]++
+		}
+	}
+
+	var chi float64
+	for i, pi := range linearComplexityPi {
+		expected := float64(n) * pi
+		chi += (float64(nu[i]) - expected) * (float64(nu[i]) - expected) / expected
+	}
+
+	return result(name, special.Igamc(float64(len(nu)-1)/2, chi/2))
+}
+
+// BerlekampMassey returns the linear complexity of the sequence s,
+// the length of the shortest linear feedback shift register that generates it.
+func BerlekampMassey(s []byte) int {
+	var (
+		n = len(s)
+		c = make([]byte, n+1)
+		b = make([]byte, n+1)
+		t = make([]byte, n+1)
+		l int
+		m = -1
+	)
+	c[0], b[0] = 1, 1
+
+	for i := 0; i < n; i++ {
+		d := s[i]
+		for j := 1; j <= l; j++ {
+			d ^= c[j] & s[i-j]
+		}
+		if d == 0 {
+			continue
+		}
+
+		copy(t, c)
+		for j := 0; j+i-m <= n; j++ {
+			c[j+i-m] ^= b[j]
+		}
+		if 2*l <= i {
+			l = i + 1 - l
+			m = i
+			copy(b, t)
+		}
+	}
+	return l
+}
@@ -0,0 +1,56 @@
+package nist_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+func TestBerlekampMassey(t *testing.T) {
+	for _, te := range []struct {
+		in string
+		l  int
+	}{
+		{"1101011110001", 4},
+		{"0000000000", 0},
+		{"0000000001", 10},
+		{"1111111111", 1},
+		{"1010101010", 2},
+	} {
+		if l := nist.BerlekampMassey(bits(te.in)); l != te.l {
+			t.Fatalf("%s: expected linear complexity %d, got %d", te.in, te.l, l)
+		}
+	}
+}
+
+func TestLinearComplexity(t *testing.T) {
+	// the output of a short linear feedback shift register, x^16 + x^14 + x^13 + x^11 + 1
+	var (
+		e   = make([]byte, 100000)
+		reg = uint16(0xace1)
+	)
+	for i := range e {
+		bit := (reg ^ reg>>2 ^ reg>>3 ^ reg>>5) & 0x01
+		reg = reg>>1 | bit<<15
+		e[i] = byte(bit)
+	}
+
+	if r := nist.LinearComplexity(e, 500); r.Passed {
+		t.Fatal("expected an LFSR sequence to fail, got ", r.PValues[0])
+	}
+	if r := nist.LinearComplexity(nist.Unpack(random(12500, 1)), 500); !r.Passed {
+		t.Fatal("expected a random sequence to pass, got ", r.PValues[0])
+	}
+}
+
+func TestRank(t *testing.T) {
+	// rows repeat every 16 bits, the matrices have rank one
+	e := bits(strings.Repeat("1100101011110000", 4096))
+	if r := nist.Rank(e); r.Passed {
+		t.Fatal("expected a periodic sequence to fail, got ", r.PValues[0])
+	}
+	if r := nist.Rank(nist.Unpack(random(12500, 1))); !r.Passed {
+		t.Fatal("expected a random sequence to pass, got ", r.PValues[0])
+	}
+}
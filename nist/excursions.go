@@ -0,0 +1,139 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// cycles returns the random walk of e split into cycles, that start and end at zero.
+// Each cycle holds the states visited between the zeroes.
+func cycles(e []byte) [][]int {
+	var (
+		all   [][]int
+		cycle []int
+		sum   int
+	)
+	for _, b := range e {
+		sum += 2*int(b) - 1
+		if sum == 0 {
+			all = append(all, cycle)
+			cycle = nil
+			continue
+		}
+		cycle = append(cycle, sum)
+	}
+
+	// the walk is closed with a final zero
+	if len(cycle) > 0 || len(all) == 0 {
+		all = append(all, cycle)
+	}
+	return all
+}
+
+// minCycles returns the minimum number of cycles required by the random excursion tests.
+func minCycles(n int) int {
+	m := 0.005 * math.Sqrt(float64(n))
+	if m < 500 {
+		m = 500
+	}
+	return int(math.Ceil(m))
+}
+
+// RandomExcursions is the random excursions test, it checks the number of visits
+// to the states -4..-1 and 1..4 within the cycles of the random walk of the sequence.
+// It produces one p-value per state and requires at least 500 cycles.
+func RandomExcursions(e []byte) Result {
+	const name = "RandomExcursions"
+
+	var (
+		c = cycles(e)
+		j = len(c)
+	)
+	if j < minCycles(len(e)) {
+		return skipped(name, fmt.Sprintf("%d cycles", j))
+	}
+
+	var (
+		states = []int{-4, -3, -2, -1, 1, 2, 3, 4}
+		nu     = make([][6]int, len(states))
+	)
+	for _, cycle := range c {
+		var visits [9]int
+		for _, s := range cycle {
+			if s >= -4 && s <= 4 {
+				visits[s+4]++
+			}
+		}
+		for i, x := range states {
+			v := visits[x+4]
+			if v > 5 {
+				v = 5
+			}
+			nu[i][v]++
+		}
+	}
+
+	r := Result{Name: name}
+	for i, x := range states {
+		var (
+			ax  = math.Abs(float64(x))
+			pi  [6]float64
+			chi float64
+		)
+		pi[0] = 1 - 1/(2*ax)
+		for k := 1; k < 5; k++ {
+			pi[k] = 1 / (4 * ax * ax) * math.Pow(1-1/(2*ax), float64(k-1))
+		}
+		pi[5] = 1 / (2 * ax) * math.Pow(1-1/(2*ax), 4)
+
+		for k := range pi {
+			expected := float64(j) * pi[k]
+			chi += (float64(nu[i][k]) - expected) * (float64(nu[i][k]) - expected) / expected
+		}
+
+		r.PValues = append(r.PValues, special.Igamc(5.0/2, chi/2))
+		r.Labels = append(r.Labels, fmt.Sprintf("x=%+d", x))
+	}
+
+	return r.evaluated()
+}
+
+// RandomExcursionsVariant is the random excursions variant test, it checks the total number of visits
+// to the states -9..-1 and 1..9 of the random walk of the sequence.
+// It produces one p-value per state and requires at least 500 cycles.
+func RandomExcursionsVariant(e []byte) Result {
+	const name = "RandomExcursionsVariant"
+
+	var (
+		c = cycles(e)
+		j = float64(len(c))
+	)
+	if len(c) < minCycles(len(e)) {
+		return skipped(name, fmt.Sprintf("%d cycles", len(c)))
+	}
+
+	var visits [19]int
+	for _, cycle := range c {
+		for _, s := range cycle {
+			if s >= -9 && s <= 9 {
+				visits[s+9]++
+			}
+		}
+	}
+
+	r := Result{Name: name}
+	for x := -9; x <= 9; x++ {
+		if x == 0 {
+			continue
+		}
+		ax := math.Abs(float64(x))
+		p := math.Erfc(math.Abs(float64(visits[x+9])-j) / math.Sqrt(2*j*(4*ax-2)))
+
+		r.PValues = append(r.PValues, p)
+		r.Labels = append(r.Labels, fmt.Sprintf("x=%+d", x))
+	}
+
+	return r.evaluated()
+}
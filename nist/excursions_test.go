@@ -0,0 +1,18 @@
+package nist_test
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+func TestRandomExcursions(t *testing.T) {
+	checkPValues(t, nist.RandomExcursions(expansionOfE()),
+		0.573306, 0.197996, 0.164011, 0.007779, 0.786868, 0.440912, 0.797854, 0.778186)
+}
+
+func TestRandomExcursionsVariant(t *testing.T) {
+	checkPValues(t, nist.RandomExcursionsVariant(expansionOfE()),
+		0.858946, 0.794755, 0.576249, 0.493417, 0.633873, 0.917283, 0.934708, 0.816012, 0.826009,
+		0.137861, 0.200642, 0.441254, 0.939291, 0.505683, 0.445935, 0.512207, 0.538635, 0.593930)
+}
@@ -0,0 +1,96 @@
+package nist
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// dft returns the discrete Fourier transform of x.
+// Lengths that are not a power of two are transformed with Bluestein's algorithm.
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	if n == 0 {
+		return nil
+	}
+	if n&(n-1) == 0 {
+		out := make([]complex128, n)
+		copy(out, x)
+		fft(out, false)
+		return out
+	}
+
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+
+	// chirp w[k] = exp(-iπk²/n), k² is reduced modulo 2n to keep the angle accurate
+	w := make([]complex128, n)
+	for k := range w {
+		kk := int64(k) * int64(k) % int64(2*n)
+		w[k] = cmplx.Exp(complex(0, -math.Pi*float64(kk)/float64(n)))
+	}
+
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * w[k]
+	}
+	b[0] = cmplx.Conj(w[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(w[k])
+		b[m-k] = b[k]
+	}
+
+	fft(a, false)
+	fft(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fft(a, true)
+
+	out := make([]complex128, n)
+	for k := range out {
+		out[k] = a[k] * w[k] / complex(float64(m), 0)
+	}
+	return out
+}
+
+// fft transforms x in place, the length of x must be a power of two.
+// The inverse transform is not scaled.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+
+	// bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	twiddle := make([]complex128, n/2)
+	for k := range twiddle {
+		twiddle[k] = cmplx.Exp(complex(0, sign*2*math.Pi*float64(k)/float64(n)))
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		stride := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * twiddle[k*stride]
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// Frequency is the frequency (monobit) test, it checks that the proportion of ones is close to one half.
+func Frequency(e []byte) Result {
+	const name = "Frequency"
+	if len(e) == 0 {
+		return skipped(name, "empty sequence")
+	}
+
+	var sum int
+	for _, b := range e {
+		sum += 2*int(b) - 1
+	}
+	sObs := math.Abs(float64(sum)) / math.Sqrt(float64(len(e)))
+
+	return result(name, math.Erfc(sObs/math.Sqrt2))
+}
+
+// BlockFrequency is the frequency test within blocks of m bits.
+func BlockFrequency(e []byte, m int) Result {
+	const name = "BlockFrequency"
+	n := len(e) / m
+	if m <= 0 || n == 0 {
+		return skipped(name, fmt.Sprintf("no block of %d bits", m))
+	}
+
+	var chi float64
+	for i := 0; i < n; i++ {
+		var ones int
+		for _, b := range e[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi += pi * pi
+	}
+	chi *= 4 * float64(m)
+
+	return result(name, special.Igamc(float64(n)/2, chi/2))
+}
+
+// Runs checks that the number of runs of identical bits is as expected for a random sequence.
+func Runs(e []byte) Result {
+	const name = "Runs"
+	n := float64(len(e))
+	if len(e) == 0 {
+		return skipped(name, "empty sequence")
+	}
+
+	var ones int
+	for _, b := range e {
+		ones += int(b)
+	}
+	pi := float64(ones) / n
+
+	// the frequency prerequisite failed, the test is not run and counts as failed
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(n) {
+		return result(name, 0)
+	}
+
+	v := 1
+	for k := 1; k < len(e); k++ {
+		if e[k] != e[k-1] {
+			v++
+		}
+	}
+	p := math.Erfc(math.Abs(float64(v)-2*n*pi*(1-pi)) / (2 * math.Sqrt(2*n) * pi * (1 - pi)))
+
+	return result(name, p)
+}
+
+// LongestRun is the test for the longest run of ones in a block.
+func LongestRun(e []byte) Result {
+	const name = "LongestRun"
+
+	var (
+		m  int
+		v  []int
+		pi []float64
+	)
+	switch n := len(e); {
+	case n < 128:
+		return skipped(name, "less than 128 bits")
+	case n < 6272:
+		m = 8
+		v = []int{1, 2, 3, 4}
+		pi = []float64{0.21484375, 0.3671875, 0.23046875, 0.1875}
+	case n < 750000:
+		m = 128
+		v = []int{4, 5, 6, 7, 8, 9}
+		pi = []float64{0.1174035788, 0.242955959, 0.249363483, 0.17517706, 0.102701071, 0.112398847}
+	default:
+		m = 10000
+		v = []int{10, 11, 12, 13, 14, 15, 16}
+		pi = []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}
+	}
+
+	var (
+		k  = len(v) - 1
+		n  = len(e) / m
+		nu = make([]int, len(v))
+	)
+	for i := 0; i < n; i++ {
+		var run, longest int
+		for _, b := range e[i*m : (i+1)*m] {
+			if b == 1 {
+				run++
+				if run > longest {
+					longest = run
+				}
+			} else {
+				run = 0
+			}
+		}
+		switch {
+		case longest <= v[0]:
+			nu[0]++
+		case longest >= v[k]:
+			nu[k]++
+		default:
+			nu[longest-v[0]]++
+		}
+	}
+
+	var chi float64
+	for i := range nu {
+		expected := float64(n) * pi[i]
+		chi += (float64(nu[i]) - expected) * (float64(nu[i]) - expected) / expected
+	}
+
+	return result(name, special.Igamc(float64(k)/2, chi/2))
+}
+
+// CumulativeSums is the cumulative sums test, it checks the maximal excursion of the random walk
+// of the sequence in forward and in reverse direction.
+func CumulativeSums(e []byte) Result {
+	const name = "CumulativeSums"
+	if len(e) == 0 {
+		return skipped(name, "empty sequence")
+	}
+
+	var (
+		n               = len(e)
+		sum, sup, inf   int
+		zForward, zBack int
+	)
+	for _, b := range e {
+		sum += 2*int(b) - 1
+		if sum > sup {
+			sup = sum
+		}
+		if sum < inf {
+			inf = sum
+		}
+	}
+	zForward = sup
+	if -inf > zForward {
+		zForward = -inf
+	}
+
+	// the reverse walk ends at the same sum, its extremes are the differences to the final sum
+	zBack = sum - inf
+	if sup-sum > zBack {
+		zBack = sup - sum
+	}
+
+	return Result{
+		Name:    name,
+		PValues: []float64{cusum(n, zForward), cusum(n, zBack)},
+		Labels:  []string{"forward", "reverse"},
+	}.evaluated()
+}
+
+// cusum returns the p-value for the maximal excursion z of a random walk with n steps.
+func cusum(n, z int) float64 {
+	if z == 0 {
+		return 0
+	}
+	var (
+		sqrtN = math.Sqrt(float64(n))
+		fz    = float64(z)
+		sum1  float64
+		sum2  float64
+	)
+	for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+		sum1 += special.Normal(float64(4*k+1)*fz/sqrtN) - special.Normal(float64(4*k-1)*fz/sqrtN)
+	}
+	for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+		sum2 += special.Normal(float64(4*k+3)*fz/sqrtN) - special.Normal(float64(4*k+1)*fz/sqrtN)
+	}
+	return 1 - sum1 + sum2
+}
@@ -0,0 +1,84 @@
+package nist_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+// epsilon holds the first 100 bits of the binary expansion of π, the example sequence of SP 800-22.
+const epsilon = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+
+// bits converts a string of zeroes and ones into a sequence, other characters are ignored.
+func bits(s string) []byte {
+	var e []byte
+	for _, c := range s {
+		if c == '0' || c == '1' {
+			e = append(e, byte(c-'0'))
+		}
+	}
+	return e
+}
+
+func checkPValues(t *testing.T, r nist.Result, expected ...float64) {
+	t.Helper()
+	if r.Skipped {
+		t.Fatalf("%s: skipped: %s", r.Name, r.Reason)
+	}
+	if len(r.PValues) != len(expected) {
+		t.Fatalf("%s: expected %d p-values, got %d", r.Name, len(expected), len(r.PValues))
+	}
+	for i, p := range expected {
+		if math.Abs(r.PValues[i]-p) > 1e-6 {
+			t.Fatalf("%s: expected p-value %f, got %f", r.Name, p, r.PValues[i])
+		}
+	}
+}
+
+func TestFrequency(t *testing.T) {
+	checkPValues(t, nist.Frequency(bits("1011010101")), 0.527089)
+	checkPValues(t, nist.Frequency(bits(epsilon)), 0.109599)
+}
+
+func TestBlockFrequency(t *testing.T) {
+	checkPValues(t, nist.BlockFrequency(bits("0110011010"), 3), 0.801252)
+	checkPValues(t, nist.BlockFrequency(bits(epsilon), 10), 0.706438)
+}
+
+func TestRuns(t *testing.T) {
+	checkPValues(t, nist.Runs(bits("1001101011")), 0.147232)
+	checkPValues(t, nist.Runs(bits(epsilon)), 0.500798)
+
+	// the frequency prerequisite fails
+	r := nist.Runs(bits(strings.Repeat("1", 100)))
+	if r.Passed || r.PValues[0] != 0 {
+		t.Fatal("expected the runs test to fail")
+	}
+}
+
+func TestLongestRun(t *testing.T) {
+	e := bits("11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010")
+	checkPValues(t, nist.LongestRun(e), 0.180609)
+
+	if r := nist.LongestRun(bits(epsilon)); !r.Skipped {
+		t.Fatal("expected the test to be skipped for less than 128 bits")
+	}
+}
+
+func TestCumulativeSums(t *testing.T) {
+	checkPValues(t, nist.CumulativeSums(bits("1011010111")), 0.411659, 0.411659)
+	checkPValues(t, nist.CumulativeSums(bits(epsilon)), 0.219194, 0.114866)
+}
+
+func TestSpectral(t *testing.T) {
+	// the examples of SP 800-22 were computed before the variance of N1 was corrected in rev. 1a,
+	// the value was cross-checked with a direct evaluation of the DFT
+	checkPValues(t, nist.Spectral(bits(epsilon)), 0.646355)
+
+	// a periodic sequence concentrates the spectrum in a few peaks
+	if r := nist.Spectral(bits(strings.Repeat("0011", 1000))); r.Passed {
+		t.Fatal("expected a periodic sequence to fail, got ", r.PValues[0])
+	}
+}
@@ -0,0 +1,367 @@
+// Package nist implements the statistical test suite of NIST SP 800-22 rev. 1a
+// for the evaluation of random and pseudorandom number generators.
+//
+// The tests operate on sequences of bits, stored as one byte with the value 0 or 1 per bit.
+// Run reads the sequences directly from an io.Reader, for example the output of an extractor:
+//
+//	r, _, cancel := debias.VonNeumann(reader, false)
+//	defer cancel()
+//	report, err := nist.Run(r, nist.DefaultConfig)
+package nist
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// DefaultAlpha is the significance level used by the tests when they are called directly.
+var DefaultAlpha = 0.01
+
+// Result is the outcome of a single test on one sequence.
+type Result struct {
+	Name string
+
+	// PValues holds the p-values of the test, most tests produce a single one.
+	PValues []float64
+
+	// Labels identifies the p-values of tests that produce more than one, e.g. the template or the state.
+	Labels []string
+
+	// Passed is set if the p-values pass the significance level.
+	// For tests with several p-values, the proportion of passing values must reach
+	// the minimum proportion for the significance level, see MinProportion.
+	Passed bool
+
+	// Skipped is set if the sequence does not meet the requirements of the test, Reason explains why.
+	Skipped bool
+	Reason  string
+}
+
+// evaluate sets Passed for the significance level alpha.
+func (r *Result) evaluate(alpha float64) {
+	if r.Skipped || len(r.PValues) == 0 {
+		r.Passed = false
+		return
+	}
+	var passed int
+	for _, p := range r.PValues {
+		if p >= alpha {
+			passed++
+		}
+	}
+	r.Passed = float64(passed)/float64(len(r.PValues)) >= MinProportion(alpha, len(r.PValues))
+}
+
+// evaluated returns r evaluated for DefaultAlpha.
+func (r Result) evaluated() Result {
+	r.evaluate(DefaultAlpha)
+	return r
+}
+
+// result returns the evaluated Result of a test with a single p-value.
+func result(name string, p float64) Result {
+	return Result{
+		Name:    name,
+		PValues: []float64{p},
+	}.evaluated()
+}
+
+// skipped returns the Result of a test that could not be applied to the sequence.
+func skipped(name, reason string) Result {
+	return Result{
+		Name:    name,
+		Skipped: true,
+		Reason:  reason,
+	}
+}
+
+// MinProportion returns the minimum proportion of p-values passing the significance level alpha
+// out of n samples, that is expected from a random source: (1-α) - 3·√(α(1-α)/n).
+func MinProportion(alpha float64, n int) float64 {
+	return 1 - alpha - 3*math.Sqrt(alpha*(1-alpha)/float64(n))
+}
+
+// Unpack returns the bits of data, most significant bit first.
+func Unpack(data []byte) []byte {
+	e := make([]byte, 0, len(data)*8)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			e = append(e, (b>>uint(i))&0x01)
+		}
+	}
+	return e
+}
+
+// Config holds the parameters of the suite.
+// Zero values are replaced with the values of DefaultConfig.
+type Config struct {
+
+	// SequenceLength is the number of bits per tested sequence.
+	SequenceLength int
+
+	// Sequences limits the number of tested sequences, zero tests all complete sequences of the input.
+	Sequences int
+
+	// Alpha is the significance level.
+	Alpha float64
+
+	// BlockFrequencyBlockSize is the block length M of the block frequency test.
+	BlockFrequencyBlockSize int
+
+	// NonOverlappingTemplateLength is the length m of the aperiodic templates of the non-overlapping template test.
+	NonOverlappingTemplateLength int
+
+	// OverlappingTemplateLength is the length m of the all ones template of the overlapping template test.
+	OverlappingTemplateLength int
+
+	// LinearComplexityBlockSize is the block length M of the linear complexity test.
+	LinearComplexityBlockSize int
+
+	// SerialBlockLength is the pattern length m of the serial test.
+	SerialBlockLength int
+
+	// ApproximateEntropyBlockLength is the pattern length m of the approximate entropy test.
+	ApproximateEntropyBlockLength int
+}
+
+// DefaultConfig holds the parameters recommended by SP 800-22.
+var DefaultConfig = Config{
+	SequenceLength:                1000000,
+	Alpha:                         0.01,
+	BlockFrequencyBlockSize:       128,
+	NonOverlappingTemplateLength:  9,
+	OverlappingTemplateLength:     9,
+	LinearComplexityBlockSize:     500,
+	SerialBlockLength:             16,
+	ApproximateEntropyBlockLength: 10,
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig
+	if c.SequenceLength > 0 {
+		d.SequenceLength = c.SequenceLength
+	}
+	if c.Sequences > 0 {
+		d.Sequences = c.Sequences
+	}
+	if c.Alpha > 0 {
+		d.Alpha = c.Alpha
+	}
+	if c.BlockFrequencyBlockSize > 0 {
+		d.BlockFrequencyBlockSize = c.BlockFrequencyBlockSize
+	}
+	if c.NonOverlappingTemplateLength > 0 {
+		d.NonOverlappingTemplateLength = c.NonOverlappingTemplateLength
+	}
+	if c.OverlappingTemplateLength > 0 {
+		d.OverlappingTemplateLength = c.OverlappingTemplateLength
+	}
+	if c.LinearComplexityBlockSize > 0 {
+		d.LinearComplexityBlockSize = c.LinearComplexityBlockSize
+	}
+	if c.SerialBlockLength > 0 {
+		d.SerialBlockLength = c.SerialBlockLength
+	}
+	if c.ApproximateEntropyBlockLength > 0 {
+		d.ApproximateEntropyBlockLength = c.ApproximateEntropyBlockLength
+	}
+	return d
+}
+
+// Tests returns the results of all tests of the suite for the sequence e.
+func Tests(e []byte, cfg Config) []Result {
+	cfg = cfg.withDefaults()
+
+	results := []Result{
+		Frequency(e),
+		BlockFrequency(e, cfg.BlockFrequencyBlockSize),
+		CumulativeSums(e),
+		Runs(e),
+		LongestRun(e),
+		Rank(e),
+		Spectral(e),
+		NonOverlappingTemplate(e, AperiodicTemplates(cfg.NonOverlappingTemplateLength), 8),
+		OverlappingTemplate(e, cfg.OverlappingTemplateLength),
+		Universal(e, 0, 0),
+		ApproximateEntropy(e, cfg.ApproximateEntropyBlockLength),
+		RandomExcursions(e),
+		RandomExcursionsVariant(e),
+		Serial(e, cfg.SerialBlockLength),
+		LinearComplexity(e, cfg.LinearComplexityBlockSize),
+	}
+	for i := range results {
+		results[i].evaluate(cfg.Alpha)
+	}
+	return results
+}
+
+// ErrNoData is returned by Run if the reader did not provide any data.
+var ErrNoData = errors.New("nist: no data to test")
+
+// Report holds the results of a suite run over one or more sequences.
+type Report struct {
+
+	// BitsTested is the number of bits in the tested sequences.
+	BitsTested int64
+
+	// Sequences holds the results of all tests for each sequence.
+	Sequences [][]Result
+
+	// Summary holds the analysis of each p-value over all sequences.
+	Summary []Summary
+
+	// Passed is set if all entries of the summary passed.
+	Passed bool
+}
+
+// Summary is the analysis of one p-value of a test over all sequences it was applied to.
+type Summary struct {
+	Name  string
+	Label string
+
+	// Sequences is the number of sequences the test was applied to.
+	Sequences int
+
+	// Proportion is the fraction of sequences passing the significance level,
+	// it must reach MinProportion.
+	Proportion    float64
+	MinProportion float64
+
+	// Uniformity is the p-value of the chi-square test for the uniform distribution of the p-values.
+	// It is only computed from 55 sequences upwards and must reach 0.0001, otherwise it is zero.
+	Uniformity float64
+
+	Passed bool
+}
+
+// minUniformitySequences is the number of sequences needed for the uniformity test of the p-values.
+const minUniformitySequences = 55
+
+// Run reads sequences of cfg.SequenceLength bits from r, most significant bit first,
+// and applies all tests of the suite to each of them.
+// If the input is shorter than a single sequence, the available bits are tested as one sequence,
+// otherwise a trailing incomplete sequence is ignored.
+func Run(r io.Reader, cfg Config) (*Report, error) {
+	cfg = cfg.withDefaults()
+
+	var (
+		report = new(Report)
+		br     = bufio.NewReader(r)
+	)
+	for cfg.Sequences == 0 || len(report.Sequences) < cfg.Sequences {
+		e, err := readBits(br, cfg.SequenceLength)
+		if len(e) == cfg.SequenceLength || len(e) > 0 && len(report.Sequences) == 0 && err == io.EOF {
+			report.Sequences = append(report.Sequences, Tests(e, cfg))
+			report.BitsTested += int64(len(e))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(report.Sequences) == 0 {
+		return nil, ErrNoData
+	}
+
+	report.summarize(cfg.Alpha)
+	return report, nil
+}
+
+// readBits reads n bits from r, it returns io.EOF with the bits read if r ends first.
+func readBits(r io.ByteReader, n int) ([]byte, error) {
+	e := make([]byte, 0, n)
+	for len(e)+8 <= n {
+		b, err := r.ReadByte()
+		if err != nil {
+			return e, err
+		}
+		for i := 7; i >= 0; i-- {
+			e = append(e, (b>>uint(i))&0x01)
+		}
+	}
+
+	// a sequence length that is not a multiple of eight drops the remaining bits of the last byte
+	if len(e) < n {
+		b, err := r.ReadByte()
+		if err != nil {
+			return e, err
+		}
+		for i := 7; len(e) < n; i-- {
+			e = append(e, (b>>uint(i))&0x01)
+		}
+	}
+	return e, nil
+}
+
+// summarize computes the proportion of passing sequences and the uniformity of the p-values.
+func (r *Report) summarize(alpha float64) {
+	type key struct {
+		name, label string
+	}
+	var (
+		order   []key
+		pvalues = make(map[key][]float64)
+	)
+	for _, results := range r.Sequences {
+		for _, res := range results {
+			for i, p := range res.PValues {
+				k := key{name: res.Name}
+				if i < len(res.Labels) {
+					k.label = res.Labels[i]
+				}
+				if _, ok := pvalues[k]; !ok {
+					order = append(order, k)
+				}
+				pvalues[k] = append(pvalues[k], p)
+			}
+		}
+	}
+
+	r.Passed = len(order) > 0
+	for _, k := range order {
+		var (
+			values = pvalues[k]
+			s      = Summary{
+				Name:          k.name,
+				Label:         k.label,
+				Sequences:     len(values),
+				MinProportion: MinProportion(alpha, len(values)),
+			}
+			passed int
+			bins   [10]int
+		)
+		for _, p := range values {
+			if p >= alpha {
+				passed++
+			}
+			bin := int(p * 10)
+			if bin > 9 {
+				bin = 9
+			}
+			bins[bin]++
+		}
+		s.Proportion = float64(passed) / float64(len(values))
+		s.Passed = s.Proportion >= s.MinProportion
+
+		if len(values) >= minUniformitySequences {
+			var (
+				expected = float64(len(values)) / 10
+				chi      float64
+			)
+			for _, n := range bins {
+				chi += (float64(n) - expected) * (float64(n) - expected) / expected
+			}
+			s.Uniformity = special.Igamc(9.0/2, chi/2)
+			s.Passed = s.Passed && s.Uniformity >= 0.0001
+		}
+
+		r.Summary = append(r.Summary, s)
+		r.Passed = r.Passed && s.Passed
+	}
+}
@@ -0,0 +1,144 @@
+package nist_test
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+	"github.com/dreadl0ck/debias/nist"
+)
+
+// random returns size bytes from a seeded pseudorandom generator.
+func random(size int, seed int64) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+var (
+	eOnce sync.Once
+	eBits []byte
+)
+
+// expansionOfE returns the first million bits of the binary expansion of e, starting with the integer part,
+// which is the data.e sequence of the examples of SP 800-22.
+func expansionOfE() []byte {
+	eOnce.Do(func() {
+		const n = 1000000
+
+		// e = Σ 1/k!, summed by binary splitting until k! exceeds 2^n
+		var (
+			k    = 1
+			log2 float64
+		)
+		for log2 < n+64 {
+			k++
+			log2 += math.Log2(float64(k))
+		}
+		p, q := factorialSum(0, k)
+
+		v := new(big.Int).Add(p, q)
+		v.Lsh(v, n+64)
+		v.Quo(v, q)
+		v.Rsh(v, uint(v.BitLen()-n))
+
+		eBits = make([]byte, n)
+		for i := range eBits {
+			eBits[i] = byte(v.Bit(n - 1 - i))
+		}
+	})
+	return eBits
+}
+
+// factorialSum returns p and q with p/q = Σ_{a<i<=b} a!/i!.
+func factorialSum(a, b int) (*big.Int, *big.Int) {
+	if b-a == 1 {
+		return big.NewInt(1), big.NewInt(int64(b))
+	}
+	m := (a + b) / 2
+	p1, q1 := factorialSum(a, m)
+	p2, q2 := factorialSum(m, b)
+	return p1.Add(p1.Mul(p1, q2), p2), q1.Mul(q1, q2)
+}
+
+// seed produces a sequence with enough cycles for the random excursion tests
+const seed = 2
+
+func TestSuite(t *testing.T) {
+	results := nist.Tests(nist.Unpack(random(125000, seed)), nist.DefaultConfig)
+	if len(results) != 15 {
+		t.Fatal("expected 15 tests, got ", len(results))
+	}
+	for _, r := range results {
+		if r.Skipped {
+			t.Fatalf("%s: skipped: %s", r.Name, r.Reason)
+		}
+		if !r.Passed {
+			t.Fatalf("%s: failed with p-values %v", r.Name, r.PValues)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	// the pipe of a Von Neumann extractor over biased data
+	var (
+		rnd  = rand.New(rand.NewSource(2))
+		data = make([]byte, 40000)
+	)
+	for i := range data {
+		for j := 0; j < 8; j++ {
+			if rnd.Float64() < 0.75 {
+				data[i] |= 1 << uint(j)
+			}
+		}
+	}
+	r, _, cancel := debias.VonNeumann(bytes.NewReader(data), false)
+	defer cancel()
+
+	report, err := nist.Run(r, nist.Config{SequenceLength: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sequences) != 7 || report.BitsTested != 56000 {
+		t.Fatalf("unexpected number of sequences: %d with %d bits", len(report.Sequences), report.BitsTested)
+	}
+
+	var frequency *nist.Summary
+	for i, s := range report.Summary {
+		if s.Name == "Frequency" {
+			frequency = &report.Summary[i]
+		}
+	}
+	if frequency == nil || frequency.Sequences != 7 || !frequency.Passed {
+		t.Fatalf("unexpected summary of the frequency test: %+v", frequency)
+	}
+
+	// the biased input fails
+	report, err = nist.Run(bytes.NewReader(data), nist.Config{SequenceLength: 8000, Sequences: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sequences) != 2 || report.Passed || report.Summary[0].Proportion != 0 {
+		t.Fatalf("expected the frequency test to fail: %+v", report.Summary[0])
+	}
+
+	if _, err := nist.Run(bytes.NewReader(nil), nist.DefaultConfig); err != nist.ErrNoData {
+		t.Fatal("expected ErrNoData, got ", err)
+	}
+}
+
+func TestRunUniformity(t *testing.T) {
+	report, err := nist.Run(bytes.NewReader(random(60*1000, 3)), nist.Config{SequenceLength: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range report.Summary {
+		if s.Name == "Frequency" && (s.Sequences != 60 || s.Uniformity < 0.0001 || !s.Passed) {
+			t.Fatalf("unexpected summary of the frequency test: %+v", s)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package nist
+
+import "math"
+
+// rankSize is the number of rows and columns of the matrices of the rank test.
+const rankSize = 32
+
+// Rank is the binary matrix rank test, it checks for linear dependence among
+// fixed length substrings by the rank of disjoint 32x32 matrices over GF(2).
+func Rank(e []byte) Result {
+	const name = "Rank"
+	n := len(e) / (rankSize * rankSize)
+	if n < 38 {
+		return skipped(name, "less than 38 matrices")
+	}
+
+	var full, minusOne int
+	for k := 0; k < n; k++ {
+		var matrix [rankSize]uint32
+		for i := range matrix {
+			for _, b := range e[(k*rankSize+i)*rankSize : (k*rankSize+i+1)*rankSize] {
+				matrix[i] = matrix[i]<<1 | uint32(b)
+			}
+		}
+		switch binaryRank(matrix[:]) {
+		case rankSize:
+			full++
+		case rankSize - 1:
+			minusOne++
+		}
+	}
+
+	var (
+		pFull     = rankProbability(rankSize)
+		pMinusOne = rankProbability(rankSize - 1)
+		pRest     = 1 - pFull - pMinusOne
+		fn        = float64(n)
+		rest      = fn - float64(full) - float64(minusOne)
+		chi       = (float64(full)-pFull*fn)*(float64(full)-pFull*fn)/(pFull*fn) +
+			(float64(minusOne)-pMinusOne*fn)*(float64(minusOne)-pMinusOne*fn)/(pMinusOne*fn) +
+			(rest-pRest*fn)*(rest-pRest*fn)/(pRest*fn)
+	)
+
+	return result(name, math.Exp(-chi/2))
+}
+
+// rankProbability returns the probability that a random 32x32 matrix over GF(2) has rank r.
+func rankProbability(r int) float64 {
+	p := math.Pow(2, float64(r*(2*rankSize-r)-rankSize*rankSize))
+	for i := 0; i < r; i++ {
+		q := 1 - math.Pow(2, float64(i-rankSize))
+		p *= q * q / (1 - math.Pow(2, float64(i-r)))
+	}
+	return p
+}
+
+// binaryRank returns the rank of the matrix over GF(2), the matrix is modified.
+func binaryRank(rows []uint32) int {
+	var rank int
+	for bit := uint32(1) << (rankSize - 1); bit != 0 && rank < len(rows); bit >>= 1 {
+		pivot := -1
+		for i := rank; i < len(rows); i++ {
+			if rows[i]&bit != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[rank], rows[pivot] = rows[pivot], rows[rank]
+		for i := range rows {
+			if i != rank && rows[i]&bit != 0 {
+				rows[i] ^= rows[rank]
+			}
+		}
+		rank++
+	}
+	return rank
+}
@@ -0,0 +1,99 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// patternCounts returns the number of occurrences of each pattern of m bits,
+// counted at every position of e with the sequence wrapped around at the end.
+func patternCounts(e []byte, m int) []int {
+	counts := make([]int, 1<<uint(m))
+	if m == 0 {
+		counts[0] = len(e)
+		return counts
+	}
+
+	wrapped := make([]byte, 0, len(e)+m-1)
+	wrapped = append(wrapped, e...)
+	wrapped = append(wrapped, e[:m-1]...)
+	for _, v := range windows(wrapped, m) {
+		counts[v]++
+	}
+	return counts
+}
+
+// psi2 returns the ψ² statistic of the serial test for patterns of m bits.
+func psi2(e []byte, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	var sum float64
+	for _, c := range patternCounts(e, m) {
+		sum += float64(c) * float64(c)
+	}
+	return sum*math.Pow(2, float64(m))/float64(len(e)) - float64(len(e))
+}
+
+// Serial is the serial test, it checks that all overlapping patterns of m bits are equally frequent.
+// It produces two p-values for the first and second differences of the ψ² statistic.
+// The pattern length must be less than floor(log2 n) - 2.
+func Serial(e []byte, m int) Result {
+	const name = "Serial"
+	if m < 2 || m > 24 || m >= bits.Len(uint(len(e)))-3 {
+		return skipped(name, fmt.Sprintf("pattern length %d out of range for %d bits", m, len(e)))
+	}
+
+	var (
+		psim0 = psi2(e, m)
+		psim1 = psi2(e, m-1)
+		psim2 = psi2(e, m-2)
+		del1  = psim0 - psim1
+		del2  = psim0 - 2*psim1 + psim2
+	)
+
+	return Result{
+		Name: name,
+		PValues: []float64{
+			special.Igamc(math.Pow(2, float64(m-1))/2, del1/2),
+			special.Igamc(math.Pow(2, float64(m-2))/2, del2/2),
+		},
+		Labels: []string{"first", "second"},
+	}.evaluated()
+}
+
+// phi returns the φ statistic of the approximate entropy test for patterns of m bits.
+func phi(e []byte, m int) float64 {
+	var (
+		n   = float64(len(e))
+		sum float64
+	)
+	for _, c := range patternCounts(e, m) {
+		if c > 0 {
+			p := float64(c) / n
+			sum += p * math.Log(p)
+		}
+	}
+	return sum
+}
+
+// ApproximateEntropy is the approximate entropy test, it compares the frequencies of overlapping patterns
+// of m and m+1 bits with the expected values for a random sequence.
+func ApproximateEntropy(e []byte, m int) Result {
+	const name = "ApproximateEntropy"
+	if m < 1 || m > 24 || m >= len(e) {
+		return skipped(name, fmt.Sprintf("pattern length %d out of range", m))
+	}
+
+	var (
+		n    = float64(len(e))
+		apEn = phi(e, m) - phi(e, m+1)
+		chi  = 2 * n * (math.Ln2 - apEn)
+		pv   = special.Igamc(math.Pow(2, float64(m-1)), chi/2)
+	)
+
+	return result(name, pv)
+}
@@ -0,0 +1,21 @@
+package nist_test
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+func TestSerial(t *testing.T) {
+	checkPValues(t, nist.Serial(expansionOfE(), 2), 0.843764, 0.561915)
+
+	// the short example of SP 800-22 does not meet m < floor(log2 n) - 2
+	if r := nist.Serial(bits("0011011101"), 3); !r.Skipped {
+		t.Fatal("expected the serial test to be skipped, got ", r.PValues)
+	}
+}
+
+func TestApproximateEntropy(t *testing.T) {
+	checkPValues(t, nist.ApproximateEntropy(bits("0100110101"), 3), 0.261961)
+	checkPValues(t, nist.ApproximateEntropy(bits(epsilon), 2), 0.235301)
+}
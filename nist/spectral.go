@@ -0,0 +1,36 @@
+package nist
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Spectral is the discrete Fourier transform (spectral) test, it detects periodic features
+// by the number of peaks in the spectrum that exceed the 95 % threshold.
+func Spectral(e []byte) Result {
+	const name = "Spectral"
+	if len(e) < 2 {
+		return skipped(name, "less than 2 bits")
+	}
+
+	x := make([]complex128, len(e))
+	for i, b := range e {
+		x[i] = complex(float64(2*int(b)-1), 0)
+	}
+	s := dft(x)
+
+	var (
+		n  = float64(len(e))
+		t  = math.Sqrt(math.Log(1/0.05) * n)
+		n0 = 0.95 * n / 2
+		n1 int
+	)
+	for _, v := range s[:len(e)/2] {
+		if cmplx.Abs(v) < t {
+			n1++
+		}
+	}
+	d := (float64(n1) - n0) / math.Sqrt(n*0.95*0.05/4)
+
+	return result(name, math.Erfc(math.Abs(d)/math.Sqrt2))
+}
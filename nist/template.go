@@ -0,0 +1,214 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dreadl0ck/debias/internal/special"
+)
+
+// AperiodicTemplates returns all templates of m bits that do not overlap with a shifted copy of themselves,
+// in lexicographic order. There are 148 templates for the recommended length of 9 bits.
+func AperiodicTemplates(m int) [][]byte {
+	var templates [][]byte
+	for v := 0; v < 1<<uint(m); v++ {
+		t := make([]byte, m)
+		for i := range t {
+			t[i] = byte(v>>uint(m-1-i)) & 0x01
+		}
+		if aperiodic(t) {
+			templates = append(templates, t)
+		}
+	}
+	return templates
+}
+
+// aperiodic reports whether no proper prefix of t equals the suffix of the same length.
+func aperiodic(t []byte) bool {
+	for k := 1; k < len(t); k++ {
+		if string(t[:k]) == string(t[len(t)-k:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// windows returns the values of all windows of m bits in e.
+func windows(e []byte, m int) []uint32 {
+	if len(e) < m {
+		return nil
+	}
+	var (
+		values = make([]uint32, len(e)-m+1)
+		mask   = uint32(1)<<uint(m) - 1
+		v      uint32
+	)
+	for i, b := range e {
+		v = (v<<1 | uint32(b)) & mask
+		if i >= m-1 {
+			values[i-m+1] = v
+		}
+	}
+	return values
+}
+
+// templateValue returns the template as an integer, most significant bit first.
+func templateValue(t []byte) uint32 {
+	var v uint32
+	for _, b := range t {
+		v = v<<1 | uint32(b)
+	}
+	return v
+}
+
+// templateLabel returns the template as a string of zeroes and ones.
+func templateLabel(t []byte) string {
+	var sb strings.Builder
+	for _, b := range t {
+		sb.WriteByte('0' + b)
+	}
+	return sb.String()
+}
+
+// NonOverlappingTemplate is the non-overlapping template matching test, it counts the occurrences of each template
+// in numBlocks blocks of the sequence, the search continues after the end of a match.
+// All templates must have the same length of at most 32 bits.
+// It produces one p-value per template.
+func NonOverlappingTemplate(e []byte, templates [][]byte, numBlocks int) Result {
+	const name = "NonOverlappingTemplate"
+	if len(templates) == 0 || numBlocks <= 0 {
+		return skipped(name, "no templates or blocks")
+	}
+
+	var (
+		m = len(templates[0])
+		b = len(e) / numBlocks
+	)
+	if m == 0 || m > 32 || b < m {
+		return skipped(name, fmt.Sprintf("blocks of %d bits for templates of %d bits", b, m))
+	}
+
+	var (
+		values = windows(e, m)
+		mu     = float64(b-m+1) / math.Pow(2, float64(m))
+		sigma2 = float64(b) * (1/math.Pow(2, float64(m)) - float64(2*m-1)/math.Pow(2, float64(2*m)))
+		r      = Result{Name: name}
+	)
+	for _, t := range templates {
+		if len(t) != m {
+			return skipped(name, "templates of different length")
+		}
+
+		var (
+			tv  = templateValue(t)
+			chi float64
+		)
+		for j := 0; j < numBlocks; j++ {
+			var w int
+			for i := j * b; i <= (j+1)*b-m; {
+				if values[i] == tv {
+					w++
+					i += m
+				} else {
+					i++
+				}
+			}
+			chi += (float64(w) - mu) * (float64(w) - mu) / sigma2
+		}
+
+		r.PValues = append(r.PValues, special.Igamc(float64(numBlocks)/2, chi/2))
+		r.Labels = append(r.Labels, templateLabel(t))
+	}
+
+	return r.evaluated()
+}
+
+const (
+	// overlappingBlockSize is the block length M of the overlapping template test.
+	overlappingBlockSize = 1032
+
+	// overlappingClasses is the number of degrees of freedom K of the overlapping template test.
+	overlappingClasses = 5
+
+	// overlappingPiLength is the template length the probabilities in overlappingPi are computed for.
+	overlappingPiLength = 9
+)
+
+// overlappingPi holds the corrected probabilities of 0 to 4 and at least 5 occurrences of the template of 9 bits
+// in a block of 1032 bits, as given in SP 800-22 rev. 1a.
+var overlappingPi = []float64{0.364091, 0.185659, 0.139381, 0.100571, 0.0704323, 0.139865}
+
+// OverlappingTemplate is the overlapping template matching test, it counts the overlapping occurrences
+// of the all ones template of m bits in blocks of 1032 bits.
+// The probabilities of the numbers of occurrences are the corrected values of SP 800-22 for the recommended m = 9,
+// other template lengths use the approximation of the original SP 800-22.
+func OverlappingTemplate(e []byte, m int) Result {
+	const name = "OverlappingTemplate"
+	var (
+		n = len(e) / overlappingBlockSize
+		k = overlappingClasses
+	)
+	if m <= 0 || m > 32 || n == 0 {
+		return skipped(name, fmt.Sprintf("no block of %d bits", overlappingBlockSize))
+	}
+
+	pi := overlappingPi
+	if m != overlappingPiLength {
+		var (
+			lambda = float64(overlappingBlockSize-m+1) / math.Pow(2, float64(m))
+			eta    = lambda / 2
+			sum    float64
+		)
+		pi = make([]float64, k+1)
+		for i := 0; i < k; i++ {
+			pi[i] = overlappingProbability(i, eta)
+			sum += pi[i]
+		}
+		pi[k] = 1 - sum
+	}
+
+	var (
+		values = windows(e, m)
+		ones   = uint32(1)<<uint(m) - 1
+		nu     = make([]int, k+1)
+	)
+	for j := 0; j < n; j++ {
+		var w int
+		for i := j * overlappingBlockSize; i <= (j+1)*overlappingBlockSize-m; i++ {
+			if values[i] == ones {
+				w++
+			}
+		}
+		if w > k {
+			w = k
+		}
+		nu[w]++
+	}
+
+	var chi float64
+	for i := range nu {
+		expected := float64(n) * pi[i]
+		chi += (float64(nu[i]) - expected) * (float64(nu[i]) - expected) / expected
+	}
+
+	return result(name, special.Igamc(float64(k)/2, chi/2))
+}
+
+// overlappingProbability returns the approximate probability of u occurrences of the template in a block.
+func overlappingProbability(u int, eta float64) float64 {
+	if u == 0 {
+		return math.Exp(-eta)
+	}
+	var sum float64
+	for l := 1; l <= u; l++ {
+		sum += math.Exp(-eta - float64(u)*math.Ln2 + float64(l)*math.Log(eta) -
+			lgamma(float64(l+1)) + lgamma(float64(u)) - lgamma(float64(l)) - lgamma(float64(u-l+1)))
+	}
+	return sum
+}
+
+func lgamma(x float64) float64 {
+	lg, _ := math.Lgamma(x)
+	return lg
+}
@@ -0,0 +1,29 @@
+package nist_test
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+func TestAperiodicTemplates(t *testing.T) {
+	for m, count := range map[int]int{2: 2, 3: 4, 4: 6, 9: 148, 10: 284} {
+		if n := len(nist.AperiodicTemplates(m)); n != count {
+			t.Fatalf("expected %d templates of %d bits, got %d", count, m, n)
+		}
+	}
+}
+
+func TestNonOverlappingTemplate(t *testing.T) {
+	r := nist.NonOverlappingTemplate(bits("10100100101110010110"), [][]byte{bits("001")}, 2)
+	checkPValues(t, r, 0.344154)
+	if r.Labels[0] != "001" {
+		t.Fatal("unexpected label: ", r.Labels[0])
+	}
+}
+
+func TestOverlappingTemplate(t *testing.T) {
+	// the example of SP 800-22 counts 329, 164, 150, 111, 78 and 136 blocks, its p-value 0.110434
+	// was computed with the uncorrected probabilities
+	checkPValues(t, nist.OverlappingTemplate(expansionOfE(), 9), 0.159032)
+}
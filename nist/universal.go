@@ -0,0 +1,74 @@
+package nist
+
+import (
+	"fmt"
+	"math"
+)
+
+// expected value and variance of the test statistic of Maurer's universal test for block lengths up to 16
+var (
+	universalExpected = []float64{0, 0.73264948, 1.5374383, 2.40160681, 3.31122472, 4.25342659, 5.2177052, 6.1962507,
+		7.1836656, 8.1764248, 9.1723243, 10.170032, 11.168765, 12.168070, 13.167693, 14.167488, 15.167379}
+	universalVariance = []float64{0, 0.690, 1.338, 1.901, 2.358, 2.705, 2.954, 3.125, 3.238, 3.311, 3.356, 3.384,
+		3.401, 3.410, 3.416, 3.419, 3.421}
+)
+
+// universalBlockLength returns the recommended block length for a sequence of n bits, or 0 if n is too short.
+func universalBlockLength(n int) int {
+	thresholds := []int{387840, 904960, 2068480, 4654080, 10342400, 22753280, 49643520,
+		107560960, 231669760, 496435200, 1059061760}
+
+	l := 0
+	for i, t := range thresholds {
+		if n >= t {
+			l = 6 + i
+		}
+	}
+	return l
+}
+
+// Universal is Maurer's universal statistical test, it checks whether the sequence can be compressed
+// by the distances between matching patterns of l bits.
+// The first q blocks initialize the table of last occurrences.
+// If l is zero, the block length recommended for the sequence length is used with q = 10·2^l.
+func Universal(e []byte, l, q int) Result {
+	const name = "Universal"
+	if l == 0 {
+		l = universalBlockLength(len(e))
+		if l == 0 {
+			return skipped(name, "less than 387840 bits")
+		}
+		q = 10 << uint(l)
+	}
+	if l < 1 || l >= len(universalExpected) {
+		return skipped(name, fmt.Sprintf("block length %d out of range", l))
+	}
+
+	k := len(e)/l - q
+	if q <= 0 || k <= 0 {
+		return skipped(name, "no blocks to test")
+	}
+
+	var (
+		table = make([]int, 1<<uint(l))
+		sum   float64
+	)
+	for i := 1; i <= q+k; i++ {
+		var dec int
+		for _, b := range e[(i-1)*l : i*l] {
+			dec = dec<<1 | int(b)
+		}
+		if i > q {
+			sum += math.Log2(float64(i - table[dec]))
+		}
+		table[dec] = i
+	}
+
+	var (
+		fn    = sum / float64(k)
+		c     = 0.7 - 0.8/float64(l) + (4+32/float64(l))*math.Pow(float64(k), -3/float64(l))/15
+		sigma = c * math.Sqrt(universalVariance[l]/float64(k))
+	)
+
+	return result(name, math.Erfc(math.Abs(fn-universalExpected[l])/(math.Sqrt2*sigma)))
+}
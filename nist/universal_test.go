@@ -0,0 +1,11 @@
+package nist_test
+
+import (
+	"testing"
+
+	"github.com/dreadl0ck/debias/nist"
+)
+
+func TestUniversal(t *testing.T) {
+	checkPValues(t, nist.Universal(expansionOfE(), 7, 1280), 0.282568)
+}
@@ -0,0 +1,205 @@
+package debias
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dreadl0ck/debias/bias"
+	"github.com/dreadl0ck/debias/entropy"
+	"github.com/dreadl0ck/debias/iq"
+	"github.com/dreadl0ck/debias/minentropy"
+	"github.com/dreadl0ck/debias/wav"
+)
+
+// Option configures the processing of File and Directory.
+type Option func(*options)
+
+type options struct {
+	minEntropy *minentropy.Config
+	health     *HealthConfig
+	analysis   bool
+	profile    *entropy.ProfileConfig
+	bias       *bias.Config
+	wav        *wav.Config
+	iq         *iq.Config
+	raw        bool
+	format     string
+	bitPlanes  *BitPlaneConfig
+	derivation *DerivationConfig
+	progress   io.Writer
+	padding    Padding
+	extractor  ExtractorConfig
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{progress: os.Stdout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMinEntropy estimates the min-entropy per bit of the input and the output of each file
+// with the non-IID estimators of SP 800-90B, using samples of cfg.Width bits.
+// Only the first cfg.MaxSamples samples of the input and the output are evaluated.
+// A zero width uses the width of minentropy.DefaultConfig.
+func WithMinEntropy(cfg minentropy.Config) Option {
+	if cfg.Width == 0 {
+		cfg.Width = minentropy.DefaultConfig.Width
+	}
+	return func(o *options) {
+		o.minEntropy = &cfg
+	}
+}
+
+// WithHealthTests runs the continuous health tests on the input of each file,
+// a failure stops processing with an error matching ErrHealth unless cfg.OnFailure is set.
+func WithHealthTests(cfg HealthConfig) Option {
+	return func(o *options) {
+		o.health = &cfg
+	}
+}
+
+// WithAnalysis computes the ent statistics of the input and the output of each file.
+func WithAnalysis() Option {
+	return func(o *options) {
+		o.analysis = true
+	}
+}
+
+// WithEntropyProfile computes the Shannon and min-entropy over windows of the input and the output of each file,
+// zero values of cfg are taken from entropy.DefaultProfileConfig.
+func WithEntropyProfile(cfg entropy.ProfileConfig) Option {
+	return func(o *options) {
+		o.profile = &cfg
+	}
+}
+
+// WithBias measures the bit bias and autocorrelation of the input of each file.
+func WithBias(cfg bias.Config) Option {
+	return func(o *options) {
+		o.bias = &cfg
+	}
+}
+
+// WithWAV selects the channels passed on from WAV files, by default all channels are used.
+func WithWAV(cfg wav.Config) Option {
+	return func(o *options) {
+		o.wav = &cfg
+	}
+}
+
+// WithIQ selects the component passed on from IQ captures, by default I and Q are interleaved.
+// A zero format is taken from the file extension, .cu8, .cs8, .cs16 or .cf32,
+// any other format decodes all files as IQ captures in this format, regardless of their extension.
+func WithIQ(cfg iq.Config) Option {
+	return func(o *options) {
+		o.iq = &cfg
+	}
+}
+
+// iqFormat returns the explicitly configured IQ format, zero if the format is taken from the file extension.
+func (o *options) iqFormat() iq.Format {
+	if o.iq == nil {
+		return 0
+	}
+	return o.iq.Format
+}
+
+// WithBitPlanes passes only the selected bits of each input sample to the extractor.
+// It is applied to the decoded sample payload, the analyses of the input are computed before the selection.
+func WithBitPlanes(cfg BitPlaneConfig) Option {
+	return func(o *options) {
+		o.bitPlanes = &cfg
+	}
+}
+
+// WithDerivation derives the bits passed to the extractor from the values of the input samples,
+// instead of passing on the bits of the samples. It replaces the bit plane selection.
+func WithDerivation(cfg DerivationConfig) Option {
+	return func(o *options) {
+		o.derivation = &cfg
+	}
+}
+
+// WithRaw disables the decoding of known file formats, all files are processed as plain bytes.
+func WithRaw() Option {
+	return func(o *options) {
+		o.raw = true
+	}
+}
+
+// WithExtractor passes cfg to the extractor, to set the seed and the assumed min-entropy of seeded extractors.
+func WithExtractor(cfg ExtractorConfig) Option {
+	return func(o *options) {
+		o.extractor = cfg
+	}
+}
+
+// WithPadding sets how the extractor handles a partially filled output byte at the end of each file,
+// for algorithms that implement Padder. The default is PadZeroes.
+func WithPadding(p Padding) Option {
+	return func(o *options) {
+		o.padding = p
+	}
+}
+
+// WithProgress writes the progress messages to w instead of stdout.
+func WithProgress(w io.Writer) Option {
+	return func(o *options) {
+		o.progress = w
+	}
+}
+
+// WithFormat decodes all files in the format of the extension ext, .wav, .cu8, .cs8, .cs16 or .cf32,
+// regardless of their own extension.
+func WithFormat(ext string) Option {
+	return func(o *options) {
+		o.format = strings.ToLower(ext)
+		if !strings.HasPrefix(o.format, ".") {
+			o.format = "." + o.format
+		}
+	}
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// sampleBytes returns the number of bytes holding the samples evaluated for cfg.
+func sampleBytes(cfg minentropy.Config) int {
+	if cfg.MaxSamples <= 0 {
+		return int(^uint(0) >> 1)
+	}
+	return (cfg.MaxSamples*cfg.Width + 7) / 8
+}
+
+// minEntropyPerBit returns the assessed min-entropy per bit of data.
+func minEntropyPerBit(data []byte, cfg minentropy.Config) (float64, error) {
+	samples, err := minentropy.Samples(data, cfg.Width)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.MaxSamples > 0 && len(samples) > cfg.MaxSamples {
+		samples = samples[:cfg.MaxSamples]
+	}
+	r, err := minentropy.NonIID(samples, cfg.Width)
+	if err != nil {
+		return 0, err
+	}
+	return r.PerBit, nil
+}
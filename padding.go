@@ -0,0 +1,25 @@
+package debias
+
+// Padding controls how a partially filled output byte is handled at the end of the input.
+type Padding int
+
+const (
+	// PadZeroes writes the partially filled byte with trailing zero bits.
+	// The padding bits are not random, use BitsOut to determine the number of valid bits.
+	PadZeroes Padding = iota
+
+	// DropPartial discards the bits that do not fill a complete output byte.
+	DropPartial
+)
+
+// Padder is implemented by algorithms that support configuring the Padding.
+type Padder interface {
+	SetPadding(p Padding)
+}
+
+// BitCounter is implemented by algorithms and extractors that track the exact number of output bits.
+type BitCounter interface {
+
+	// BitsOut returns the number of valid output bits produced so far, padding bits are not counted.
+	BitsOut() int64
+}
@@ -0,0 +1,140 @@
+package debias_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// neumannBits applies Von Neumann debiasing to a bit string, without any padding.
+func neumannBits(in string) string {
+	var out strings.Builder
+	for i := 0; i+1 < len(in); i += 2 {
+		if in[i] != in[i+1] {
+			out.WriteByte(in[i])
+		}
+	}
+	return out.String()
+}
+
+func TestPadding(t *testing.T) {
+	for i, te := range tests {
+
+		// only complete bytes are used as input by bitString
+		var (
+			in       = te.in[:len(te.in)/8*8]
+			bits     = neumannBits(in)
+			complete = bits[:len(bits)/8*8]
+		)
+
+		if !strings.HasPrefix(te.out, bits) {
+			t.Fatalf("test #%d: reference output %s does not match %s", i, bits, te.out)
+		}
+
+		for _, p := range []debias.Padding{debias.PadZeroes, debias.DropPartial} {
+
+			r := debias.NewVonNeumann(bytes.NewReader(bitString(in).bytes()))
+			r.SetPadding(p)
+
+			out, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				expected = bitString(complete).bytes()
+				numBits  = len(complete)
+			)
+			if p == debias.PadZeroes && len(bits) > len(complete) {
+				expected = append(expected, bitString(bits[len(complete):]+strings.Repeat("0", 8-(len(bits)-len(complete)))).bytes()...)
+				numBits = len(bits)
+			}
+
+			if r.BitsOut() != int64(numBits) {
+				t.Fatalf("test #%d, padding %d: expected %d valid bits but got %d", i, p, numBits, r.BitsOut())
+			}
+
+			if !bytes.Equal(out, expected) {
+				t.Fatalf("test #%d, padding %d: expected %08b but got %08b", i, p, expected, out)
+			}
+		}
+	}
+}
+
+func TestPaddingWriter(t *testing.T) {
+	var out bytes.Buffer
+
+	w := debias.NewVonNeumannWriter(&out)
+	w.SetPadding(debias.DropPartial)
+
+	// 7 output bits
+	_, err := w.Write(bitString("10101010101001").bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Len() != 0 || w.BitsOut() != 0 {
+		t.Fatalf("expected no output, got %08b and %d bits", out.Bytes(), w.BitsOut())
+	}
+}
+
+func TestStatsBitsOut(t *testing.T) {
+	// 10011001 01 yields 1010 0, all other bytes are discarded
+	s, _ := runFile(t, "in.bin", bitString("1001100101000000").bytes(), debias.ModeVonNeumann)
+	if s.BytesOut != 1 || s.BitsOut != 5 {
+		t.Fatal("expected 1 byte with 5 valid bits, got ", s.BytesOut, " bytes and ", s.BitsOut, " bits")
+	}
+}
+
+func TestKaminskyBitsOut(t *testing.T) {
+	data := biased(1000, 0.7, 4)
+
+	for _, p := range []debias.Padding{debias.PadZeroes, debias.DropPartial} {
+		vn := debias.NewVonNeumann(bytes.NewReader(data))
+		vn.SetPadding(p)
+		if _, err := ioutil.ReadAll(vn); err != nil {
+			t.Fatal(err)
+		}
+
+		k, err := debias.NewKaminsky(bytes.NewReader(data), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.SetPadding(p)
+		out, err := ioutil.ReadAll(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the encrypted bits are the Von Neumann output, the padding of the ciphertext is not counted
+		if k.BitsOut() != vn.BitsOut() {
+			t.Fatalf("padding %d: expected %d bits but got %d", p, vn.BitsOut(), k.BitsOut())
+		}
+		if int64(len(out)) <= (k.BitsOut()+7)/8 || len(out)%16 != 0 {
+			t.Fatalf("padding %d: unexpected ciphertext of %d bytes for %d bits", p, len(out), k.BitsOut())
+		}
+	}
+}
+
+// biased returns size bytes where each bit is set with probability p.
+func biased(size int, p float64, seed int64) []byte {
+	var (
+		rnd  = rand.New(rand.NewSource(seed))
+		data = make([]byte, size)
+	)
+	for i := range data {
+		for j := 0; j < 8; j++ {
+			if rnd.Float64() < p {
+				data[i] |= 1 << j
+			}
+		}
+	}
+	return data
+}
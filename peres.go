@@ -0,0 +1,137 @@
+package debias
+
+import (
+	"bytes"
+	"io"
+)
+
+// DefaultPeresDepth is the recursion depth of the Peres extractor registered for ModePeres.
+var DefaultPeresDepth = 8
+
+// peresBlockSize is the number of input bytes the Peres procedure is applied to at once.
+const peresBlockSize = 1024
+
+func init() {
+	Register(ModePeres, "peres", func(r io.Reader) Extractor {
+		return NewPeres(r, DefaultPeresDepth)
+	})
+}
+
+// NewPeres returns an Extractor applying the iterated Von Neumann procedure by Yuval Peres
+// to the data read from r, recursing at most depth levels.
+//
+// For each block of input bits x the output is Ψ(x) = VN(x) || Ψ(u) || Ψ(v), where
+// - VN(x) is the Von Neumann output for the bit pairs of x
+// - u is the sequence of the XOR of each pair
+// - v is the sequence of the values of the discarded "00" and "11" pairs
+//
+// With a depth of zero the output is identical to Von Neumann debiasing,
+// with increasing depth the yield approaches the Shannon entropy of the input.
+func NewPeres(r io.Reader, depth int) *Reader {
+	return NewReader(r, newPeres(depth))
+}
+
+// peres implements the iterated Von Neumann procedure.
+type peres struct {
+	depth   int
+	out     bitWriter
+	padding Padding
+
+	// bits of the current block, one bit per byte
+	block []byte
+
+	// scratch buffers for the XOR and discarded sequences of each level
+	xors     [][]byte
+	discards [][]byte
+
+	// number of bits produced by the first level, which is plain Von Neumann debiasing
+	vnBits int64
+}
+
+func newPeres(depth int) *peres {
+	if depth < 0 {
+		depth = 0
+	}
+	return &peres{
+		depth:    depth,
+		block:    make([]byte, 0, peresBlockSize*8),
+		xors:     make([][]byte, depth+1),
+		discards: make([][]byte, depth+1),
+	}
+}
+
+func (p *peres) Process(data []byte, out *bytes.Buffer) error {
+	for _, b := range data {
+		for j := 7; j >= 0; j-- {
+			p.block = append(p.block, (b>>j)&0x01)
+		}
+		if len(p.block) == cap(p.block) {
+			p.extract(p.block, p.depth, out)
+			p.block = p.block[:0]
+		}
+	}
+	return nil
+}
+
+func (p *peres) Flush(out *bytes.Buffer) error {
+	p.extract(p.block, p.depth, out)
+	p.block = p.block[:0]
+
+	// write leftover
+	p.out.flush(out, p.padding)
+	return nil
+}
+
+// extract applies the procedure to bits and recurses on the XOR and discarded sequences.
+func (p *peres) extract(bits []byte, depth int, out *bytes.Buffer) {
+	if len(bits) < 2 {
+		return
+	}
+
+	var (
+		xors     = p.xors[depth][:0]
+		discards = p.discards[depth][:0]
+	)
+
+	for i := 0; i+1 < len(bits); i += 2 {
+
+		ch, ch2 := bits[i], bits[i+1]
+
+		if ch != ch2 {
+			p.out.writeBit(ch, out)
+			if depth == p.depth {
+				p.vnBits++
+			}
+		} else {
+			discards = append(discards, ch)
+		}
+
+		xors = append(xors, ch^ch2)
+	}
+
+	// keep the grown buffers for the next block
+	p.xors[depth] = xors
+	p.discards[depth] = discards
+
+	if depth == 0 {
+		return
+	}
+
+	p.extract(xors, depth-1, out)
+	p.extract(discards, depth-1, out)
+}
+
+func (p *peres) SetPadding(pd Padding) {
+	p.padding = pd
+}
+
+func (p *peres) BitsOut() int64 {
+	return p.out.bits
+}
+
+// ReportStats sets the gain over plain Von Neumann debiasing.
+func (p *peres) ReportStats(s *Stats) {
+	if p.vnBits > 0 {
+		s.Gain = float64(p.BitsOut()) / float64(p.vnBits)
+	}
+}
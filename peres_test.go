@@ -0,0 +1,96 @@
+package debias_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// peresBits applies the iterated Von Neumann procedure to a bit string, without any padding.
+func peresBits(in string, depth int) string {
+	if len(in) < 2 {
+		return ""
+	}
+
+	var xors, discards strings.Builder
+	for i := 0; i+1 < len(in); i += 2 {
+		if in[i] == in[i+1] {
+			discards.WriteByte(in[i])
+			xors.WriteByte('0')
+		} else {
+			xors.WriteByte('1')
+		}
+	}
+
+	out := neumannBits(in)
+	if depth == 0 {
+		return out
+	}
+	return out + peresBits(xors.String(), depth-1) + peresBits(discards.String(), depth-1)
+}
+
+func TestPeres(t *testing.T) {
+	for i, te := range tests {
+		for _, depth := range []int{0, 1, 2, 4, 8} {
+
+			var (
+				in       = te.in[:len(te.in)/8*8]
+				bits     = peresBits(in, depth)
+				complete = bits[:len(bits)/8*8]
+			)
+
+			r := debias.NewPeres(bytes.NewReader(bitString(in).bytes()), depth)
+			r.SetPadding(debias.DropPartial)
+
+			out, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(out, bitString(complete).bytes()) {
+				t.Fatalf("test #%d, depth %d: expected %s but got %08b", i, depth, complete, out)
+			}
+			if r.BitsOut() != int64(len(complete)) {
+				t.Fatalf("test #%d, depth %d: expected %d bits but got %d", i, depth, len(complete), r.BitsOut())
+			}
+
+			// the output always starts with the Von Neumann output
+			if !strings.HasPrefix(bits, neumannBits(in)) {
+				t.Fatalf("test #%d, depth %d: %s does not start with the Von Neumann output", i, depth, bits)
+			}
+		}
+
+		// depth zero is plain Von Neumann debiasing
+		peres, err := ioutil.ReadAll(debias.NewPeres(bytes.NewReader(bitString(te.in).bytes()), 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(peres, bitString(te.out).bytes()) {
+			t.Fatalf("test #%d: expected %s but got %08b", i, te.out, peres)
+		}
+	}
+}
+
+func TestPeresStats(t *testing.T) {
+	// p = 0.8: Von Neumann yields 0.16 bits per input bit, the entropy is 0.72
+	data := biased(100000, 0.8, 1)
+
+	neumann, _ := runFile(t, "in.bin", data, debias.ModeVonNeumann)
+	peres, _ := runFile(t, "in.bin", data, debias.ModePeres)
+
+	if neumann.Efficiency < 0.15 || neumann.Efficiency > 0.17 {
+		t.Fatal("unexpected Von Neumann efficiency: ", neumann.Efficiency)
+	}
+	if peres.Efficiency < 0.6 || peres.Efficiency > 0.73 {
+		t.Fatal("unexpected Peres efficiency: ", peres.Efficiency)
+	}
+	if peres.Gain < 3.5 {
+		t.Fatal("expected a gain over Von Neumann, got ", peres.Gain)
+	}
+	if neumann.Gain != 0 {
+		t.Fatal("unexpected gain for Von Neumann: ", neumann.Gain)
+	}
+}
@@ -0,0 +1,97 @@
+package debias
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// pipe runs alg in a goroutine over the bytes read from reader and streams the output
+// through an io.Pipe in chunks of MaxChunkSize.
+// If wait is set, reading continues after io.EOF until the context is cancelled.
+// Any other source error closes the pipe with a *SourceError after the pending output has been written.
+func pipe(reader io.ByteReader, wait bool, alg Algorithm) (*io.PipeReader, context.Context, context.CancelFunc) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		pr, pw      = io.Pipe()
+
+		in       = make([]byte, 1)
+		outBuf   bytes.Buffer
+		numBytes int64
+	)
+
+	// finish writes the leftover and closes the pipe with the given cause, nil results in io.EOF.
+	finish := func(cause error) {
+		defer cancel()
+
+		if numBytes == 0 && cause == nil {
+			pw.CloseWithError(ErrShortInput)
+			return
+		}
+
+		err := alg.Flush(&outBuf)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		_, err = pw.Write(outBuf.Bytes())
+		if err != nil {
+			return
+		}
+
+		pw.CloseWithError(cause)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				finish(nil)
+				return
+			default:
+				b, err := reader.ReadByte()
+				if err != nil {
+					if err != io.EOF {
+						finish(&SourceError{Offset: numBytes, Err: err})
+						return
+					}
+					if !wait {
+						finish(nil)
+						return
+					}
+					continue
+				}
+				numBytes++
+
+				in[0] = b
+				err = alg.Process(in, &outBuf)
+				if err != nil {
+					pw.CloseWithError(err)
+					cancel()
+					return
+				}
+
+				for outBuf.Len() >= MaxChunkSize {
+					_, err = pw.Write(outBuf.Next(MaxChunkSize))
+					if err != nil {
+						// reader has been closed
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return pr, ctx, cancel
+}
+
+// failedPipe returns a pipe that fails the first read with err, for algorithms that could not be created.
+func failedPipe(err error) (*io.PipeReader, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	pw.CloseWithError(err)
+	cancel()
+	return pr, ctx, cancel
+}
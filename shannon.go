@@ -0,0 +1,75 @@
+package debias
+
+import "math"
+
+// Entropy accumulates the byte frequencies of the data written to it and computes its Shannon entropy.
+// The data itself is not retained, so it can be fed chunk by chunk, e.g. with io.Copy from an extractor.
+type Entropy struct {
+	counts [256]int64
+	total  int64
+}
+
+// Write adds the bytes of p to the frequencies, it never returns an error.
+func (e *Entropy) Write(p []byte) (int, error) {
+	for _, b := range p {
+		e.counts[b]++
+	}
+	e.total += int64(len(p))
+	return len(p), nil
+}
+
+// Bytes returns the number of bytes written.
+func (e *Entropy) Bytes() int64 {
+	return e.total
+}
+
+// BitsPerByte returns the Shannon entropy in bits per byte, between 0 and 8.
+func (e *Entropy) BitsPerByte() float64 {
+	if e.total == 0 {
+		return 0
+	}
+	var (
+		n   = float64(e.total)
+		sum float64
+	)
+	for _, c := range e.counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		sum -= p * math.Log2(p)
+	}
+	return sum
+}
+
+// BitsPerBit returns the Shannon entropy in bits per bit of data, between 0 and 1.
+func (e *Entropy) BitsPerBit() float64 {
+	return e.BitsPerByte() / 8
+}
+
+// TotalBits returns the Shannon entropy of all bytes written, in bits.
+func (e *Entropy) TotalBits() float64 {
+	return e.BitsPerByte() * float64(e.total)
+}
+
+// Reset clears the frequencies.
+func (e *Entropy) Reset() {
+	*e = Entropy{}
+}
+
+// EntropyBitsPerByte returns the Shannon entropy of data in bits per byte.
+func EntropyBitsPerByte(data []byte) float64 {
+	var e Entropy
+	e.Write(data)
+	return e.BitsPerByte()
+}
+
+// EntropyBitsPerBit returns the Shannon entropy of data in bits per bit.
+func EntropyBitsPerBit(data []byte) float64 {
+	return EntropyBitsPerByte(data) / 8
+}
+
+// EntropyTotalBits returns the Shannon entropy of data in bits.
+func EntropyTotalBits(data []byte) float64 {
+	return EntropyBitsPerByte(data) * float64(len(data))
+}
@@ -0,0 +1,80 @@
+package debias_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func TestEntropyBitsPerByte(t *testing.T) {
+	uniform := make([]byte, 256*16)
+	for i := range uniform {
+		uniform[i] = byte(i)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want float64
+	}{
+		{"empty", nil, 0},
+		{"constant", bytes.Repeat([]byte{0x41}, 100), 0},
+		{"two values", bytes.Repeat([]byte{0, 1}, 50), 1},
+		{"four values", bytes.Repeat([]byte{0, 1, 2, 3}, 50), 2},
+		{"uniform", uniform, 8},
+		// p = 1/4, 3/4
+		{"skewed", []byte{1, 0, 0, 0}, 0.8112781244591328},
+	}
+	for _, tt := range tests {
+		got := debias.EntropyBitsPerByte(tt.data)
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: got %v bits per byte, want %v", tt.name, got, tt.want)
+		}
+		if got := debias.EntropyBitsPerBit(tt.data); math.Abs(got-tt.want/8) > 1e-12 {
+			t.Errorf("%s: got %v bits per bit, want %v", tt.name, got, tt.want/8)
+		}
+		if got := debias.EntropyTotalBits(tt.data); math.Abs(got-tt.want*float64(len(tt.data))) > 1e-9 {
+			t.Errorf("%s: got %v total bits, want %v", tt.name, got, tt.want*float64(len(tt.data)))
+		}
+	}
+}
+
+func TestEntropyStreaming(t *testing.T) {
+	data := biased(100000, 0.8, 1)
+
+	var e debias.Entropy
+	for i := 0; i < len(data); i += 777 {
+		end := i + 777
+		if end > len(data) {
+			end = len(data)
+		}
+		e.Write(data[i:end])
+	}
+	if e.Bytes() != int64(len(data)) {
+		t.Fatal("unexpected number of bytes: ", e.Bytes())
+	}
+	if e.BitsPerByte() != debias.EntropyBitsPerByte(data) {
+		t.Fatal("streaming result differs: ", e.BitsPerByte(), debias.EntropyBitsPerByte(data))
+	}
+
+	// the output of the extractor is read without buffering it
+	pr, _, _ := debias.VonNeumann(bytes.NewReader(data), false)
+	var out debias.Entropy
+	if _, err := io.Copy(&out, pr); err != nil {
+		t.Fatal(err)
+	}
+	if out.Bytes() == 0 || out.BitsPerBit() < 0.99 {
+		t.Fatal("entropy of the debiased output too low: ", out.BitsPerBit())
+	}
+	if out.BitsPerBit() <= e.BitsPerBit() {
+		t.Fatal("debiasing did not increase the entropy: ", e.BitsPerBit(), out.BitsPerBit())
+	}
+
+	e.Reset()
+	if e.Bytes() != 0 || e.TotalBits() != 0 {
+		t.Fatal("reset did not clear the frequencies")
+	}
+}
@@ -0,0 +1,139 @@
+package debias
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// sourceBuffer reads from a source in a goroutine and buffers up to a fixed number of chunks,
+// so that a source is drained at its own rate while the extractor waits for another one.
+type sourceBuffer struct {
+	chunks  chan []byte
+	done    chan struct{}
+	pending []byte
+
+	// err is set before chunks is closed
+	err error
+
+	// number of bytes read from the source and handed to the extractor, accessed atomically
+	bytesRead int64
+	bytesUsed int64
+
+	// number of bytes currently and at most buffered, accessed atomically
+	buffered    int64
+	maxBuffered int64
+}
+
+// defaultSourceChunks is the number of chunks buffered per source by default.
+const defaultSourceChunks = 64
+
+// newSourceBuffer starts reading src in chunks of MaxChunkSize, buffering at most numChunks chunks.
+func newSourceBuffer(src io.Reader, numChunks int) *sourceBuffer {
+	if numChunks < 1 {
+		numChunks = 1
+	}
+
+	s := &sourceBuffer{
+		chunks: make(chan []byte, numChunks),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.chunks)
+
+		for {
+			buf := make([]byte, MaxChunkSize)
+			n, err := src.Read(buf)
+			if n > 0 {
+				atomic.AddInt64(&s.bytesRead, int64(n))
+				buffered := atomic.AddInt64(&s.buffered, int64(n))
+				if buffered > atomic.LoadInt64(&s.maxBuffered) {
+					atomic.StoreInt64(&s.maxBuffered, buffered)
+				}
+
+				select {
+				case s.chunks <- buf[:n]:
+				case <-s.done:
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					s.err = &SourceError{Offset: atomic.LoadInt64(&s.bytesRead), Err: err}
+				} else {
+					s.err = io.EOF
+				}
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// read fills p from the buffered chunks and returns the number of bytes copied.
+// It returns io.EOF if the source ended before p could be filled, or the error of the source.
+// The bytes are not counted as used, see use.
+func (s *sourceBuffer) read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			chunk, ok := <-s.chunks
+			if !ok {
+				return n, s.err
+			}
+			s.pending = chunk
+		}
+
+		c := copy(p[n:], s.pending)
+		s.pending = s.pending[c:]
+		n += c
+
+		atomic.AddInt64(&s.buffered, -int64(c))
+	}
+	return n, nil
+}
+
+// readFull fills p from the buffered chunks.
+// It returns io.EOF if the source ended before p could be filled, or the error of the source.
+// Only the bytes of a completely filled p are counted as used.
+func (s *sourceBuffer) readFull(p []byte) error {
+	n, err := s.read(p)
+	if err != nil {
+		return err
+	}
+	s.use(n)
+	return nil
+}
+
+// unread returns p to the front of the buffer, so that it is returned by the next read.
+func (s *sourceBuffer) unread(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	s.pending = append(append(make([]byte, 0, len(p)+len(s.pending)), p...), s.pending...)
+	atomic.AddInt64(&s.buffered, int64(len(p)))
+}
+
+// use counts n bytes as consumed by the extractor.
+func (s *sourceBuffer) use(n int) {
+	atomic.AddInt64(&s.bytesUsed, int64(n))
+}
+
+// stop ends the reading goroutine, a read that is already in progress on the source completes first.
+func (s *sourceBuffer) stop() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// stats returns the statistics for the source.
+func (s *sourceBuffer) stats() SourceStats {
+	return SourceStats{
+		BytesRead:   atomic.LoadInt64(&s.bytesRead),
+		BytesUsed:   atomic.LoadInt64(&s.bytesUsed),
+		MaxBuffered: atomic.LoadInt64(&s.maxBuffered),
+	}
+}
@@ -1,10 +1,96 @@
 package debias
 
-import "time"
+import (
+	"time"
+
+	"github.com/dreadl0ck/debias/bias"
+	"github.com/dreadl0ck/debias/ent"
+	"github.com/dreadl0ck/debias/entropy"
+	"github.com/dreadl0ck/debias/iq"
+	"github.com/dreadl0ck/debias/wav"
+)
 
 type Stats struct {
 	FileName string
+
+	// BytesIn is the number of input bytes, for decoded files only the sample payload is counted.
 	BytesIn  int64
 	BytesOut int64
+
+	// BitsUsed is the number of input bits passed to the extractor after the bit plane selection or derivation,
+	// it is only set when requested with WithBitPlanes or WithDerivation.
+	BitsUsed int64
+
+	// Derivation is the name of the bit derivation strategy, if requested with WithDerivation.
+	Derivation string
+
+	// BitsOut is the number of valid output bits, padding bits are not counted.
+	BitsOut int64
+
+	// Efficiency is the ratio of valid output bits to input bits,
+	// or to BitsUsed if the bits passed to the extractor are selected with WithBitPlanes or WithDerivation.
+	Efficiency float64
+
+	// Gain is the ratio of valid output bits to the bits plain Von Neumann debiasing
+	// would have produced, it is only set by extractors that improve on Von Neumann.
+	Gain float64
+
 	Duration time.Duration
+
+	// Seed is the seed of seeded extractors, so the run can be reproduced with ExtractorConfig.Seed.
+	// SeedSource is SeedSourceRandom, SeedSourceConfig or the name of the seed file.
+	Seed       []byte
+	SeedSource string
+
+	// Sources holds the statistics for each input of multi-source extractors.
+	Sources []SourceStats
+
+	// Fallback is set if a multi-source extractor continued with fewer sources after one ran dry,
+	// FallbackBytes is the number of output bytes produced with fewer sources.
+	Fallback      bool
+	FallbackBytes int64
+
+	// MinEntropyIn and MinEntropyOut are the min-entropy per bit of the input and output
+	// assessed with the SP 800-90B estimators, they are only set when requested with WithMinEntropy.
+	MinEntropyIn  float64
+	MinEntropyOut float64
+
+	// AnalysisIn and AnalysisOut hold the ent statistics of the input and output,
+	// they are only set when requested with WithAnalysis.
+	AnalysisIn  *ent.Report
+	AnalysisOut *ent.Report
+
+	// ProfileIn and ProfileOut hold the windowed entropy of the input and output,
+	// they are only set when requested with WithEntropyProfile.
+	ProfileIn  *entropy.Profile
+	ProfileOut *entropy.Profile
+
+	// Bias holds the bit bias of the input, it is only set when requested with WithBias.
+	// Its PredictedYield can be compared to the Efficiency of Von Neumann debiasing.
+	Bias *bias.Report
+
+	// WAV holds the header and the selected channels of a decoded WAV file.
+	WAV *wav.Info
+
+	// IQ holds the sample format and the selected component of a decoded IQ capture.
+	IQ *iq.Config
+}
+
+// SourceStats holds the statistics for one input of a multi-source extractor.
+type SourceStats struct {
+
+	// BytesRead is the number of bytes read from the source.
+	BytesRead int64
+
+	// BytesUsed is the number of bytes consumed by the extractor,
+	// the difference to BytesRead has been buffered but not used.
+	BytesUsed int64
+
+	// MaxBuffered is the maximum number of bytes buffered while waiting for the other sources.
+	MaxBuffered int64
+}
+
+// StatsReporter is implemented by algorithms and extractors that add details to the Stats of a run.
+type StatsReporter interface {
+	ReportStats(s *Stats)
 }
@@ -0,0 +1,225 @@
+package debias
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	// DefaultToeplitzBlockSize is the input block size in bits of the Toeplitz extractor registered for ModeToeplitz.
+	DefaultToeplitzBlockSize = 1024
+
+	// DefaultToeplitzMinEntropy is the min-entropy per input bit assumed by the extractor registered for ModeToeplitz,
+	// unless it is set in the ExtractorConfig.
+	DefaultToeplitzMinEntropy = 0.5
+
+	// DefaultToeplitzEpsilon is the distance from uniform of the output of the extractor registered for ModeToeplitz,
+	// unless it is set in the ExtractorConfig.
+	DefaultToeplitzEpsilon = math.Exp2(-64)
+)
+
+func init() {
+	RegisterConfig(ModeToeplitz, "toeplitz", func(r io.Reader, cfg ExtractorConfig) Extractor {
+		if cfg.MinEntropy == 0 {
+			cfg.MinEntropy = DefaultToeplitzMinEntropy
+		}
+		if cfg.Epsilon == 0 {
+			cfg.Epsilon = DefaultToeplitzEpsilon
+		}
+		var (
+			n = DefaultToeplitzBlockSize
+			m = ToeplitzOutputSize(n, cfg.MinEntropy, cfg.Epsilon)
+		)
+
+		seed, source, err := cfg.seed(ToeplitzSeedSize(n, m))
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+
+		t, err := newToeplitz(seed, n, m)
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+		t.source = source
+		return NewReader(r, t)
+	})
+}
+
+// ToeplitzOutputSize returns the number of output bits for an input block of n bits
+// with the given min-entropy per bit, so that the output is epsilon close to uniform.
+// Following the leftover hash lemma this is n * minEntropy - 2 * log2(1/epsilon).
+func ToeplitzOutputSize(n int, minEntropy, epsilon float64) int {
+	m := math.Floor(float64(n)*minEntropy + 2*math.Log2(epsilon))
+	if m < 0 {
+		return 0
+	}
+	return int(m)
+}
+
+// ToeplitzSeedSize returns the number of seed bytes required for an n x m Toeplitz matrix.
+func ToeplitzSeedSize(n, m int) int {
+	return (n + m - 1 + 7) / 8
+}
+
+// GenerateToeplitzSeed reads a seed for an n x m Toeplitz matrix from crypto/rand.
+func GenerateToeplitzSeed(n, m int) ([]byte, error) {
+	seed := make([]byte, ToeplitzSeedSize(n, m))
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: seed: %v", ErrRandom, err)
+	}
+	return seed, nil
+}
+
+// LoadToeplitzSeed reads a seed for an n x m Toeplitz matrix from the beginning of file.
+func LoadToeplitzSeed(file string, n, m int) ([]byte, error) {
+	return readSeed(file, ToeplitzSeedSize(n, m))
+}
+
+// NewToeplitz returns an Extractor hashing blocks of n input bits read from r into m output bits,
+// by multiplying them with the Toeplitz matrix defined by seed over GF(2).
+// The seed must contain at least n + m - 1 bits, see ToeplitzSeedSize,
+// and n must be a multiple of eight. Bits that do not fill a complete block at the end of the input are discarded.
+//
+// The multiplication is bit-sliced: the columns of the matrix are precomputed as 64 bit words
+// and combined for every four input bits, which results in m / 256 word operations per input bit.
+func NewToeplitz(r io.Reader, seed []byte, n, m int) (*Reader, error) {
+	t, err := newToeplitz(seed, n, m)
+	if err != nil {
+		return nil, err
+	}
+	t.source = SeedSourceConfig
+	return NewReader(r, t), nil
+}
+
+// toeplitz implements the Toeplitz hashing extractor.
+type toeplitz struct {
+	n, m    int
+	words   int
+	out     bitWriter
+	padding Padding
+
+	// the seed and where it came from, for the stats
+	seed   []byte
+	source string
+
+	// XOR of the matrix columns for every nibble position and value
+	table []uint64
+
+	block []byte
+	acc   []uint64
+}
+
+func newToeplitz(seed []byte, n, m int) (*toeplitz, error) {
+	if n <= 0 || n%8 != 0 || m <= 0 || m > n {
+		return nil, fmt.Errorf("%w: toeplitz matrix %d x %d", ErrInvalidSize, n, m)
+	}
+	if len(seed) < ToeplitzSeedSize(n, m) {
+		return nil, fmt.Errorf("%w: seed has %d bytes, need %d", ErrShortInput, len(seed), ToeplitzSeedSize(n, m))
+	}
+
+	t := &toeplitz{
+		n:     n,
+		m:     m,
+		words: (m + 63) / 64,
+		block: make([]byte, 0, n/8),
+		seed:  seed[:ToeplitzSeedSize(n, m)],
+	}
+	t.acc = make([]uint64, t.words)
+	t.table = make([]uint64, n/4*16*t.words)
+
+	// column j of T[i][j] = seed[i+n-1-j] is the m bit window of the seed starting at n-1-j
+	column := make([]uint64, t.words)
+	for j := 0; j < n; j++ {
+		for w := range column {
+			column[w] = 0
+		}
+		for i := 0; i < m; i++ {
+			k := i + n - 1 - j
+			if (seed[k/8]>>(7-uint(k%8)))&0x01 == 1 {
+				column[i/64] |= 1 << (63 - uint(i%64))
+			}
+		}
+
+		// add the column to all nibble values with the bit for j set
+		var (
+			pos = j / 4
+			bit = 3 - j%4
+		)
+		for v := 0; v < 16; v++ {
+			if (v>>uint(bit))&0x01 == 0 {
+				continue
+			}
+			entry := t.table[(pos*16+v)*t.words:]
+			for w, c := range column {
+				entry[w] ^= c
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func (t *toeplitz) Process(p []byte, out *bytes.Buffer) error {
+	for len(p) > 0 {
+		n := cap(t.block) - len(t.block)
+		if n > len(p) {
+			n = len(p)
+		}
+		t.block = append(t.block, p[:n]...)
+		p = p[n:]
+
+		if len(t.block) == cap(t.block) {
+			t.hash(out)
+			t.block = t.block[:0]
+		}
+	}
+	return nil
+}
+
+func (t *toeplitz) Flush(out *bytes.Buffer) error {
+	// incomplete blocks are discarded
+	t.block = t.block[:0]
+
+	// write leftover
+	t.out.flush(out, t.padding)
+	return nil
+}
+
+// hash multiplies the current block with the matrix and writes the m output bits.
+func (t *toeplitz) hash(out *bytes.Buffer) {
+	for w := range t.acc {
+		t.acc[w] = 0
+	}
+
+	for i, b := range t.block {
+		var (
+			hi = t.table[((2*i)*16+int(b>>4))*t.words:]
+			lo = t.table[((2*i+1)*16+int(b&0x0f))*t.words:]
+		)
+		for w := range t.acc {
+			t.acc[w] ^= hi[w] ^ lo[w]
+		}
+	}
+
+	for i := 0; i < t.m; i++ {
+		t.out.writeBit(byte(t.acc[i/64]>>(63-uint(i%64)))&0x01, out)
+	}
+}
+
+func (t *toeplitz) SetPadding(p Padding) {
+	t.padding = p
+}
+
+func (t *toeplitz) BitsOut() int64 {
+	return t.out.bits
+}
+
+// ReportStats sets the seed and its source.
+func (t *toeplitz) ReportStats(s *Stats) {
+	s.Seed = t.seed
+	s.SeedSource = t.source
+}
@@ -0,0 +1,178 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// toeplitzBits multiplies the n bit blocks of in with the Toeplitz matrix T[i][j] = seed[i+n-1-j].
+func toeplitzBits(in, seed []byte, n, m int) []byte {
+	bit := func(data []byte, k int) byte {
+		return (data[k/8] >> (7 - uint(k%8))) & 0x01
+	}
+
+	var out []byte
+	for block := 0; block+n <= len(in)*8; block += n {
+		for i := 0; i < m; i++ {
+			var y byte
+			for j := 0; j < n; j++ {
+				y ^= bit(seed, i+n-1-j) & bit(in, block+j)
+			}
+			out = append(out, y)
+		}
+	}
+	return out
+}
+
+func TestToeplitz(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+
+	for _, te := range []struct{ n, m int }{
+		{8, 1},
+		{64, 20},
+		{128, 64},
+		{1024, 500},
+	} {
+		var (
+			in   = biased(te.n/8*3+5, 0.7, rnd.Int63())
+			seed = make([]byte, debias.ToeplitzSeedSize(te.n, te.m))
+		)
+		rnd.Read(seed)
+
+		r, err := debias.NewToeplitz(bytes.NewReader(in), seed, te.n, te.m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := toeplitzBits(in, seed, te.n, te.m)
+		if r.BitsOut() != int64(len(expected)) {
+			t.Fatalf("%d x %d: expected %d bits but got %d", te.n, te.m, len(expected), r.BitsOut())
+		}
+		for i, b := range expected {
+			if (out[i/8]>>(7-uint(i%8)))&0x01 != b {
+				t.Fatalf("%d x %d: unexpected output bit %d", te.n, te.m, i)
+			}
+		}
+	}
+}
+
+func TestToeplitzSeed(t *testing.T) {
+	if m := debias.ToeplitzOutputSize(1024, 0.5, math.Exp2(-32)); m != 448 {
+		t.Fatal("unexpected output size: ", m)
+	}
+	if m := debias.ToeplitzOutputSize(128, 0.1, math.Exp2(-32)); m != 0 {
+		t.Fatal("unexpected output size: ", m)
+	}
+
+	seed, err := debias.GenerateToeplitzSeed(1024, 448)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seed) != 184 {
+		t.Fatal("unexpected seed size: ", len(seed))
+	}
+
+	file := filepath.Join(t.TempDir(), "seed.bin")
+	err = ioutil.WriteFile(file, seed, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := debias.LoadToeplitzSeed(file, 1024, 448)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(seed, loaded) {
+		t.Fatal("loaded seed differs")
+	}
+
+	if _, err = debias.LoadToeplitzSeed(file, 2048, 448); !errors.Is(err, debias.ErrShortInput) {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+	if _, err = debias.NewToeplitz(bytes.NewReader(nil), seed, 1020, 448); !errors.Is(err, debias.ErrInvalidSize) {
+		t.Fatal("expected ErrInvalidSize, got ", err)
+	}
+}
+
+// checkSeededMode checks that the seed of a seeded mode is reported in the stats,
+// and that the runs can be reproduced from it.
+func checkSeededMode(t *testing.T, mode debias.Mode, data []byte) {
+	t.Helper()
+
+	s, out := runFile(t, "in.bin", data, mode)
+	if s.SeedSource != debias.SeedSourceRandom || len(s.Seed) == 0 || s.BitsOut == 0 {
+		t.Fatalf("unexpected stats: seed %q of %d bytes, %d bits", s.SeedSource, len(s.Seed), s.BitsOut)
+	}
+
+	again, repeated := runFile(t, "in.bin", data, mode, debias.WithExtractor(debias.ExtractorConfig{Seed: s.Seed}))
+	if again.SeedSource != debias.SeedSourceConfig || !bytes.Equal(out, repeated) {
+		t.Fatal("output not reproduced from the reported seed")
+	}
+
+	file := filepath.Join(t.TempDir(), "seed.bin")
+	err := ioutil.WriteFile(file, s.Seed, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, repeated = runFile(t, "in.bin", data, mode, debias.WithExtractor(debias.ExtractorConfig{SeedFile: file}))
+	if again.SeedSource != file || !bytes.Equal(out, repeated) {
+		t.Fatal("output not reproduced from the seed file")
+	}
+
+	// less min-entropy per input bit results in less output, with a shorter seed
+	again, _ = runFile(t, "in.bin", data, mode, debias.WithExtractor(debias.ExtractorConfig{MinEntropy: 0.3, Seed: s.Seed}))
+	if again.BitsOut == 0 || again.BitsOut >= s.BitsOut {
+		t.Fatal("unexpected output for a lower min-entropy: ", again.BitsOut, " bits")
+	}
+}
+
+func TestToeplitzMode(t *testing.T) {
+	checkSeededMode(t, debias.ModeToeplitz, biased(16*1024, 0.8, 12))
+}
+
+func BenchmarkToeplitz(b *testing.B) {
+	var (
+		data = biased(1<<20, 0.7, 9)
+		n    = debias.DefaultToeplitzBlockSize
+		m    = debias.ToeplitzOutputSize(n, debias.DefaultToeplitzMinEntropy, debias.DefaultToeplitzEpsilon)
+	)
+
+	seed, err := debias.GenerateToeplitzSeed(n, m)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		r, err := debias.NewToeplitz(bytes.NewReader(data), seed, n, m)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err = ioutil.ReadAll(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVonNeumann(b *testing.B) {
+	data := biased(1<<20, 0.7, 9)
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ioutil.ReadAll(debias.NewVonNeumann(bytes.NewReader(data))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,617 @@
+package debias
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	// DefaultTrevisanBlockSize is the input block size in bits of the Trevisan extractor registered for ModeTrevisan.
+	DefaultTrevisanBlockSize = 8192
+
+	// DefaultTrevisanMinEntropy is the min-entropy per input bit assumed by the extractor registered for ModeTrevisan,
+	// unless it is set in the ExtractorConfig.
+	DefaultTrevisanMinEntropy = 0.5
+
+	// DefaultTrevisanEpsilon is the error of the extractor registered for ModeTrevisan,
+	// unless it is set in the ExtractorConfig.
+	DefaultTrevisanEpsilon = math.Exp2(-32)
+)
+
+func init() {
+	RegisterConfig(ModeTrevisan, "trevisan", func(r io.Reader, cfg ExtractorConfig) Extractor {
+		if cfg.MinEntropy == 0 {
+			cfg.MinEntropy = DefaultTrevisanMinEntropy
+		}
+		if cfg.Epsilon == 0 {
+			cfg.Epsilon = DefaultTrevisanEpsilon
+		}
+		t, err := NewTrevisan(TrevisanConfig{
+			BlockSize:  DefaultTrevisanBlockSize,
+			MinEntropy: cfg.MinEntropy,
+			Epsilon:    cfg.Epsilon,
+			Seed:       cfg.Seed,
+			SeedFile:   cfg.SeedFile,
+		})
+		if err != nil {
+			return &errExtractor{err: err}
+		}
+		return NewReader(r, t)
+	})
+}
+
+// OneBitExtractor is the one-bit extractor used by the Trevisan construction.
+type OneBitExtractor interface {
+
+	// SeedLength returns the number of seed bits t.
+	SeedLength() int
+
+	// MinEntropy returns the min-entropy in bits the input needs to have.
+	MinEntropy() float64
+
+	// ExtractBit returns the output bit for the input block x and the seed bits, one bit per byte.
+	ExtractBit(x []byte, seed []byte) byte
+}
+
+// LinearOneBitExtractor is implemented by one-bit extractors whose output for a fixed seed
+// is the parity of a subset of the input bits, like the polynomial hash and the XOR code.
+// The Trevisan extractor precomputes the subsets once for its seed,
+// so that each output bit takes n / 64 word operations.
+type LinearOneBitExtractor interface {
+	OneBitExtractor
+
+	// Mask returns the subset of the n input bits selected by the seed bits, one bit per byte,
+	// as a bit mask packed into 64 bit words, most significant bit first.
+	Mask(n int, seed []byte) []uint64
+}
+
+// maxTrevisanMaskBytes limits the memory of the precomputed masks of a LinearOneBitExtractor,
+// larger extractors evaluate the one-bit extractor for every output bit.
+const maxTrevisanMaskBytes = 64 << 20
+
+// OneBitFunc creates a one-bit extractor for inputs of n bits
+// with the given min-entropy per bit and error epsilon.
+type OneBitFunc func(n int, minEntropy, epsilon float64) (OneBitExtractor, error)
+
+// WeakDesign is the weak design used by the Trevisan construction.
+type WeakDesign interface {
+
+	// Sets returns m sets of t indices into the seed, and the seed length d.
+	Sets(m, t int) ([][]int, int)
+
+	// Overlap returns the overlap parameter r of the design.
+	Overlap() float64
+}
+
+// TrevisanConfig holds the parameters of the Trevisan extractor.
+type TrevisanConfig struct {
+
+	// BlockSize is the input block size n in bits, it must be a multiple of eight.
+	BlockSize int
+
+	// MinEntropy is the min-entropy per input bit.
+	MinEntropy float64
+
+	// Epsilon is the maximum distance of the output from uniform.
+	Epsilon float64
+
+	// OneBit creates the one-bit extractor, defaults to NewPolynomialHash.
+	OneBit OneBitFunc
+
+	// Design is the weak design, defaults to BlockDesign.
+	Design WeakDesign
+
+	// Seed is the seed for the design. If it is empty, the seed is read from the beginning of SeedFile,
+	// or from crypto/rand if no seed file is set either.
+	Seed     []byte
+	SeedFile string
+}
+
+// Trevisan is an Algorithm implementing the seeded strong extractor by Luca Trevisan (2001),
+// in the modular form by Mauerer, Portmann and Scholz (2012).
+//
+// The i-th output bit for an input block x is C(x, y|S_i), where C is a one-bit extractor
+// and y|S_i is the seed y restricted to the i-th set S_i of a weak design.
+// If C is a (k, ε')-strong extractor and the design has the overlap r,
+// this results in a (k + rm + log(1/ε'), mε')-strong extractor.
+// The output length m is derived from this for the configured min-entropy and error.
+//
+// The extractor is strong, so the seed is reused for all blocks.
+// Bits that do not fill a complete block at the end of the input are discarded.
+type Trevisan struct {
+	n, m    int
+	oneBit  OneBitExtractor
+	sets    [][]int
+	seed    []byte
+	source  string
+	out     bitWriter
+	padding Padding
+
+	block []byte
+	bits  []byte
+
+	// input masks of the output bits for a LinearOneBitExtractor, and the block packed into words
+	masks [][]uint64
+	words []uint64
+}
+
+// NewTrevisan creates a Trevisan extractor for the given configuration.
+func NewTrevisan(cfg TrevisanConfig) (*Trevisan, error) {
+	n := cfg.BlockSize
+	if n <= 0 || n%8 != 0 || cfg.MinEntropy <= 0 || cfg.MinEntropy > 1 || cfg.Epsilon <= 0 || cfg.Epsilon >= 1 {
+		return nil, fmt.Errorf("%w: trevisan block size %d, min-entropy %f, epsilon %g", ErrInvalidSize, n, cfg.MinEntropy, cfg.Epsilon)
+	}
+	if cfg.OneBit == nil {
+		cfg.OneBit = NewPolynomialHash
+	}
+	if cfg.Design == nil {
+		cfg.Design = BlockDesign{}
+	}
+
+	var (
+		k      = float64(n) * cfg.MinEntropy
+		r      = cfg.Design.Overlap()
+		m      = int(k / r)
+		oneBit OneBitExtractor
+		err    error
+	)
+
+	// m appears on both sides of k >= k' + rm + log(1/ε'), with ε' = ε/m:
+	// iterate until the output length is stable
+	for i := 0; i < 32 && m > 0; i++ {
+		epsilon := cfg.Epsilon / float64(m)
+
+		oneBit, err = cfg.OneBit(n, cfg.MinEntropy, epsilon)
+		if err != nil {
+			return nil, err
+		}
+
+		next := int(math.Floor((k - oneBit.MinEntropy() - math.Log2(1/epsilon)) / r))
+		if next == m {
+			break
+		}
+		m = next
+	}
+	if m <= 0 || oneBit == nil {
+		return nil, fmt.Errorf("%w: not enough min-entropy for any output", ErrInvalidSize)
+	}
+
+	t := &Trevisan{
+		n:      n,
+		m:      m,
+		oneBit: oneBit,
+		block:  make([]byte, 0, n/8),
+		bits:   make([]byte, oneBit.SeedLength()),
+	}
+
+	var d int
+	t.sets, d = cfg.Design.Sets(m, oneBit.SeedLength())
+
+	t.seed, t.source, err = ExtractorConfig{Seed: cfg.Seed, SeedFile: cfg.SeedFile}.seed((d + 7) / 8)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.seed)*8 < d {
+		return nil, fmt.Errorf("%w: seed has %d bytes, need %d", ErrShortInput, len(t.seed), (d+7)/8)
+	}
+
+	words := (n + 63) / 64
+	if lin, ok := oneBit.(LinearOneBitExtractor); ok && m*words*8 <= maxTrevisanMaskBytes {
+		t.words = make([]uint64, words)
+		t.masks = make([][]uint64, m)
+		for i, set := range t.sets {
+			t.setBits(set)
+			t.masks[i] = lin.Mask(n, t.bits)
+		}
+	}
+
+	return t, nil
+}
+
+// OutputSize returns the number of output bits per input block.
+func (t *Trevisan) OutputSize() int {
+	return t.m
+}
+
+// Seed returns the seed used for the design.
+func (t *Trevisan) Seed() []byte {
+	return t.seed
+}
+
+func (t *Trevisan) Process(p []byte, out *bytes.Buffer) error {
+	for len(p) > 0 {
+		n := cap(t.block) - len(t.block)
+		if n > len(p) {
+			n = len(p)
+		}
+		t.block = append(t.block, p[:n]...)
+		p = p[n:]
+
+		if len(t.block) == cap(t.block) {
+			t.extract(out)
+			t.block = t.block[:0]
+		}
+	}
+	return nil
+}
+
+func (t *Trevisan) Flush(out *bytes.Buffer) error {
+	// incomplete blocks are discarded
+	t.block = t.block[:0]
+
+	// write leftover
+	t.out.flush(out, t.padding)
+	return nil
+}
+
+// extract writes the m output bits for the current block.
+func (t *Trevisan) extract(out *bytes.Buffer) {
+	if t.masks != nil {
+		for w := range t.words {
+			t.words[w] = 0
+		}
+		for i, b := range t.block {
+			t.words[i/8] |= uint64(b) << (56 - 8*uint(i%8))
+		}
+		for _, mask := range t.masks {
+			var v uint64
+			for w, m := range mask {
+				v ^= t.words[w] & m
+			}
+			t.out.writeBit(byte(parity(v)), out)
+		}
+		return
+	}
+
+	for _, set := range t.sets {
+		t.setBits(set)
+		t.out.writeBit(t.oneBit.ExtractBit(t.block, t.bits), out)
+	}
+}
+
+// setBits restricts the seed to the set, into the seed bits of the one-bit extractor.
+func (t *Trevisan) setBits(set []int) {
+	for j, k := range set {
+		t.bits[j] = (t.seed[k/8] >> (7 - uint(k%8))) & 0x01
+	}
+}
+
+func (t *Trevisan) SetPadding(p Padding) {
+	t.padding = p
+}
+
+func (t *Trevisan) BitsOut() int64 {
+	return t.out.bits
+}
+
+// ReportStats sets the seed and its source.
+func (t *Trevisan) ReportStats(s *Stats) {
+	s.Seed = t.seed
+	s.SeedSource = t.source
+}
+
+// polynomialHash is the one-bit extractor based on the concatenation of a Reed-Solomon and a Hadamard code.
+type polynomialHash struct {
+	field      gfField
+	minEntropy float64
+}
+
+// NewPolynomialHash returns a one-bit extractor that splits the input into l bit chunks c_i,
+// evaluates the polynomial p(α) = Σ c_i α^i over GF(2^l) and outputs the inner product of p(α) and r.
+// The seed consists of α and r, the field size is l = log2(n) + 2 log2(2/ε), rounded up to 32, 64 or 128 bits.
+// The required min-entropy is 3 log2(2/ε) bits.
+func NewPolynomialHash(n int, minEntropy, epsilon float64) (OneBitExtractor, error) {
+	l := math.Ceil(math.Log2(float64(n)) + 2*math.Log2(2/epsilon))
+
+	for _, f := range gfFields {
+		if float64(f.bits) >= l {
+			return &polynomialHash{
+				field:      f,
+				minEntropy: 3 * math.Log2(2/epsilon),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: polynomial hash needs a field of %d bits", ErrInvalidSize, int(l))
+}
+
+func (p *polynomialHash) SeedLength() int {
+	return 2 * p.field.bits
+}
+
+func (p *polynomialHash) MinEntropy() float64 {
+	return p.minEntropy
+}
+
+func (p *polynomialHash) ExtractBit(x []byte, seed []byte) byte {
+	var (
+		l     = p.field.bits
+		alpha = gfFromBits(seed[:l])
+		r     = gfFromBits(seed[l:])
+		size  = l / 8
+		chunk [16]byte
+		res   gfElement
+	)
+
+	// evaluate with Horner's rule, the first chunk is the highest coefficient
+	for i := 0; i*size < len(x); i++ {
+		for j := range chunk[:size] {
+			chunk[j] = 0
+		}
+		copy(chunk[:size], x[i*size:])
+
+		res = p.field.mul(res, alpha)
+		if l == 128 {
+			res.hi ^= binary.BigEndian.Uint64(chunk[:8])
+			res.lo ^= binary.BigEndian.Uint64(chunk[8:])
+		} else if l == 64 {
+			res.lo ^= binary.BigEndian.Uint64(chunk[:8])
+		} else {
+			res.lo ^= uint64(binary.BigEndian.Uint32(chunk[:4]))
+		}
+	}
+
+	return byte(parity(res.hi&r.hi ^ res.lo&r.lo))
+}
+
+// Mask returns the input bits whose parity is the output bit for seed.
+//
+// Horner's rule multiplies the chunk i of N with β = α^(N-1-i), so the coefficient of x^k in the chunk
+// contributes <β x^k, r> = Σ_j β_j s_(j+k), with s_t = <x^t, r>. The weights of the coefficients of a chunk
+// are therefore a linear function of β, with the image of x^j holding s_j to s_(j+l-1).
+// Both this function and the multiplication with α are evaluated with tables.
+func (p *polynomialHash) Mask(n int, seed []byte) []uint64 {
+	var (
+		l      = p.field.bits
+		alpha  = gfFromBits(seed[:l])
+		r      = gfFromBits(seed[l:])
+		chunks = (n + l - 1) / l
+		mask   = make([]uint64, (chunks*l+63)/64)
+	)
+
+	// s_t for t < 2l - 1, packed into words with s_0 as the lowest bit
+	var s [4]uint64
+	e := gfElement{lo: 1}
+	for t := 0; t < 2*l-1; t++ {
+		s[t/64] |= uint64(parity(e.hi&r.hi^e.lo&r.lo)) << uint(t%64)
+		e = p.field.mulX(e)
+	}
+
+	var (
+		weights  = make([]gfElement, l)
+		products = make([]gfElement, l)
+		a        = alpha
+	)
+	for j := range weights {
+		weights[j] = gfElement{lo: bitsAt(s[:], j), hi: bitsAt(s[:], j+64)}
+		if l < 128 {
+			weights[j].hi = 0
+			if l < 64 {
+				weights[j].lo &= 1<<uint(l) - 1
+			}
+		}
+		products[j] = a
+		a = p.field.mulX(a)
+	}
+	var (
+		weight   = newGFTable(weights)
+		multiply = newGFTable(products)
+		beta     = gfElement{lo: 1}
+	)
+
+	// the weight of x^k belongs to bit l-1-k of the chunk, so the weights are the chunk mask read as a number
+	for i := chunks - 1; i >= 0; i-- {
+		w := weight.apply(beta)
+		switch l {
+		case 128:
+			mask[2*i] = w.hi
+			mask[2*i+1] = w.lo
+		case 64:
+			mask[i] = w.lo
+		default:
+			mask[i/2] |= w.lo << uint(32*(1-i%2))
+		}
+		beta = multiply.apply(beta)
+	}
+
+	// chunks are padded with zeroes
+	mask = mask[:(n+63)/64]
+	if n%64 != 0 {
+		mask[len(mask)-1] &^= 1<<uint(64-n%64) - 1
+	}
+	return mask
+}
+
+// bitsAt returns the 64 bits of s starting at bit pos, with s[0] holding the lowest bits.
+func bitsAt(s []uint64, pos int) uint64 {
+	var (
+		w = pos / 64
+		b = uint(pos % 64)
+		v uint64
+	)
+	if w < len(s) {
+		v = s[w] >> b
+	}
+	if b > 0 && w+1 < len(s) {
+		v |= s[w+1] << (64 - b)
+	}
+	return v
+}
+
+// xorCode is the one-bit extractor that outputs the XOR of input bits at positions selected by the seed.
+type xorCode struct {
+	positions  int
+	indexBits  int
+	n          int
+	minEntropy float64
+}
+
+// NewXORCode returns a one-bit extractor that outputs the XOR of l input bits,
+// with the positions taken from the seed as log2(n) bit indices.
+// The number of positions is chosen so the bias of the XOR is below ε for independent bits
+// with the given min-entropy, which applies to the output of the debiasing algorithms,
+// and the required min-entropy is log2(1/ε) bits.
+func NewXORCode(n int, minEntropy, epsilon float64) (OneBitExtractor, error) {
+	positions := 1
+	if minEntropy < 1 {
+		// the bias of a single bit is 2^(1-h) - 1, the bias of the XOR of l bits is its l-th power
+		positions = int(math.Ceil(math.Log2(epsilon) / math.Log2(math.Exp2(1-minEntropy)-1)))
+	}
+
+	indexBits := 1
+	for 1<<uint(indexBits) < n {
+		indexBits++
+	}
+
+	return &xorCode{
+		positions:  positions,
+		indexBits:  indexBits,
+		n:          n,
+		minEntropy: math.Log2(1 / epsilon),
+	}, nil
+}
+
+func (c *xorCode) SeedLength() int {
+	return c.positions * c.indexBits
+}
+
+func (c *xorCode) MinEntropy() float64 {
+	return c.minEntropy
+}
+
+func (c *xorCode) ExtractBit(x []byte, seed []byte) byte {
+	var out byte
+	for i := 0; i < c.positions; i++ {
+		var pos int
+		for _, b := range seed[i*c.indexBits : (i+1)*c.indexBits] {
+			pos = pos<<1 | int(b)
+		}
+		pos %= c.n
+		out ^= (x[pos/8] >> (7 - uint(pos%8))) & 0x01
+	}
+	return out
+}
+
+// Mask returns the input bits whose parity is the output bit for seed, positions selected twice cancel out.
+func (c *xorCode) Mask(n int, seed []byte) []uint64 {
+	mask := make([]uint64, (n+63)/64)
+	for i := 0; i < c.positions; i++ {
+		var pos int
+		for _, b := range seed[i*c.indexBits : (i+1)*c.indexBits] {
+			pos = pos<<1 | int(b)
+		}
+		pos %= c.n
+		mask[pos/64] ^= 1 << (63 - uint(pos%64))
+	}
+	return mask
+}
+
+// PolynomialDesign is the weak design by Nisan and Wigderson based on polynomials over GF(2^k).
+// For a field size q >= 2t, the i-th set is {a * q + p_i(a) | a < t},
+// where the coefficients of the polynomial p_i are the base q digits of i.
+// Two polynomials of degree c share at most c points, the seed length is d = t * q.
+type PolynomialDesign struct{}
+
+// Overlap returns 2e.
+func (PolynomialDesign) Overlap() float64 {
+	return 2 * math.E
+}
+
+func (PolynomialDesign) Sets(m, t int) ([][]int, int) {
+	return polynomialDesign(m, t, 0)
+}
+
+// polynomialDesign creates m sets of the polynomial design for t, with indices starting at offset.
+func polynomialDesign(m, t, offset int) ([][]int, int) {
+	var (
+		k = 1
+		q = 2
+	)
+	for q < 2*t {
+		k++
+		q <<= 1
+	}
+
+	// degree such that there are enough polynomials for m sets
+	c := 0
+	for num := q; num < m; num *= q {
+		c++
+	}
+
+	var (
+		f    = gfSmall{bits: uint(k), poly: gfSmallPolys[k]}
+		sets = make([][]int, m)
+	)
+	for i := range sets {
+
+		// coefficients of p_i
+		coeffs := make([]uint32, c+1)
+		for j, v := 0, i; j <= c; j++ {
+			coeffs[j] = uint32(v % q)
+			v /= q
+		}
+
+		set := make([]int, t)
+		for a := 0; a < t; a++ {
+			var y uint32
+			for j := c; j >= 0; j-- {
+				y = f.mul(y, uint32(a)) ^ coeffs[j]
+			}
+			set[a] = offset + a*q + int(y)
+		}
+		sets[i] = set
+	}
+
+	return sets, t * q
+}
+
+// BlockDesign is a weak design with overlap 1 following Hartman and Raz,
+// built from polynomial designs on separate parts of the seed.
+// The block sizes decrease geometrically so that the overlap of the sets in each block
+// is covered by the sets in the following blocks. Blocks that fit into the field are disjoint.
+type BlockDesign struct{}
+
+// Overlap returns 1.
+func (BlockDesign) Overlap() float64 {
+	return 1
+}
+
+func (BlockDesign) Sets(m, t int) ([][]int, int) {
+	var (
+		r    = PolynomialDesign{}.Overlap()
+		sets = make([][]int, 0, m)
+		d    int
+	)
+
+	q := 2
+	for q < 2*t {
+		q <<= 1
+	}
+
+	for remaining := m; remaining > 0; {
+		size := int(math.Ceil(float64(remaining) / r))
+		if remaining <= q {
+			size = remaining
+		}
+
+		block, blockSeed := polynomialDesign(size, t, d)
+		sets = append(sets, block...)
+		d += blockSeed
+		remaining -= size
+	}
+
+	return sets, d
+}
+
+// parity returns the parity of the set bits in v.
+func parity(v uint64) int {
+	v ^= v >> 32
+	v ^= v >> 16
+	v ^= v >> 8
+	v ^= v >> 4
+	v ^= v >> 2
+	v ^= v >> 1
+	return int(v & 0x01)
+}
@@ -0,0 +1,184 @@
+package debias_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+// designSums returns the maximum of Σ_{j<i} 2^|S_i ∩ S_j| over all sets
+// and checks the set sizes and indices.
+func designSums(t *testing.T, sets [][]int, size, d int) float64 {
+	var (
+		members = make([]map[int]bool, len(sets))
+		max     float64
+	)
+	for i, set := range sets {
+		if len(set) != size {
+			t.Fatalf("set %d has %d elements, expected %d", i, len(set), size)
+		}
+
+		members[i] = make(map[int]bool, len(set))
+		for _, k := range set {
+			if k < 0 || k >= d {
+				t.Fatalf("set %d contains index %d outside of the seed length %d", i, k, d)
+			}
+			members[i][k] = true
+		}
+		if len(members[i]) != size {
+			t.Fatalf("set %d contains duplicate indices", i)
+		}
+
+		var sum float64
+		for j := 0; j < i; j++ {
+			var overlap int
+			for _, k := range set {
+				if members[j][k] {
+					overlap++
+				}
+			}
+			sum += math.Exp2(float64(overlap))
+		}
+		if sum > max {
+			max = sum
+		}
+	}
+	return max
+}
+
+func TestWeakDesigns(t *testing.T) {
+	for _, te := range []struct{ m, size int }{
+		{10, 8},
+		{300, 16},
+		{800, 24},
+	} {
+		for _, design := range []debias.WeakDesign{debias.PolynomialDesign{}, debias.BlockDesign{}} {
+			sets, d := design.Sets(te.m, te.size)
+			if len(sets) != te.m {
+				t.Fatalf("%T: expected %d sets, got %d", design, te.m, len(sets))
+			}
+
+			// weak design: Σ_{j<i} 2^|S_i ∩ S_j| <= r(m-1)
+			if sum := designSums(t, sets, te.size, d); sum > design.Overlap()*float64(te.m-1) {
+				t.Fatalf("%T with %d sets of size %d is not a weak design: sum %f", design, te.m, te.size, sum)
+			}
+		}
+	}
+}
+
+func TestOneBitMask(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+
+	// the epsilons select fields of 32, 64 and 128 bits for the polynomial hash, 1000 bits end in a partial chunk
+	for _, epsilon := range []float64{math.Exp2(-10), math.Exp2(-20), math.Exp2(-40)} {
+		for _, fn := range []debias.OneBitFunc{debias.NewPolynomialHash, debias.NewXORCode} {
+			const n = 1000
+			ex, err := fn(n, 0.7, epsilon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lin, ok := ex.(debias.LinearOneBitExtractor)
+			if !ok {
+				t.Fatalf("%T is not linear", ex)
+			}
+
+			x := make([]byte, n/8)
+			seed := make([]byte, ex.SeedLength())
+			for i := 0; i < 50; i++ {
+				rng.Read(x)
+				for j := range seed {
+					seed[j] = byte(rng.Intn(2))
+				}
+
+				var ones int
+				for w, m := range lin.Mask(n, seed) {
+					var word uint64
+					for b := 0; b < 8 && w*8+b < len(x); b++ {
+						word |= uint64(x[w*8+b]) << (56 - 8*uint(b))
+					}
+					ones += bits.OnesCount64(word & m)
+				}
+				if byte(ones&0x01) != ex.ExtractBit(x, seed) {
+					t.Fatalf("%T with epsilon %g: mask differs from the extracted bit", ex, epsilon)
+				}
+			}
+		}
+	}
+}
+
+func trevisanOutput(t *testing.T, cfg debias.TrevisanConfig, data []byte) (*debias.Trevisan, []byte) {
+	tr, err := debias.NewTrevisan(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(debias.NewReader(bytes.NewReader(data), tr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr, out
+}
+
+func TestTrevisan(t *testing.T) {
+	data := biased(16*1024, 0.8, 10)
+
+	for _, oneBit := range []debias.OneBitFunc{debias.NewPolynomialHash, debias.NewXORCode} {
+		for _, design := range []debias.WeakDesign{debias.PolynomialDesign{}, debias.BlockDesign{}} {
+
+			cfg := debias.TrevisanConfig{
+				BlockSize:  1024,
+				MinEntropy: 0.7,
+				Epsilon:    math.Exp2(-20),
+				OneBit:     oneBit,
+				Design:     design,
+			}
+
+			tr, out := trevisanOutput(t, cfg, data)
+
+			m := tr.OutputSize()
+			if m <= 0 || float64(m) > 0.7*1024/design.Overlap() {
+				t.Fatalf("%T: unexpected output size %d", design, m)
+			}
+			if blocks := len(data) * 8 / 1024; len(out) != (blocks*m+7)/8 {
+				t.Fatal("unexpected number of output bytes: ", len(out), " for ", m, " bits per block")
+			}
+
+			var ones int
+			for _, b := range out[:len(out)-1] {
+				ones += bits.OnesCount8(b)
+			}
+			if ratio := float64(ones) / float64((len(out)-1)*8); ratio < 0.48 || ratio > 0.52 {
+				t.Fatalf("%T: output is biased: ones ratio %f", design, ratio)
+			}
+
+			// the same seed results in the same output
+			cfg.Seed = tr.Seed()
+			if _, again := trevisanOutput(t, cfg, data); !bytes.Equal(out, again) {
+				t.Fatal("expected identical output for the same seed")
+			}
+		}
+	}
+
+	_, err := debias.NewTrevisan(debias.TrevisanConfig{BlockSize: 64, MinEntropy: 0.1, Epsilon: math.Exp2(-20)})
+	if err == nil {
+		t.Fatal("expected an error for too little min-entropy")
+	}
+}
+
+func TestTrevisanMode(t *testing.T) {
+	defer func(size int) {
+		debias.DefaultTrevisanBlockSize = size
+	}(debias.DefaultTrevisanBlockSize)
+	debias.DefaultTrevisanBlockSize = 1024
+
+	s, _ := runFile(t, "in.bin", biased(16*1024, 0.8, 11), debias.ModeTrevisan)
+	if s.BitsOut == 0 || s.Efficiency <= 0 || s.Efficiency >= debias.DefaultTrevisanMinEntropy {
+		t.Fatal("unexpected stats: ", s.BitsOut, " bits, efficiency ", s.Efficiency)
+	}
+	checkSeededMode(t, debias.ModeTrevisan, biased(16*1024, 0.8, 11))
+}
@@ -0,0 +1,216 @@
+package debias
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// TwoSourceVariant selects the function a two-source extractor applies to the aligned blocks.
+type TwoSourceVariant int
+
+const (
+	// InnerProduct outputs the inner product of the blocks over GF(2), the Hadamard extractor.
+	// This is a two-source extractor if the min-entropies k1 and k2 of the blocks satisfy
+	// k1 + k2 > n + 2 log(1/ε), as shown by Chor and Goldreich (1988).
+	InnerProduct TwoSourceVariant = iota
+
+	// ChorGoldreich interprets the blocks as elements of GF(2^n) and outputs the leading bits of their product.
+	// This extends the inner product to multiple output bits, which are close to uniform
+	// for m < (k1 + k2 - n) / 2 - log(1/ε).
+	ChorGoldreich
+
+	// Bourgain outputs the leading bits of x*y + x²*y² in GF(2^n), the inner product of (x, x²) and (y, y²).
+	// Following Bourgain (2005) this works for min-entropy rates slightly below one half.
+	Bourgain
+)
+
+// TwoSourceConfig holds the parameters of a two-source extractor.
+type TwoSourceConfig struct {
+
+	// Variant selects the extraction function.
+	Variant TwoSourceVariant
+
+	// BlockSize is the size of the aligned blocks in bits, it must be a multiple of eight.
+	// The ChorGoldreich and Bourgain variants support 32, 64 and 128 bits.
+	BlockSize int
+
+	// OutputBits is the number of bits produced per block for the ChorGoldreich and Bourgain variants,
+	// it defaults to 1. The InnerProduct variant always produces a single bit.
+	OutputBits int
+
+	// Buffer is the number of bytes buffered for each source while waiting for the other one.
+	// It defaults to 64 chunks of MaxChunkSize.
+	Buffer int
+}
+
+// TwoSource is an Extractor combining two independent weak sources into a single output.
+// Each source is read in its own goroutine and buffered, so a faster source is drained
+// at its own rate while the extractor waits for the slower one.
+// The output ends when one of the sources ends, the input of an incomplete block is discarded.
+type TwoSource struct {
+	cfg     TwoSourceConfig
+	field   gfField
+	sources [2]*sourceBuffer
+	blocks  [2][]byte
+
+	out     bytes.Buffer
+	bits    bitWriter
+	padding Padding
+	err     error
+
+	numBlocks int64
+}
+
+// NewTwoSource returns a TwoSource extractor reading the independent sources a and b.
+func NewTwoSource(a, b io.Reader, cfg TwoSourceConfig) (*TwoSource, error) {
+	if cfg.BlockSize <= 0 || cfg.BlockSize%8 != 0 {
+		return nil, fmt.Errorf("%w: two-source block size %d", ErrInvalidSize, cfg.BlockSize)
+	}
+	if cfg.OutputBits == 0 || cfg.Variant == InnerProduct {
+		cfg.OutputBits = 1
+	}
+	if cfg.OutputBits < 0 || cfg.OutputBits > cfg.BlockSize {
+		return nil, fmt.Errorf("%w: two-source output bits %d", ErrInvalidSize, cfg.OutputBits)
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaultSourceChunks * MaxChunkSize
+	}
+
+	t := &TwoSource{cfg: cfg}
+
+	if cfg.Variant != InnerProduct {
+		var ok bool
+		for _, f := range gfFields {
+			if f.bits == cfg.BlockSize {
+				t.field, ok = f, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: no field with %d bits", ErrInvalidSize, cfg.BlockSize)
+		}
+	}
+
+	for i, src := range []io.Reader{a, b} {
+		t.sources[i] = newSourceBuffer(src, cfg.Buffer/MaxChunkSize)
+		t.blocks[i] = make([]byte, cfg.BlockSize/8)
+	}
+
+	return t, nil
+}
+
+// Read reads the extracted data into p.
+func (t *TwoSource) Read(p []byte) (int, error) {
+	for t.out.Len() == 0 && t.err == nil {
+		t.fill()
+	}
+	if t.out.Len() > 0 {
+		return t.out.Read(p)
+	}
+	return 0, t.err
+}
+
+// Close stops reading from the sources, subsequent reads return ErrClosed.
+// Close does not close the underlying sources.
+func (t *TwoSource) Close() error {
+	for _, s := range t.sources {
+		s.stop()
+	}
+	t.out.Reset()
+	t.err = ErrClosed
+	return nil
+}
+
+// fill extracts the output for the next pair of blocks.
+func (t *TwoSource) fill() {
+	for i, s := range t.sources {
+		err := s.readFull(t.blocks[i])
+		if err == nil {
+			continue
+		}
+
+		for _, s := range t.sources {
+			s.stop()
+		}
+
+		if err != io.EOF {
+			t.err = fmt.Errorf("source %d: %w", i, err)
+			return
+		}
+		if t.numBlocks == 0 {
+			t.err = ErrShortInput
+			return
+		}
+
+		// write leftover
+		t.bits.flush(&t.out, t.padding)
+		t.err = io.EOF
+		return
+	}
+	t.numBlocks++
+
+	x, y := t.blocks[0], t.blocks[1]
+
+	if t.cfg.Variant == InnerProduct {
+		var ones int
+		for i := range x {
+			ones += bits.OnesCount8(x[i] & y[i])
+		}
+		t.bits.writeBit(byte(ones&0x01), &t.out)
+		return
+	}
+
+	var (
+		a = gfFromBytes(x)
+		b = gfFromBytes(y)
+		z = t.field.mul(a, b)
+	)
+	if t.cfg.Variant == Bourgain {
+		ab2 := t.field.mul(z, z)
+		z.hi ^= ab2.hi
+		z.lo ^= ab2.lo
+	}
+
+	// leading bits of the product
+	for i := 0; i < t.cfg.OutputBits; i++ {
+		pos := t.field.bits - 1 - i
+		if pos >= 64 {
+			t.bits.writeBit(byte(z.hi>>uint(pos-64))&0x01, &t.out)
+		} else {
+			t.bits.writeBit(byte(z.lo>>uint(pos))&0x01, &t.out)
+		}
+	}
+}
+
+func (t *TwoSource) SetPadding(p Padding) {
+	t.padding = p
+}
+
+func (t *TwoSource) BitsOut() int64 {
+	return t.bits.bits
+}
+
+// ReportStats sets the statistics for both sources.
+func (t *TwoSource) ReportStats(s *Stats) {
+	s.Sources = []SourceStats{t.sources[0].stats(), t.sources[1].stats()}
+	s.BytesIn = 0
+	for _, src := range s.Sources {
+		s.BytesIn += src.BytesUsed
+	}
+	s.BitsOut = t.BitsOut()
+	if s.BytesIn > 0 {
+		s.Efficiency = float64(s.BitsOut) / float64(s.BytesIn*8)
+	}
+}
+
+// gfFromBytes builds a field element from up to 16 bytes in big endian order.
+func gfFromBytes(b []byte) gfElement {
+	var buf [16]byte
+	copy(buf[16-len(b):], b)
+	return gfElement{
+		hi: binary.BigEndian.Uint64(buf[:8]),
+		lo: binary.BigEndian.Uint64(buf[8:]),
+	}
+}
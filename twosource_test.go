@@ -0,0 +1,156 @@
+package debias_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/bits"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func twoSourceOutput(t *testing.T, a, b []byte, cfg debias.TwoSourceConfig) (*debias.TwoSource, []byte) {
+	ts, err := debias.NewTwoSource(bytes.NewReader(a), bytes.NewReader(b), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts, out
+}
+
+func TestTwoSourceInnerProduct(t *testing.T) {
+	var (
+		// inner products: 0 1 1 0 1 0 0 1
+		a = []byte{0xf0, 0x01, 0xff, 0x0f, 0x80, 0x00, 0xaa, 0x07}
+		b = []byte{0x30, 0x03, 0x01, 0xf0, 0xff, 0xff, 0x0f, 0x07}
+	)
+	ts, out := twoSourceOutput(t, a, b, debias.TwoSourceConfig{BlockSize: 8})
+	if !bytes.Equal(out, []byte{0x69}) {
+		t.Fatalf("expected 01101001 but got %08b", out)
+	}
+	if ts.BitsOut() != 8 {
+		t.Fatal("expected 8 bits, got ", ts.BitsOut())
+	}
+}
+
+func TestTwoSourceChorGoldreich(t *testing.T) {
+	// the product with the unit element keeps the leading bits of the other block
+	var (
+		a = []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+		b = []byte{0xab, 0x00, 0x00, 0x00, 0x5c, 0x12, 0x34, 0x56}
+	)
+	_, out := twoSourceOutput(t, a, b, debias.TwoSourceConfig{
+		Variant:    debias.ChorGoldreich,
+		BlockSize:  32,
+		OutputBits: 8,
+	})
+	if !bytes.Equal(out, []byte{0xab, 0x5c}) {
+		t.Fatalf("expected ab5c but got %x", out)
+	}
+}
+
+func TestTwoSourceBias(t *testing.T) {
+	var (
+		a = biased(40000, 0.7, 1)
+		b = biased(40000, 0.3, 2)
+	)
+	for _, variant := range []debias.TwoSourceVariant{debias.InnerProduct, debias.ChorGoldreich, debias.Bourgain} {
+		_, out := twoSourceOutput(t, a, b, debias.TwoSourceConfig{
+			Variant:    variant,
+			BlockSize:  64,
+			OutputBits: 2,
+		})
+
+		var ones int
+		for _, b := range out {
+			ones += bits.OnesCount8(b)
+		}
+		ratio := float64(ones) / float64(len(out)*8)
+		if ratio < 0.47 || ratio > 0.53 {
+			t.Fatalf("variant %d: unexpected ratio of ones: %f", variant, ratio)
+		}
+	}
+}
+
+func TestTwoSourceRateMismatch(t *testing.T) {
+	var (
+		a = biased(5000, 0.5, 3)
+		b = biased(8000, 0.5, 4)
+	)
+
+	// one source delivers a single byte per read
+	ts, err := debias.NewTwoSource(iotest.OneByteReader(bytes.NewReader(a)), bytes.NewReader(b), debias.TwoSourceConfig{
+		BlockSize: 128,
+		Buffer:    2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 312 complete blocks in the shorter source
+	if len(out) != 39 {
+		t.Fatal("expected 39 bytes, got ", len(out))
+	}
+
+	var s debias.Stats
+	ts.ReportStats(&s)
+	if len(s.Sources) != 2 {
+		t.Fatal("expected stats for two sources, got ", len(s.Sources))
+	}
+	for i, src := range s.Sources {
+		if src.BytesUsed != 312*16 {
+			t.Fatalf("source %d: expected %d bytes used, got %d", i, 312*16, src.BytesUsed)
+		}
+		if src.BytesRead < src.BytesUsed {
+			t.Fatalf("source %d: read %d bytes but used %d", i, src.BytesRead, src.BytesUsed)
+		}
+	}
+	if s.BitsOut != 312 {
+		t.Fatal("expected 312 bits, got ", s.BitsOut)
+	}
+
+	if err := ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.Read(make([]byte, 1)); err != debias.ErrClosed {
+		t.Fatal("expected ErrClosed, got ", err)
+	}
+}
+
+func TestTwoSourceErrors(t *testing.T) {
+	for _, cfg := range []debias.TwoSourceConfig{
+		{BlockSize: 12},
+		{BlockSize: 0},
+		{BlockSize: 48, Variant: debias.ChorGoldreich},
+		{BlockSize: 32, Variant: debias.Bourgain, OutputBits: 33},
+	} {
+		_, err := debias.NewTwoSource(bytes.NewReader(nil), bytes.NewReader(nil), cfg)
+		if !errors.Is(err, debias.ErrInvalidSize) {
+			t.Fatalf("config %+v: expected ErrInvalidSize, got %v", cfg, err)
+		}
+	}
+
+	ts, err := debias.NewTwoSource(bytes.NewReader([]byte{0x01}), bytes.NewReader(nil), debias.TwoSourceConfig{BlockSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(ts); err != debias.ErrShortInput {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+
+	ts, err = debias.NewTwoSource(bytes.NewReader([]byte{0x01}), &brokenReader{}, debias.TwoSourceConfig{BlockSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(ts)
+	checkSourceError(t, err, 0)
+}
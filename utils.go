@@ -18,6 +18,10 @@ func pkcs5Padding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padText...)
 }
 
+// ShannonEntropy returns the Shannon entropy of data with the per byte entropy rounded up to whole bits.
+//
+// Deprecated: the rounding hides the difference between e.g. 7.01 and 7.99 bits per byte,
+// use EntropyTotalBits, EntropyBitsPerByte or an Entropy accumulator instead.
 func ShannonEntropy(data []byte) int {
 
 	var (
@@ -37,3 +41,45 @@ func ShannonEntropy(data []byte) int {
 
 	return int(math.Ceil(sum*-1)) * len(data)
 }
+
+// bitWriter packs single bits into bytes, starting with the most significant bit.
+type bitWriter struct {
+	outByte  byte
+	bitCount uint
+
+	// number of bits written
+	bits int64
+}
+
+// writeBit appends a bit and writes the byte to out once it is full.
+func (w *bitWriter) writeBit(bit byte, out *bytes.Buffer) {
+	if bit == 1 {
+		// store a 1 in our bitbuffer
+		w.outByte = setBit(w.outByte, 7-w.bitCount)
+	} // else: leave the buffer alone, it's already 0 at this bit
+
+	w.bitCount++
+	w.bits++
+
+	// is the byte full?
+	if w.bitCount == 8 {
+		out.WriteByte(w.outByte)
+		w.outByte = byte(0)
+		w.bitCount = 0
+	}
+}
+
+// flush handles the partially filled byte according to the padding.
+// With PadZeroes it is written to out padded with trailing zeroes, with DropPartial its bits are discarded.
+func (w *bitWriter) flush(out *bytes.Buffer, padding Padding) {
+	if w.bitCount == 0 {
+		return
+	}
+	if padding == DropPartial {
+		w.bits -= int64(w.bitCount)
+	} else {
+		out.WriteByte(w.outByte)
+	}
+	w.outByte = byte(0)
+	w.bitCount = 0
+}
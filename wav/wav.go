@@ -0,0 +1,321 @@
+// Package wav decodes RIFF/WAVE files, so extractors only see the sample payload
+// and not the header and metadata chunks.
+//
+// Integer PCM with 8, 16, 24 or 32 bits and 32 bit IEEE float samples are supported,
+// in plain or WAVE_FORMAT_EXTENSIBLE headers with any number of channels.
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/dreadl0ck/debias/internal/sample"
+)
+
+var (
+	// ErrFormat is returned if the data is not a valid WAVE file.
+	ErrFormat = errors.New("wav: invalid file")
+
+	// ErrUnsupported is returned for sample formats that cannot be decoded.
+	ErrUnsupported = errors.New("wav: unsupported sample format")
+
+	// ErrChannel is returned if a selected channel does not exist in the file.
+	ErrChannel = errors.New("wav: invalid channel")
+)
+
+// Format codes of the fmt chunk.
+const (
+	FormatPCM        = 0x0001
+	FormatFloat      = 0x0003
+	FormatExtensible = 0xfffe
+)
+
+// Header holds the metadata of a WAVE file.
+type Header struct {
+
+	// Format is the sample format, FormatPCM or FormatFloat.
+	// For WAVE_FORMAT_EXTENSIBLE files it is taken from the sub format and Extensible is set.
+	Format     uint16 `json:"format"`
+	Extensible bool   `json:"extensible"`
+
+	Channels      int `json:"channels"`
+	SampleRate    int `json:"sampleRate"`
+	BitsPerSample int `json:"bitsPerSample"`
+
+	// BlockAlign is the number of bytes per frame, holding one sample of each channel.
+	BlockAlign int `json:"blockAlign"`
+
+	// ValidBits and ChannelMask are only set for WAVE_FORMAT_EXTENSIBLE files.
+	ValidBits   int    `json:"validBits,omitempty"`
+	ChannelMask uint32 `json:"channelMask,omitempty"`
+
+	// DataSize is the size of the sample payload in bytes, -1 if the size was not known when the file was written.
+	DataSize int64 `json:"dataSize"`
+}
+
+// BytesPerSample returns the number of bytes per sample of one channel.
+func (h *Header) BytesPerSample() int {
+	return (h.BitsPerSample + 7) / 8
+}
+
+// Config selects the decoded channels.
+type Config struct {
+
+	// Channels lists the channels to pass on, starting at 0, nil passes all channels.
+	Channels []int
+}
+
+// Info is the header of a file along with the selected channels.
+type Info struct {
+	Header
+	Channels []int `json:"selectedChannels"`
+}
+
+// Reader returns the sample payload of the selected channels of a WAVE file, in the byte order of the file.
+type Reader struct {
+	src      io.Reader
+	header   Header
+	channels []int
+
+	// remaining payload bytes, -1 if unknown
+	remaining int64
+
+	frame []byte
+	sel   []byte
+	out   []byte
+	err   error
+
+	// decoder of the samples passed on by Read, created by ReadSamples
+	samples *sample.Decoder
+}
+
+// NewReader parses the header of the WAVE file read from r and returns a Reader positioned at the sample payload.
+func NewReader(r io.Reader, cfg Config) (*Reader, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := cfg.Channels
+	if channels == nil {
+		channels = make([]int, h.Channels)
+		for i := range channels {
+			channels[i] = i
+		}
+	}
+	if len(channels) == 0 {
+		return nil, ErrChannel
+	}
+	for _, c := range channels {
+		if c < 0 || c >= h.Channels {
+			return nil, fmt.Errorf("%w: %d of %d", ErrChannel, c, h.Channels)
+		}
+	}
+
+	return &Reader{
+		src:       r,
+		header:    *h,
+		channels:  append([]int(nil), channels...),
+		remaining: h.DataSize,
+		frame:     make([]byte, h.BlockAlign),
+	}, nil
+}
+
+func readHeader(r io.Reader) (*Header, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: missing RIFF/WAVE signature", ErrFormat)
+	}
+
+	var h *Header
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("%w: no data chunk: %v", ErrFormat, err)
+		}
+		var (
+			id   = string(chunk[0:4])
+			size = int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		)
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("%w: fmt chunk: %v", ErrFormat, err)
+			}
+			var err error
+			h, err = parseFormat(body)
+			if err != nil {
+				return nil, err
+			}
+		case "data":
+			if h == nil {
+				return nil, fmt.Errorf("%w: data chunk before fmt chunk", ErrFormat)
+			}
+			h.DataSize = size
+			if size == 0 || size == math.MaxUint32 {
+				// written by a stream that did not seek back to fill in the size
+				h.DataSize = -1
+			}
+			return h, nil
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, size); err != nil {
+				return nil, fmt.Errorf("%w: %s chunk: %v", ErrFormat, id, err)
+			}
+		}
+
+		// chunks are padded to an even size
+		if size%2 == 1 {
+			if _, err := io.CopyN(ioutil.Discard, r, 1); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+		}
+	}
+}
+
+func parseFormat(b []byte) (*Header, error) {
+	if len(b) < 16 {
+		return nil, fmt.Errorf("%w: fmt chunk too short", ErrFormat)
+	}
+	le := binary.LittleEndian
+	h := &Header{
+		Format:        le.Uint16(b[0:2]),
+		Channels:      int(le.Uint16(b[2:4])),
+		SampleRate:    int(le.Uint32(b[4:8])),
+		BlockAlign:    int(le.Uint16(b[12:14])),
+		BitsPerSample: int(le.Uint16(b[14:16])),
+	}
+	if h.Format == FormatExtensible {
+		if len(b) < 40 {
+			return nil, fmt.Errorf("%w: extensible fmt chunk too short", ErrFormat)
+		}
+		h.Extensible = true
+		h.ValidBits = int(le.Uint16(b[18:20]))
+		h.ChannelMask = le.Uint32(b[20:24])
+
+		// the sub format GUID starts with the format code
+		h.Format = le.Uint16(b[24:26])
+	}
+
+	if h.Channels == 0 || h.BlockAlign != h.Channels*h.BytesPerSample() {
+		return nil, fmt.Errorf("%w: %d channels with block align %d", ErrFormat, h.Channels, h.BlockAlign)
+	}
+	switch {
+	case h.Format == FormatPCM && (h.BitsPerSample == 8 || h.BitsPerSample == 16 || h.BitsPerSample == 24 || h.BitsPerSample == 32):
+	case h.Format == FormatFloat && h.BitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("%w: format %#x with %d bits", ErrUnsupported, h.Format, h.BitsPerSample)
+	}
+	return h, nil
+}
+
+// Header returns the metadata of the file.
+func (r *Reader) Header() *Header {
+	h := r.header
+	return &h
+}
+
+// Info returns the metadata of the file and the selected channels.
+func (r *Reader) Info() *Info {
+	return &Info{
+		Header:   r.header,
+		Channels: append([]int(nil), r.channels...),
+	}
+}
+
+// Read reads the payload of the selected channels into p.
+// A trailing partial frame is dropped.
+func (r *Reader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(r.out) == 0 {
+			if r.err != nil {
+				break
+			}
+			r.nextFrame()
+			continue
+		}
+		c := copy(p[n:], r.out)
+		r.out = r.out[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// nextFrame reads one frame and queues the samples of the selected channels.
+func (r *Reader) nextFrame() {
+	size := int64(len(r.frame))
+	if r.remaining >= 0 && r.remaining < size {
+		r.err = io.EOF
+		return
+	}
+	if _, err := io.ReadFull(r.src, r.frame); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.err = err
+		return
+	}
+	if r.remaining >= 0 {
+		r.remaining -= size
+	}
+
+	if len(r.channels) == r.header.Channels && isIdentity(r.channels) {
+		r.out = r.frame
+		return
+	}
+	bps := r.header.BytesPerSample()
+	r.sel = r.sel[:0]
+	for _, c := range r.channels {
+		r.sel = append(r.sel, r.frame[c*bps:(c+1)*bps]...)
+	}
+	r.out = r.sel
+}
+
+func isIdentity(channels []int) bool {
+	for i, c := range channels {
+		if c != i {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadSamples decodes the samples of the selected channels into dst, interleaved by frame.
+// Integer samples keep their value, 8 bit samples are shifted to be signed, float samples are returned as stored.
+// ReadSamples and Read consume the same payload.
+func (r *Reader) ReadSamples(dst []float64) (int, error) {
+	if r.samples == nil {
+		r.samples = sample.NewDecoder(r, r.header.BytesPerSample(), r.header.Sample)
+	}
+	return r.samples.ReadSamples(dst)
+}
+
+// Sample decodes one sample of BytesPerSample bytes, as returned by ReadSamples.
+func (h *Header) Sample(b []byte) float64 {
+	le := binary.LittleEndian
+	if h.Format == FormatFloat {
+		return float64(math.Float32frombits(le.Uint32(b)))
+	}
+	switch len(b) {
+	case 1:
+		return float64(int(b[0]) - 128)
+	case 2:
+		return float64(int16(le.Uint16(b)))
+	case 3:
+		return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8)
+	default:
+		return float64(int32(le.Uint32(b)))
+	}
+}
@@ -0,0 +1,222 @@
+package wav_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/dreadl0ck/debias/wav"
+)
+
+// build returns a WAVE file with the given fmt chunk body and payload,
+// preceded by a LIST chunk of odd size and followed by a trailing chunk.
+func build(fmtChunk, payload []byte) []byte {
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+
+	chunk := func(id string, data []byte) {
+		body.WriteString(id)
+		binary.Write(&body, binary.LittleEndian, uint32(len(data)))
+		body.Write(data)
+		if len(data)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	chunk("LIST", []byte("INFOISFT\x03\x00\x00\x00ab\x00"))
+	chunk("fmt ", fmtChunk)
+	chunk("data", payload)
+	chunk("id3 ", []byte("trailing metadata"))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(body.Len()))
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func pcmFormat(format uint16, channels, rate, bits int) []byte {
+	var b bytes.Buffer
+	align := channels * bits / 8
+	for _, v := range []interface{}{format, uint16(channels), uint32(rate), uint32(rate * align), uint16(align), uint16(bits)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func extensibleFormat(sub uint16, channels, rate, bits, valid int, mask uint32) []byte {
+	b := bytes.NewBuffer(pcmFormat(wav.FormatExtensible, channels, rate, bits))
+	for _, v := range []interface{}{uint16(22), uint16(valid), mask, sub} {
+		binary.Write(b, binary.LittleEndian, v)
+	}
+	b.Write([]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71})
+	return b.Bytes()
+}
+
+func TestPayload(t *testing.T) {
+	// two channels of 16 bit samples, three frames and a partial one
+	payload := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+	data := build(pcmFormat(wav.FormatPCM, 2, 48000, 16), payload)
+
+	r, err := wav.NewReader(bytes.NewReader(data), wav.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := r.Header()
+	if h.Format != wav.FormatPCM || h.Channels != 2 || h.SampleRate != 48000 || h.BitsPerSample != 16 || h.DataSize != 14 {
+		t.Fatalf("unexpected header: %+v", h)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, payload[:12]) {
+		t.Fatal("unexpected payload: ", out)
+	}
+
+	r, err = wav.NewReader(bytes.NewReader(data), wav.Config{Channels: []int{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, []byte{3, 4, 7, 8, 11, 12}) {
+		t.Fatal("unexpected payload of channel 1: ", out)
+	}
+	if info := r.Info(); len(info.Channels) != 1 || info.Channels[0] != 1 || info.Header.Channels != 2 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  []byte
+		payload []byte
+		want    []float64
+	}{
+		{
+			name:    "8 bit",
+			format:  pcmFormat(wav.FormatPCM, 1, 8000, 8),
+			payload: []byte{0, 128, 255},
+			want:    []float64{-128, 0, 127},
+		},
+		{
+			name:    "16 bit",
+			format:  pcmFormat(wav.FormatPCM, 1, 8000, 16),
+			payload: []byte{0x00, 0x80, 0xff, 0xff, 0xff, 0x7f},
+			want:    []float64{-32768, -1, 32767},
+		},
+		{
+			name:    "24 bit",
+			format:  pcmFormat(wav.FormatPCM, 1, 8000, 24),
+			payload: []byte{0x00, 0x00, 0x80, 0x01, 0x00, 0x00, 0xff, 0xff, 0xff},
+			want:    []float64{-8388608, 1, -1},
+		},
+		{
+			name:    "32 bit",
+			format:  pcmFormat(wav.FormatPCM, 1, 8000, 32),
+			payload: []byte{0x00, 0x00, 0x00, 0x80, 0x02, 0x00, 0x00, 0x00},
+			want:    []float64{math.MinInt32, 2},
+		},
+		{
+			name:    "float",
+			format:  pcmFormat(wav.FormatFloat, 1, 8000, 32),
+			payload: []byte{0x00, 0x00, 0x00, 0x3f, 0x00, 0x00, 0x80, 0xbf},
+			want:    []float64{0.5, -1},
+		},
+		{
+			name:    "extensible 24 bit in 32",
+			format:  extensibleFormat(wav.FormatPCM, 1, 8000, 32, 24, 0x4),
+			payload: []byte{0x00, 0x01, 0x00, 0x00},
+			want:    []float64{256},
+		},
+	}
+	for _, tt := range tests {
+		r, err := wav.NewReader(bytes.NewReader(build(tt.format, tt.payload)), wav.Config{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := make([]float64, 10)
+		n, err := r.ReadSamples(got)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if n != len(tt.want) {
+			t.Fatalf("%s: got %d samples, want %d", tt.name, n, len(tt.want))
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: sample %d is %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestExtensible(t *testing.T) {
+	data := build(extensibleFormat(wav.FormatFloat, 4, 96000, 32, 32, 0x33), make([]byte, 4*4*10))
+
+	r, err := wav.NewReader(bytes.NewReader(data), wav.Config{Channels: []int{3, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := r.Header()
+	if !h.Extensible || h.Format != wav.FormatFloat || h.ValidBits != 32 || h.ChannelMask != 0x33 || h.Channels != 4 {
+		t.Fatalf("unexpected header: %+v", h)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2*4*10 {
+		t.Fatal("unexpected payload size: ", len(out))
+	}
+}
+
+func TestStreamedSize(t *testing.T) {
+	data := build(pcmFormat(wav.FormatPCM, 1, 8000, 8), nil)
+
+	// a size of 0xffffffff is written by streams, the payload extends to the end of the file
+	i := bytes.Index(data, []byte("data"))
+	binary.LittleEndian.PutUint32(data[i+4:], math.MaxUint32)
+	data = append(data[:i+8], 1, 2, 3)
+
+	r, err := wav.NewReader(bytes.NewReader(data), wav.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Header().DataSize != -1 {
+		t.Fatal("unexpected data size: ", r.Header().DataSize)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, []byte{1, 2, 3}) {
+		t.Fatal("unexpected payload: ", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := wav.NewReader(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00AVI ")), wav.Config{}); !errors.Is(err, wav.ErrFormat) {
+		t.Fatal("expected ErrFormat, got ", err)
+	}
+	if _, err := wav.NewReader(bytes.NewReader(nil), wav.Config{}); !errors.Is(err, wav.ErrFormat) {
+		t.Fatal("expected ErrFormat, got ", err)
+	}
+
+	// 64 bit float
+	data := build(pcmFormat(wav.FormatFloat, 1, 8000, 64), nil)
+	if _, err := wav.NewReader(bytes.NewReader(data), wav.Config{}); !errors.Is(err, wav.ErrUnsupported) {
+		t.Fatal("expected ErrUnsupported, got ", err)
+	}
+
+	data = build(pcmFormat(wav.FormatPCM, 2, 8000, 16), nil)
+	if _, err := wav.NewReader(bytes.NewReader(data), wav.Config{Channels: []int{2}}); !errors.Is(err, wav.ErrChannel) {
+		t.Fatal("expected ErrChannel, got ", err)
+	}
+}
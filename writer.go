@@ -0,0 +1,130 @@
+package debias
+
+import (
+	"bytes"
+	"io"
+)
+
+// Writer applies an Algorithm to the biased data written to it
+// and writes the debiased output to an underlying io.Writer.
+// It does not start any goroutines, all work happens in Write and Close.
+type Writer struct {
+	dst io.Writer
+	alg Algorithm
+	out bytes.Buffer
+	err error
+
+	// number of bytes written to the Writer
+	numBytes int64
+
+	// number of bytes written to the underlying writer
+	bytesOut int64
+}
+
+// NewWriter returns a Writer applying alg to the data written to it and writing the output to dst.
+func NewWriter(dst io.Writer, alg Algorithm) *Writer {
+	return &Writer{
+		dst: dst,
+		alg: alg,
+	}
+}
+
+// NewVonNeumannWriter returns a Writer applying Von Neumann debiasing and writing the output to dst.
+func NewVonNeumannWriter(dst io.Writer) *Writer {
+	return NewWriter(dst, &vonNeumann{})
+}
+
+// NewKaminskyWriter returns a Writer applying Kaminsky debiasing and writing the output to dst,
+// the output is encrypted every blockSize input bytes.
+// It returns ErrInvalidSize if blockSize is below one.
+func NewKaminskyWriter(dst io.Writer, blockSize int64) (*Writer, error) {
+	k, err := newKaminsky(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(dst, k), nil
+}
+
+// Write debiases p and writes all completed output to the underlying writer.
+// Bits that do not fill a complete output byte yet are kept until the next Write or Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	err := w.alg.Process(p, &w.out)
+	if err != nil {
+		w.err = err
+		return 0, err
+	}
+	w.numBytes += int64(len(p))
+
+	err = w.writeOut()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close flushes the partial state of the algorithm to the underlying writer.
+// It returns ErrShortInput if nothing has been written.
+// Close does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		if w.err == ErrClosed {
+			return nil
+		}
+		return w.err
+	}
+	w.err = ErrClosed
+
+	if w.numBytes == 0 {
+		return ErrShortInput
+	}
+
+	err := w.alg.Flush(&w.out)
+	if err != nil {
+		return err
+	}
+
+	return w.writeOut()
+}
+
+// writeOut drains the output buffer into the underlying writer.
+func (w *Writer) writeOut() error {
+	if w.out.Len() == 0 {
+		return nil
+	}
+	n, err := w.out.WriteTo(w.dst)
+	w.bytesOut += n
+	if err != nil {
+		w.err = err
+	}
+	return err
+}
+
+// SetPadding configures how the algorithm handles a partially filled output byte at the end of the input.
+// It has no effect if the algorithm does not implement Padder.
+func (w *Writer) SetPadding(p Padding) {
+	if pd, ok := w.alg.(Padder); ok {
+		pd.SetPadding(p)
+	}
+}
+
+// BitsOut returns the number of valid output bits produced so far, padding bits are not counted.
+// If the algorithm does not implement BitCounter, all bits of the output bytes are counted.
+func (w *Writer) BitsOut() int64 {
+	if bc, ok := w.alg.(BitCounter); ok {
+		return bc.BitsOut()
+	}
+	return w.bytesOut * 8
+}
+
+// ReportStats adds the details reported by the algorithm to s.
+// It has no effect if the algorithm does not implement StatsReporter.
+func (w *Writer) ReportStats(s *Stats) {
+	if sr, ok := w.alg.(StatsReporter); ok {
+		sr.ReportStats(s)
+	}
+}
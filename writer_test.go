@@ -0,0 +1,93 @@
+package debias_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dreadl0ck/debias"
+)
+
+func TestVonNeumannWriter(t *testing.T) {
+	for i, te := range tests {
+
+		var out bytes.Buffer
+		w := debias.NewVonNeumannWriter(&out)
+
+		// feed the input bit by bit to make sure the partial state is kept between writes
+		for _, b := range bitString(te.in).bytes() {
+			_, err := w.Write([]byte{b})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := ioutil.ReadAll(debias.NewVonNeumann(bytes.NewReader(bitString(te.in).bytes())))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out.Bytes(), expected) {
+			t.Fatalf("test #%d: expected %08b but got %08b", i, expected, out.Bytes())
+		}
+	}
+}
+
+func TestWriterComposition(t *testing.T) {
+	var (
+		data      = bytes.Repeat(bitString("10011001").bytes(), 100000)
+		neumann   bytes.Buffer
+		kaminsky  bytes.Buffer
+		bufWriter = bufio.NewWriter(&neumann)
+		vn        = debias.NewVonNeumannWriter(bufWriter)
+	)
+	km, err := debias.NewKaminskyWriter(&kaminsky, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = io.Copy(io.MultiWriter(vn, km), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = vn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = km.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = bufWriter.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10011001 yields 1010 for every byte
+	if neumann.Len() != len(data)/2 {
+		t.Fatal("unexpected number of output bytes: ", neumann.Len())
+	}
+	if kaminsky.Len() == 0 || kaminsky.Len()%16 != 0 {
+		t.Fatal("expected a multiple of the AES block size, got ", kaminsky.Len(), " bytes")
+	}
+
+	if _, err = vn.Write(data); err != debias.ErrClosed {
+		t.Fatal("expected ErrClosed, got ", err)
+	}
+}
+
+func TestWriterShortInput(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := debias.NewVonNeumannWriter(&out).Close(); err != debias.ErrShortInput {
+		t.Fatal("expected ErrShortInput, got ", err)
+	}
+	if out.Len() != 0 {
+		t.Fatal("unexpected output: ", out.Bytes())
+	}
+}